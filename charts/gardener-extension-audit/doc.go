@@ -1,4 +1,4 @@
-//go:generate sh -c "../../vendor/github.com/gardener/gardener/hack/generate-controller-registration.sh audit . $(cat ../../VERSION) ../../example/controller-registration.yaml Extension:audit"
+//go:generate sh -c "../../vendor/github.com/gardener/gardener/hack/generate-controller-registration.sh --pod-security-enforce=restricted audit . $(cat ../../VERSION) ../../example/controller-registration.yaml Extension:audit"
 
 // Package chart enables go:generate support for generating the correct controller registration.
 package chart
@@ -6,6 +6,7 @@ import (
 
 	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
 	"github.com/metal-stack/gardener-extension-audit/pkg/controller/audit"
+	"github.com/metal-stack/gardener-extension-audit/pkg/controller/healthcheck"
 
 	extensionscontroller "github.com/gardener/gardener/extensions/pkg/controller"
 	heartbeatcontroller "github.com/gardener/gardener/extensions/pkg/controller/heartbeat"
@@ -49,6 +50,9 @@ func NewControllerManagerCommand(ctx context.Context) *cobra.Command {
 
 	options.optionAggregator.AddFlags(cmd.Flags())
 
+	cmd.AddCommand(NewDiffCommand(ctx))
+	cmd.AddCommand(NewRenderCommand(ctx))
+
 	return cmd
 }
 
@@ -84,6 +88,9 @@ func (o *Options) run(ctx context.Context) error {
 
 	ctrlConfig := o.auditOptions.Completed()
 	ctrlConfig.Apply(&audit.DefaultAddOptions.Config)
+	ctrlConfig.Apply(&healthcheck.DefaultAddOptions.Config)
+	ctrlConfig.ApplyHealthCheckConfig(&healthcheck.DefaultAddOptions.HealthCheckConfig)
+	o.healthOptions.Completed().Apply(&healthcheck.DefaultAddOptions.Controller)
 	o.controllerOptions.Completed().Apply(&audit.DefaultAddOptions.ControllerOptions)
 	o.reconcileOptions.Completed().Apply(&audit.DefaultAddOptions.IgnoreOperationAnnotation)
 	o.heartbeatOptions.Completed().Apply(&heartbeatcontroller.DefaultAddOptions)
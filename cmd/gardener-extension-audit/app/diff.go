@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
+	configapi "github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+	auditcmd "github.com/metal-stack/gardener-extension-audit/pkg/cmd"
+	"github.com/metal-stack/gardener-extension-audit/pkg/controller/audit"
+
+	extensionscontroller "github.com/gardener/gardener/extensions/pkg/controller"
+	controllercmd "github.com/gardener/gardener/extensions/pkg/controller/cmd"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewDiffCommand creates a new command that shows for every shoot of a seed how a rollout of this version of the
+// extension with the given controller configuration would change the fluent-bit configuration of its audit webhook
+// backend. It only reads from the seed.
+func NewDiffCommand(ctx context.Context) *cobra.Command {
+	var (
+		restOptions  = &controllercmd.RESTOptions{}
+		auditOptions = &auditcmd.AuthOptions{}
+		namespace    string
+	)
+
+	cmd := &cobra.Command{
+		Use:           "diff",
+		Short:         "shows how a rollout of this version would change the fluent-bit configuration of the shoots of a seed.",
+		SilenceErrors: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := restOptions.Complete(); err != nil {
+				return fmt.Errorf("error completing rest options: %w", err)
+			}
+			if err := auditOptions.Complete(); err != nil {
+				return fmt.Errorf("error completing controller configuration: %w", err)
+			}
+
+			scheme := runtime.NewScheme()
+			if err := extensionscontroller.AddToScheme(scheme); err != nil {
+				return err
+			}
+			if err := install.AddToScheme(scheme); err != nil {
+				return err
+			}
+
+			c, err := client.New(restOptions.Completed().Config, client.Options{Scheme: scheme})
+			if err != nil {
+				return fmt.Errorf("unable to create client: %w", err)
+			}
+
+			var config configapi.ControllerConfiguration
+			auditOptions.Completed().Apply(&config)
+
+			cmd.SilenceUsage = true
+			return diff(ctx, cmd.OutOrStdout(), c, config, namespace)
+		},
+	}
+
+	restOptions.AddFlags(cmd.Flags())
+	auditOptions.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&namespace, "namespace", "", "Restricts the diff to the shoot in the given namespace of the seed.")
+
+	return cmd
+}
+
+// diff writes the changes of the fluent-bit configuration of every shoot with the audit extension to the writer
+func diff(ctx context.Context, w io.Writer, c client.Client, config configapi.ControllerConfiguration, namespace string) error {
+	extensions := &extensionsv1alpha1.ExtensionList{}
+	if err := c.List(ctx, extensions, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("unable to list extensions: %w", err)
+	}
+
+	var failed []string
+
+	for _, ex := range extensions.Items {
+		if ex.Spec.Type != audit.Type || ex.DeletionTimestamp != nil {
+			continue
+		}
+
+		diffs, err := audit.DiffFluentBitConfig(ctx, logr.Discard(), c, config, &ex)
+		if err != nil {
+			fmt.Fprintf(w, "%s: error: %s\n", ex.Namespace, err)
+			failed = append(failed, ex.Namespace)
+			continue
+		}
+
+		if len(diffs) == 0 {
+			fmt.Fprintf(w, "%s: no changes\n", ex.Namespace)
+			continue
+		}
+
+		fmt.Fprintf(w, "%s:\n", ex.Namespace)
+		for _, d := range diffs {
+			for _, line := range strings.Split(d.String(), "\n") {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("unable to diff the fluent-bit configuration of %d shoot(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
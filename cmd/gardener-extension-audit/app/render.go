@@ -0,0 +1,291 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
+	configapi "github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config/validation"
+	auditcmd "github.com/metal-stack/gardener-extension-audit/pkg/cmd"
+	"github.com/metal-stack/gardener-extension-audit/pkg/controller/audit"
+
+	extensionscontroller "github.com/gardener/gardener/extensions/pkg/controller"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	gutil "github.com/gardener/gardener/pkg/utils/gardener"
+	"github.com/go-logr/logr"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+// renderOptions are the inputs of the render command
+type renderOptions struct {
+	auditConfigPath string
+	configPath      string
+	shootPath       string
+	secretPaths     []string
+	namespace       string
+	validate        bool
+}
+
+// NewRenderCommand creates a new command that renders the manifests that the extension deploys for an audit config
+// without access to a seed, e.g. to review changes of the audit config or of the extension itself.
+func NewRenderCommand(ctx context.Context) *cobra.Command {
+	opts := &renderOptions{}
+
+	cmd := &cobra.Command{
+		Use:           "render",
+		Short:         "renders the manifests that the extension deploys for an audit config, including the fluent-bit configuration.",
+		SilenceErrors: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.auditConfigPath == "" {
+				return errors.New("audit config is not set")
+			}
+
+			cmd.SilenceUsage = true
+			return render(ctx, cmd.OutOrStdout(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.auditConfigPath, "audit-config", "", "Path to the audit config as it is given in the provider config of the extension.")
+	cmd.Flags().StringVar(&opts.configPath, "config", "", "Path to the controller configuration, no operator configuration is applied if not set.")
+	cmd.Flags().StringVar(&opts.shootPath, "shoot", "", "Path to a shoot or cluster resource, a minimal shoot is used if not set.")
+	cmd.Flags().StringArrayVar(&opts.secretPaths, "secret", nil, "Path to a file with secrets referenced by the backends. Secrets without namespace are referenced in the shoot resources, secrets with namespace are the backend secrets of the operator.")
+	cmd.Flags().StringVar(&opts.namespace, "namespace", "", "The shoot namespace of the seed, defaults to the technical id of the shoot.")
+	cmd.Flags().BoolVar(&opts.validate, "validate", false, "Only validates the controller configuration and the audit config instead of printing the manifests.")
+
+	return cmd
+}
+
+// render writes the manifests for the given inputs to the writer or only validates the inputs
+func render(ctx context.Context, w io.Writer, opts *renderOptions) error {
+	scheme := runtime.NewScheme()
+	if err := extensionscontroller.AddToScheme(scheme); err != nil {
+		return err
+	}
+	if err := gardencorev1beta1.AddToScheme(scheme); err != nil {
+		return err
+	}
+	if err := install.AddToScheme(scheme); err != nil {
+		return err
+	}
+
+	config := &configapi.ControllerConfiguration{}
+	if opts.configPath != "" {
+		var err error
+		config, err = auditcmd.LoadConfiguration(opts.configPath)
+		if err != nil {
+			return fmt.Errorf("unable to load controller configuration: %w", err)
+		}
+	}
+
+	if errs := validation.ValidateConfiguration(config); len(errs) > 0 {
+		if opts.validate {
+			for _, err := range errs {
+				fmt.Fprintf(w, "controller configuration: %s\n", err)
+			}
+		}
+
+		return fmt.Errorf("invalid controller configuration: %w", errs.ToAggregate())
+	}
+
+	objects, ex, err := renderInputs(scheme, opts)
+	if err != nil {
+		return err
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+
+	manifests, err := audit.Render(ctx, logr.Discard(), c, *config, ex)
+	if err != nil {
+		if opts.validate {
+			fmt.Fprintf(w, "audit config: %s\n", err)
+		}
+
+		return fmt.Errorf("unable to render manifests: %w", err)
+	}
+
+	if opts.validate {
+		fmt.Fprintln(w, "controller configuration and audit config are valid")
+		return nil
+	}
+
+	_, err = w.Write(manifests.Bytes())
+	return err
+}
+
+// renderInputs returns the objects of the seed from which the manifests are rendered together with the extension
+func renderInputs(scheme *runtime.Scheme, opts *renderOptions) ([]client.Object, *extensionsv1alpha1.Extension, error) {
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+
+	cluster, err := readCluster(decoder, opts.shootPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	namespace := opts.namespace
+	if namespace == "" {
+		namespace = cluster.Name
+	}
+	cluster.Name = namespace
+
+	auditConfig, err := os.ReadFile(opts.auditConfigPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read audit config: %w", err)
+	}
+	providerConfig, err := yaml.YAMLToJSON(auditConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to convert audit config: %w", err)
+	}
+
+	ex := &extensionsv1alpha1.Extension{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      audit.Type,
+			Namespace: namespace,
+		},
+		Spec: extensionsv1alpha1.ExtensionSpec{
+			DefaultSpec: extensionsv1alpha1.DefaultSpec{
+				Type:           audit.Type,
+				ProviderConfig: &runtime.RawExtension{Raw: providerConfig},
+			},
+		},
+	}
+
+	objects := []client.Object{cluster, ex}
+
+	for _, path := range opts.secretPaths {
+		secrets, err := readSecrets(decoder, path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, secret := range secrets {
+			if secret.Namespace == "" {
+				// secrets referenced in the shoot resources are copied into the shoot namespace by the gardenlet
+				secret.Namespace = namespace
+				secret.Name = v1beta1constants.ReferencedResourcesPrefix + secret.Name
+			}
+
+			objects = append(objects, secret)
+		}
+	}
+
+	return objects, ex, nil
+}
+
+// readCluster reads a cluster or a shoot from the given file, in case of a shoot it is wrapped into a cluster named
+// after its technical id. a minimal shoot is used if no file is given.
+func readCluster(decoder runtime.Decoder, path string) (*extensionsv1alpha1.Cluster, error) {
+	shoot := &gardencorev1beta1.Shoot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "render",
+			Namespace: "garden-local",
+		},
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read shoot: %w", err)
+		}
+
+		obj, _, err := decoder.Decode(data, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode shoot: %w", err)
+		}
+
+		switch o := obj.(type) {
+		case *extensionsv1alpha1.Cluster:
+			return o, nil
+		case *gardencorev1beta1.Shoot:
+			shoot = o
+		default:
+			return nil, fmt.Errorf("%s is neither a shoot nor a cluster", obj.GetObjectKind().GroupVersionKind().Kind)
+		}
+	}
+
+	shoot.SetGroupVersionKind(gardencorev1beta1.SchemeGroupVersion.WithKind("Shoot"))
+
+	shootJSON, err := json.Marshal(shoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &extensionsv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: gutil.ComputeTechnicalID(strings.TrimPrefix(shoot.Namespace, "garden-"), shoot),
+		},
+		Spec: extensionsv1alpha1.ClusterSpec{
+			Shoot:        runtime.RawExtension{Raw: shootJSON},
+			Seed:         runtime.RawExtension{Raw: []byte("{}")},
+			CloudProfile: runtime.RawExtension{Raw: []byte("{}")},
+		},
+	}, nil
+}
+
+// readSecrets reads all secrets of the given file, which may contain multiple yaml documents
+func readSecrets(decoder runtime.Decoder, path string) ([]*corev1.Secret, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secrets: %w", err)
+	}
+
+	var (
+		secrets []*corev1.Secret
+		reader  = utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	)
+
+	for {
+		document, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read secrets of %q: %w", path, err)
+		}
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+
+		obj, _, err := decoder.Decode(document, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode secret of %q: %w", path, err)
+		}
+
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return nil, fmt.Errorf("%q contains a %s, only secrets are supported", path, obj.GetObjectKind().GroupVersionKind().Kind)
+		}
+
+		// the api server merges the string data into the data, which the fake client does not
+		for key, value := range secret.StringData {
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Data[key] = []byte(value)
+		}
+		secret.StringData = nil
+
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
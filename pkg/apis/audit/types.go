@@ -1,18 +1,39 @@
 package audit
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 const (
 	AuditWebhookModeBatch          AuditWebhookMode = "batch"
 	AuditWebhookModeBlocking       AuditWebhookMode = "blocking"
 	AuditWebhookModeBlockingStrict AuditWebhookMode = "blocking-strict"
+
+	AuditPersistenceTypePersistentVolume AuditPersistenceType = "persistentVolume"
+	AuditPersistenceTypeEphemeral        AuditPersistenceType = "ephemeral"
+
+	OutputHealthStatusHealthy OutputHealthStatus = "Healthy"
+	OutputHealthStatusWarning OutputHealthStatus = "Warning"
+	OutputHealthStatusFailed  OutputHealthStatus = "Failed"
+
+	BackendOriginUser     BackendOrigin = "User"
+	BackendOriginDefault  BackendOrigin = "Default"
+	BackendOriginEnforced BackendOrigin = "Enforced"
+
+	BackendTypeLog               BackendType = "log"
+	BackendTypeClusterForwarding BackendType = "clusterForwarding"
+	BackendTypeSplunk            BackendType = "splunk"
 )
 
 type (
-	AuditWebhookMode string
+	AuditWebhookMode     string
+	AuditPersistenceType string
+	OutputHealthStatus   string
+	BackendOrigin        string
+	BackendType          string
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -33,10 +54,16 @@ type AuditConfig struct {
 
 	// Backends contains the settings for the various backends.
 	Backends *AuditBackends
+
+	// Resources are the resource requirements of the fluent-bit container of the audit webhook backend.
+	Resources *corev1.ResourceRequirements
 }
 
 type AuditPersistence struct {
-	// Size is the size of the PVC to be used for each replica of the statefulset.
+	// Type determines where the audit data is buffered, either on a persistent volume or in an ephemeral emptyDir.
+	Type AuditPersistenceType
+
+	// Size is the size of the PVC to be used for each replica of the statefulset. In ephemeral mode, it limits the size of the emptyDir.
 	Size *resource.Quantity
 
 	// StorageClassName is the name of the storage class to be used for the PVC. If empty, the default
@@ -102,3 +129,166 @@ type AuditBackendSplunk struct {
 	// CustomData contains a map of custom key value pairs. The custom data is added to each audit log entry using fluentbit's modify filter.
 	CustomData map[string]string
 }
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AuditStatus contains the status of the audit extension, which is written to the extension's provider status.
+type AuditStatus struct {
+	metav1.TypeMeta
+
+	// Health contains the health of the audit webhook backend as observed by the last health check.
+	Health *AuditHealth
+
+	// Configuration is the effective configuration of the audit extension as applied by the last reconciliation.
+	Configuration *EffectiveConfiguration
+}
+
+type EffectiveConfiguration struct {
+	// WebhookMode is the audit webhook mode with which the kube-apiserver sends the audit events.
+	WebhookMode AuditWebhookMode
+
+	// DefaultProfiles are the names of the default profiles of the operator that were applied to the shoot.
+	DefaultProfiles []string
+
+	// Backends are the enabled backends to which the audit events are forwarded.
+	Backends []EffectiveBackend
+
+	// ConfigChecksum is the checksum of the fluent-bit configuration of the audit webhook backend, it changes whenever
+	// the effective configuration of the backends changes.
+	ConfigChecksum string
+}
+
+type EffectiveBackend struct {
+	// Name is the name of the backend, which is also the name of the fluent-bit output. The health of the backend is
+	// reported under the same name in the outputs of the health.
+	Name string
+
+	// Type is the type of the backend, which is log, clusterForwarding or splunk.
+	Type BackendType
+
+	// Origin is User if the backend is configured by the user, Default if it is a default backend of the operator
+	// and Enforced if it is enforced by the operator.
+	Origin BackendOrigin
+
+	// Splunk contains the settings of a splunk backend without the secrets. It is not set for backends enforced by the operator.
+	Splunk *EffectiveSplunkBackend
+}
+
+type EffectiveSplunkBackend struct {
+	// Host is the hostname or IP of the splunk HEC endpoint.
+	Host string
+
+	// Port is the port on which the HEC endpoint is listening.
+	Port string
+
+	// Index is the splunk index that is used.
+	Index string
+
+	// TlsEnabled determines whether TLS is used to communicate to the HEC endpoint.
+	TlsEnabled bool
+
+	// SecretResourceName is the reference under Shoot.spec.resources to the secret used to authenticate against the
+	// splunk backend. It is only set for backends configured by the user.
+	SecretResourceName string
+}
+
+type AuditHealth struct {
+	// LastCheckTime is the point in time when the health of the audit webhook backend was checked.
+	LastCheckTime metav1.Time
+
+	// FluentBitVersions are the versions of fluent-bit observed in the audit webhook backend pods, which differ
+	// during a rollout only.
+	FluentBitVersions []string
+
+	// Outputs contains the health of the fluent-bit outputs, one for every configured backend.
+	Outputs []OutputHealth
+
+	// Buffer contains the health of the filesystem buffer of the audit webhook backend pods.
+	Buffer *BufferHealth
+
+	// Input contains the health of the audit events received from the kube-apiserver.
+	Input *InputHealth
+
+	// Pods contains the counters of the fluent-bit pods as seen in the last health check. They are used to calculate
+	// the increase of the counters in the next health check, also after a restart of the extension.
+	Pods []PodCounters
+}
+
+type OutputHealth struct {
+	// Name is the name of the fluent-bit output, which is named after the backend.
+	Name string
+
+	// Status is Healthy, Warning in case retries occurred or Failed in case records were dropped or the buffer is almost full.
+	Status OutputHealthStatus
+
+	// Message describes the reason for the status.
+	Message string
+
+	// LastSuccessfulFlushTime is the last point in time at which the output was observed to deliver records to the backend.
+	LastSuccessfulFlushTime *metav1.Time
+
+	// Retries is the amount of retries that have occurred since the previous health check.
+	Retries int64
+
+	// DroppedRecords is the amount of records that were dropped since the previous health check.
+	DroppedRecords int64
+
+	// BufferUsagePercent is the usage of the output's filesystem buffer in percent of its limit.
+	BufferUsagePercent *int32
+}
+
+type BufferHealth struct {
+	// Status is Healthy, Warning in case the buffer exceeds the warning threshold or is about to run full or Failed in case
+	// the buffer exceeds the failure threshold.
+	Status OutputHealthStatus
+
+	// Message describes the reason for the status.
+	Message string
+
+	// UsagePercent is the estimated usage of the fullest buffer of all audit webhook backend pods in percent.
+	UsagePercent int32
+
+	// EstimatedTimeToFull is the estimated duration until the first buffer runs full, calculated from the growth since the previous health check.
+	// It is not set if no buffer is growing.
+	EstimatedTimeToFull *metav1.Duration
+}
+
+type InputHealth struct {
+	// Status is Healthy or Failed in case no audit events were received for the configured period or the kube-apiserver
+	// is not configured to send audit events to the audit webhook backend.
+	Status OutputHealthStatus
+
+	// Message describes the reason for the status.
+	Message string
+
+	// LastReceivedTime is the last point in time at which the audit webhook backend was observed to receive audit events.
+	LastReceivedTime *metav1.Time
+}
+
+type PodCounters struct {
+	// Name is the name of the fluent-bit pod.
+	Name string
+
+	// UID is the uid of the fluent-bit pod, which is used to detect that a pod was recreated.
+	UID types.UID
+
+	// InputRecords is the total amount of records received by the pod's fluent-bit inputs.
+	InputRecords int64
+
+	// Outputs contains the counters of the pod's fluent-bit outputs.
+	Outputs []OutputCounters
+}
+
+type OutputCounters struct {
+	// Name is the name of the fluent-bit output.
+	Name string
+
+	// Retries is the total amount of retries of the output.
+	Retries int64
+
+	// DroppedRecords is the total amount of dropped records of the output.
+	DroppedRecords int64
+
+	// ProcessedRecords is the total amount of records that were delivered by the output.
+	ProcessedRecords int64
+}
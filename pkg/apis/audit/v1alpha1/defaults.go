@@ -55,3 +55,14 @@ func defaultBackendSplunk(backend *AuditBackendSplunk) {
 		backend.FilesystemBufferSize = pointer.Pointer("900M")
 	}
 }
+
+// EffectiveWebhookMode returns the webhook mode that is configured for the kube-apiserver, an empty or unknown mode
+// falls back to blocking-strict
+func EffectiveWebhookMode(mode AuditWebhookMode) AuditWebhookMode {
+	switch mode {
+	case AuditWebhookModeBatch, AuditWebhookModeBlocking, AuditWebhookModeBlockingStrict:
+		return mode
+	default:
+		return AuditWebhookModeBlockingStrict
+	}
+}
@@ -35,6 +35,7 @@ func init() {
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&AuditConfig{},
+		&AuditStatus{},
 	)
 	return nil
 }
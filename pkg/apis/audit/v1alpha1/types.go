@@ -1,8 +1,10 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 const (
@@ -15,12 +17,31 @@ const (
 	AuditWebhookModeBlocking       AuditWebhookMode = "blocking"
 	AuditWebhookModeBlockingStrict AuditWebhookMode = "blocking-strict"
 
+	AuditPersistenceTypePersistentVolume AuditPersistenceType = "persistentVolume"
+	AuditPersistenceTypeEphemeral        AuditPersistenceType = "ephemeral"
+
+	OutputHealthStatusHealthy OutputHealthStatus = "Healthy"
+	OutputHealthStatusWarning OutputHealthStatus = "Warning"
+	OutputHealthStatusFailed  OutputHealthStatus = "Failed"
+
+	BackendOriginUser     BackendOrigin = "User"
+	BackendOriginDefault  BackendOrigin = "Default"
+	BackendOriginEnforced BackendOrigin = "Enforced"
+
+	BackendTypeLog               BackendType = "log"
+	BackendTypeClusterForwarding BackendType = "clusterForwarding"
+	BackendTypeSplunk            BackendType = "splunk"
+
 	SplunkSecretTokenKey  = "token"
 	SplunkSecretCaFileKey = "ca"
 )
 
 type (
-	AuditWebhookMode string
+	AuditWebhookMode     string
+	AuditPersistenceType string
+	OutputHealthStatus   string
+	BackendOrigin        string
+	BackendType          string
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -43,10 +64,22 @@ type AuditConfig struct {
 	// Backends contains the settings for the various backends.
 	// +optional
 	Backends *AuditBackends `json:"backends,omitempty"`
+
+	// Resources are the resource requirements of the fluent-bit container of the audit webhook backend.
+	// The requests are only initial values as they are adjusted by a vertical pod autoscaler within the given limits.
+	// If not set, the defaults of the operator are used.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 type AuditPersistence struct {
-	// Size is the size of the PVC to be used for each replica of the statefulset.
+	// Type determines where the audit data is buffered, either on a persistent volume ("persistentVolume") or in an
+	// emptyDir limited to the configured size ("ephemeral"). Buffered data in an emptyDir is lost when a pod is rescheduled.
+	// If empty, "ephemeral" is used for shoots with purpose evaluation or testing and "persistentVolume" otherwise.
+	// +optional
+	Type AuditPersistenceType `json:"type,omitempty"`
+
+	// Size is the size of the PVC to be used for each replica of the statefulset. In ephemeral mode, it limits the size of the emptyDir.
 	// +optional
 	Size *resource.Quantity `json:"size,omitempty"`
 
@@ -124,3 +157,187 @@ type AuditBackendSplunk struct {
 	// The keys and the values may only contain letters, numbers, '_' or '.'. Empty keys or values are also not accepted.
 	CustomData map[string]string `json:"customData,omitempty"`
 }
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AuditStatus contains the status of the audit extension, which is written to the extension's provider status.
+type AuditStatus struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Health contains the health of the audit webhook backend as observed by the last health check.
+	// +optional
+	Health *AuditHealth `json:"health,omitempty"`
+
+	// Configuration is the effective configuration of the audit extension as applied by the last reconciliation.
+	// +optional
+	Configuration *EffectiveConfiguration `json:"configuration,omitempty"`
+}
+
+type EffectiveConfiguration struct {
+	// WebhookMode is the audit webhook mode with which the kube-apiserver sends the audit events.
+	WebhookMode AuditWebhookMode `json:"webhookMode"`
+
+	// DefaultProfiles are the names of the default profiles of the operator that were applied to the shoot.
+	// +optional
+	DefaultProfiles []string `json:"defaultProfiles,omitempty"`
+
+	// Backends are the enabled backends to which the audit events are forwarded.
+	// +optional
+	Backends []EffectiveBackend `json:"backends,omitempty"`
+
+	// ConfigChecksum is the checksum of the fluent-bit configuration of the audit webhook backend, it changes whenever
+	// the effective configuration of the backends changes.
+	ConfigChecksum string `json:"configChecksum"`
+}
+
+type EffectiveBackend struct {
+	// Name is the name of the backend, which is also the name of the fluent-bit output. The health of the backend is
+	// reported under the same name in the outputs of the health.
+	Name string `json:"name"`
+
+	// Type is the type of the backend, which is log, clusterForwarding or splunk.
+	Type BackendType `json:"type"`
+
+	// Origin is User if the backend is configured by the user, Default if it is a default backend of the operator
+	// and Enforced if it is enforced by the operator.
+	Origin BackendOrigin `json:"origin"`
+
+	// Splunk contains the settings of a splunk backend without the secrets. It is not set for backends enforced by the operator.
+	// +optional
+	Splunk *EffectiveSplunkBackend `json:"splunk,omitempty"`
+}
+
+type EffectiveSplunkBackend struct {
+	// Host is the hostname or IP of the splunk HEC endpoint.
+	Host string `json:"host"`
+
+	// Port is the port on which the HEC endpoint is listening.
+	Port string `json:"port"`
+
+	// Index is the splunk index that is used.
+	Index string `json:"index"`
+
+	// TlsEnabled determines whether TLS is used to communicate to the HEC endpoint.
+	TlsEnabled bool `json:"tls"`
+
+	// SecretResourceName is the reference under Shoot.spec.resources to the secret used to authenticate against the
+	// splunk backend. It is only set for backends configured by the user.
+	// +optional
+	SecretResourceName string `json:"secretResourceName,omitempty"`
+}
+
+type AuditHealth struct {
+	// LastCheckTime is the point in time when the health of the audit webhook backend was checked.
+	LastCheckTime metav1.Time `json:"lastCheckTime"`
+
+	// FluentBitVersions are the versions of fluent-bit observed in the audit webhook backend pods, which differ
+	// during a rollout only.
+	// +optional
+	FluentBitVersions []string `json:"fluentBitVersions,omitempty"`
+
+	// Outputs contains the health of the fluent-bit outputs, one for every configured backend.
+	// +optional
+	Outputs []OutputHealth `json:"outputs,omitempty"`
+
+	// Buffer contains the health of the filesystem buffer of the audit webhook backend pods.
+	// +optional
+	Buffer *BufferHealth `json:"buffer,omitempty"`
+
+	// Input contains the health of the audit events received from the kube-apiserver.
+	// +optional
+	Input *InputHealth `json:"input,omitempty"`
+
+	// Pods contains the counters of the fluent-bit pods as seen in the last health check. They are used to calculate
+	// the increase of the counters in the next health check, also after a restart of the extension.
+	// +optional
+	Pods []PodCounters `json:"pods,omitempty"`
+}
+
+type OutputHealth struct {
+	// Name is the name of the fluent-bit output, which is named after the backend.
+	Name string `json:"name"`
+
+	// Status is Healthy, Warning in case retries occurred or Failed in case records were dropped or the buffer is almost full.
+	Status OutputHealthStatus `json:"status"`
+
+	// Message describes the reason for the status.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastSuccessfulFlushTime is the last point in time at which the output was observed to deliver records to the backend.
+	// +optional
+	LastSuccessfulFlushTime *metav1.Time `json:"lastSuccessfulFlushTime,omitempty"`
+
+	// Retries is the amount of retries that have occurred since the previous health check.
+	Retries int64 `json:"retries"`
+
+	// DroppedRecords is the amount of records that were dropped since the previous health check.
+	DroppedRecords int64 `json:"droppedRecords"`
+
+	// BufferUsagePercent is the usage of the output's filesystem buffer in percent of its limit.
+	// +optional
+	BufferUsagePercent *int32 `json:"bufferUsagePercent,omitempty"`
+}
+
+type BufferHealth struct {
+	// Status is Healthy, Warning in case the buffer exceeds the warning threshold or is about to run full or Failed in case
+	// the buffer exceeds the failure threshold.
+	Status OutputHealthStatus `json:"status"`
+
+	// Message describes the reason for the status.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// UsagePercent is the estimated usage of the fullest buffer of all audit webhook backend pods in percent.
+	UsagePercent int32 `json:"usagePercent"`
+
+	// EstimatedTimeToFull is the estimated duration until the first buffer runs full, calculated from the growth since the previous health check.
+	// It is not set if no buffer is growing.
+	// +optional
+	EstimatedTimeToFull *metav1.Duration `json:"estimatedTimeToFull,omitempty"`
+}
+
+type InputHealth struct {
+	// Status is Healthy or Failed in case no audit events were received for the configured period or the kube-apiserver
+	// is not configured to send audit events to the audit webhook backend.
+	Status OutputHealthStatus `json:"status"`
+
+	// Message describes the reason for the status.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastReceivedTime is the last point in time at which the audit webhook backend was observed to receive audit events.
+	// +optional
+	LastReceivedTime *metav1.Time `json:"lastReceivedTime,omitempty"`
+}
+
+type PodCounters struct {
+	// Name is the name of the fluent-bit pod.
+	Name string `json:"name"`
+
+	// UID is the uid of the fluent-bit pod, which is used to detect that a pod was recreated.
+	// +optional
+	UID types.UID `json:"uid,omitempty"`
+
+	// InputRecords is the total amount of records received by the pod's fluent-bit inputs.
+	// +optional
+	InputRecords int64 `json:"inputRecords,omitempty"`
+
+	// Outputs contains the counters of the pod's fluent-bit outputs.
+	// +optional
+	Outputs []OutputCounters `json:"outputs,omitempty"`
+}
+
+type OutputCounters struct {
+	// Name is the name of the fluent-bit output.
+	Name string `json:"name"`
+
+	// Retries is the total amount of retries of the output.
+	Retries int64 `json:"retries"`
+
+	// DroppedRecords is the total amount of dropped records of the output.
+	DroppedRecords int64 `json:"droppedRecords"`
+
+	// ProcessedRecords is the total amount of records that were delivered by the output.
+	ProcessedRecords int64 `json:"processedRecords"`
+}
@@ -13,9 +13,12 @@ import (
 	unsafe "unsafe"
 
 	audit "github.com/metal-stack/gardener-extension-audit/pkg/apis/audit"
+	v1 "k8s.io/api/core/v1"
 	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	types "k8s.io/apimachinery/pkg/types"
 )
 
 func init() {
@@ -75,6 +78,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*AuditHealth)(nil), (*audit.AuditHealth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AuditHealth_To_audit_AuditHealth(a.(*AuditHealth), b.(*audit.AuditHealth), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*audit.AuditHealth)(nil), (*AuditHealth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_audit_AuditHealth_To_v1alpha1_AuditHealth(a.(*audit.AuditHealth), b.(*AuditHealth), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*AuditPersistence)(nil), (*audit.AuditPersistence)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_AuditPersistence_To_audit_AuditPersistence(a.(*AuditPersistence), b.(*audit.AuditPersistence), scope)
 	}); err != nil {
@@ -85,6 +98,96 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*AuditStatus)(nil), (*audit.AuditStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AuditStatus_To_audit_AuditStatus(a.(*AuditStatus), b.(*audit.AuditStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*audit.AuditStatus)(nil), (*AuditStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_audit_AuditStatus_To_v1alpha1_AuditStatus(a.(*audit.AuditStatus), b.(*AuditStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BufferHealth)(nil), (*audit.BufferHealth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BufferHealth_To_audit_BufferHealth(a.(*BufferHealth), b.(*audit.BufferHealth), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*audit.BufferHealth)(nil), (*BufferHealth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_audit_BufferHealth_To_v1alpha1_BufferHealth(a.(*audit.BufferHealth), b.(*BufferHealth), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*EffectiveBackend)(nil), (*audit.EffectiveBackend)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_EffectiveBackend_To_audit_EffectiveBackend(a.(*EffectiveBackend), b.(*audit.EffectiveBackend), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*audit.EffectiveBackend)(nil), (*EffectiveBackend)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_audit_EffectiveBackend_To_v1alpha1_EffectiveBackend(a.(*audit.EffectiveBackend), b.(*EffectiveBackend), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*EffectiveConfiguration)(nil), (*audit.EffectiveConfiguration)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_EffectiveConfiguration_To_audit_EffectiveConfiguration(a.(*EffectiveConfiguration), b.(*audit.EffectiveConfiguration), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*audit.EffectiveConfiguration)(nil), (*EffectiveConfiguration)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_audit_EffectiveConfiguration_To_v1alpha1_EffectiveConfiguration(a.(*audit.EffectiveConfiguration), b.(*EffectiveConfiguration), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*EffectiveSplunkBackend)(nil), (*audit.EffectiveSplunkBackend)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_EffectiveSplunkBackend_To_audit_EffectiveSplunkBackend(a.(*EffectiveSplunkBackend), b.(*audit.EffectiveSplunkBackend), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*audit.EffectiveSplunkBackend)(nil), (*EffectiveSplunkBackend)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_audit_EffectiveSplunkBackend_To_v1alpha1_EffectiveSplunkBackend(a.(*audit.EffectiveSplunkBackend), b.(*EffectiveSplunkBackend), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*InputHealth)(nil), (*audit.InputHealth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_InputHealth_To_audit_InputHealth(a.(*InputHealth), b.(*audit.InputHealth), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*audit.InputHealth)(nil), (*InputHealth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_audit_InputHealth_To_v1alpha1_InputHealth(a.(*audit.InputHealth), b.(*InputHealth), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*OutputCounters)(nil), (*audit.OutputCounters)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_OutputCounters_To_audit_OutputCounters(a.(*OutputCounters), b.(*audit.OutputCounters), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*audit.OutputCounters)(nil), (*OutputCounters)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_audit_OutputCounters_To_v1alpha1_OutputCounters(a.(*audit.OutputCounters), b.(*OutputCounters), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*OutputHealth)(nil), (*audit.OutputHealth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_OutputHealth_To_audit_OutputHealth(a.(*OutputHealth), b.(*audit.OutputHealth), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*audit.OutputHealth)(nil), (*OutputHealth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_audit_OutputHealth_To_v1alpha1_OutputHealth(a.(*audit.OutputHealth), b.(*OutputHealth), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PodCounters)(nil), (*audit.PodCounters)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_PodCounters_To_audit_PodCounters(a.(*PodCounters), b.(*audit.PodCounters), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*audit.PodCounters)(nil), (*PodCounters)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_audit_PodCounters_To_v1alpha1_PodCounters(a.(*audit.PodCounters), b.(*PodCounters), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -197,6 +300,7 @@ func autoConvert_v1alpha1_AuditConfig_To_audit_AuditConfig(in *AuditConfig, out
 	out.Replicas = (*int32)(unsafe.Pointer(in.Replicas))
 	out.WebhookMode = audit.AuditWebhookMode(in.WebhookMode)
 	out.Backends = (*audit.AuditBackends)(unsafe.Pointer(in.Backends))
+	out.Resources = (*v1.ResourceRequirements)(unsafe.Pointer(in.Resources))
 	return nil
 }
 
@@ -212,6 +316,7 @@ func autoConvert_audit_AuditConfig_To_v1alpha1_AuditConfig(in *audit.AuditConfig
 	out.Replicas = (*int32)(unsafe.Pointer(in.Replicas))
 	out.WebhookMode = AuditWebhookMode(in.WebhookMode)
 	out.Backends = (*AuditBackends)(unsafe.Pointer(in.Backends))
+	out.Resources = (*v1.ResourceRequirements)(unsafe.Pointer(in.Resources))
 	return nil
 }
 
@@ -220,7 +325,38 @@ func Convert_audit_AuditConfig_To_v1alpha1_AuditConfig(in *audit.AuditConfig, ou
 	return autoConvert_audit_AuditConfig_To_v1alpha1_AuditConfig(in, out, s)
 }
 
+func autoConvert_v1alpha1_AuditHealth_To_audit_AuditHealth(in *AuditHealth, out *audit.AuditHealth, s conversion.Scope) error {
+	out.LastCheckTime = in.LastCheckTime
+	out.FluentBitVersions = *(*[]string)(unsafe.Pointer(&in.FluentBitVersions))
+	out.Outputs = *(*[]audit.OutputHealth)(unsafe.Pointer(&in.Outputs))
+	out.Buffer = (*audit.BufferHealth)(unsafe.Pointer(in.Buffer))
+	out.Input = (*audit.InputHealth)(unsafe.Pointer(in.Input))
+	out.Pods = *(*[]audit.PodCounters)(unsafe.Pointer(&in.Pods))
+	return nil
+}
+
+// Convert_v1alpha1_AuditHealth_To_audit_AuditHealth is an autogenerated conversion function.
+func Convert_v1alpha1_AuditHealth_To_audit_AuditHealth(in *AuditHealth, out *audit.AuditHealth, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AuditHealth_To_audit_AuditHealth(in, out, s)
+}
+
+func autoConvert_audit_AuditHealth_To_v1alpha1_AuditHealth(in *audit.AuditHealth, out *AuditHealth, s conversion.Scope) error {
+	out.LastCheckTime = in.LastCheckTime
+	out.FluentBitVersions = *(*[]string)(unsafe.Pointer(&in.FluentBitVersions))
+	out.Outputs = *(*[]OutputHealth)(unsafe.Pointer(&in.Outputs))
+	out.Buffer = (*BufferHealth)(unsafe.Pointer(in.Buffer))
+	out.Input = (*InputHealth)(unsafe.Pointer(in.Input))
+	out.Pods = *(*[]PodCounters)(unsafe.Pointer(&in.Pods))
+	return nil
+}
+
+// Convert_audit_AuditHealth_To_v1alpha1_AuditHealth is an autogenerated conversion function.
+func Convert_audit_AuditHealth_To_v1alpha1_AuditHealth(in *audit.AuditHealth, out *AuditHealth, s conversion.Scope) error {
+	return autoConvert_audit_AuditHealth_To_v1alpha1_AuditHealth(in, out, s)
+}
+
 func autoConvert_v1alpha1_AuditPersistence_To_audit_AuditPersistence(in *AuditPersistence, out *audit.AuditPersistence, s conversion.Scope) error {
+	out.Type = audit.AuditPersistenceType(in.Type)
 	out.Size = (*resource.Quantity)(unsafe.Pointer(in.Size))
 	out.StorageClassName = (*string)(unsafe.Pointer(in.StorageClassName))
 	return nil
@@ -232,6 +368,7 @@ func Convert_v1alpha1_AuditPersistence_To_audit_AuditPersistence(in *AuditPersis
 }
 
 func autoConvert_audit_AuditPersistence_To_v1alpha1_AuditPersistence(in *audit.AuditPersistence, out *AuditPersistence, s conversion.Scope) error {
+	out.Type = AuditPersistenceType(in.Type)
 	out.Size = (*resource.Quantity)(unsafe.Pointer(in.Size))
 	out.StorageClassName = (*string)(unsafe.Pointer(in.StorageClassName))
 	return nil
@@ -241,3 +378,239 @@ func autoConvert_audit_AuditPersistence_To_v1alpha1_AuditPersistence(in *audit.A
 func Convert_audit_AuditPersistence_To_v1alpha1_AuditPersistence(in *audit.AuditPersistence, out *AuditPersistence, s conversion.Scope) error {
 	return autoConvert_audit_AuditPersistence_To_v1alpha1_AuditPersistence(in, out, s)
 }
+
+func autoConvert_v1alpha1_AuditStatus_To_audit_AuditStatus(in *AuditStatus, out *audit.AuditStatus, s conversion.Scope) error {
+	out.Health = (*audit.AuditHealth)(unsafe.Pointer(in.Health))
+	out.Configuration = (*audit.EffectiveConfiguration)(unsafe.Pointer(in.Configuration))
+	return nil
+}
+
+// Convert_v1alpha1_AuditStatus_To_audit_AuditStatus is an autogenerated conversion function.
+func Convert_v1alpha1_AuditStatus_To_audit_AuditStatus(in *AuditStatus, out *audit.AuditStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AuditStatus_To_audit_AuditStatus(in, out, s)
+}
+
+func autoConvert_audit_AuditStatus_To_v1alpha1_AuditStatus(in *audit.AuditStatus, out *AuditStatus, s conversion.Scope) error {
+	out.Health = (*AuditHealth)(unsafe.Pointer(in.Health))
+	out.Configuration = (*EffectiveConfiguration)(unsafe.Pointer(in.Configuration))
+	return nil
+}
+
+// Convert_audit_AuditStatus_To_v1alpha1_AuditStatus is an autogenerated conversion function.
+func Convert_audit_AuditStatus_To_v1alpha1_AuditStatus(in *audit.AuditStatus, out *AuditStatus, s conversion.Scope) error {
+	return autoConvert_audit_AuditStatus_To_v1alpha1_AuditStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_BufferHealth_To_audit_BufferHealth(in *BufferHealth, out *audit.BufferHealth, s conversion.Scope) error {
+	out.Status = audit.OutputHealthStatus(in.Status)
+	out.Message = in.Message
+	out.UsagePercent = in.UsagePercent
+	out.EstimatedTimeToFull = (*metav1.Duration)(unsafe.Pointer(in.EstimatedTimeToFull))
+	return nil
+}
+
+// Convert_v1alpha1_BufferHealth_To_audit_BufferHealth is an autogenerated conversion function.
+func Convert_v1alpha1_BufferHealth_To_audit_BufferHealth(in *BufferHealth, out *audit.BufferHealth, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BufferHealth_To_audit_BufferHealth(in, out, s)
+}
+
+func autoConvert_audit_BufferHealth_To_v1alpha1_BufferHealth(in *audit.BufferHealth, out *BufferHealth, s conversion.Scope) error {
+	out.Status = OutputHealthStatus(in.Status)
+	out.Message = in.Message
+	out.UsagePercent = in.UsagePercent
+	out.EstimatedTimeToFull = (*metav1.Duration)(unsafe.Pointer(in.EstimatedTimeToFull))
+	return nil
+}
+
+// Convert_audit_BufferHealth_To_v1alpha1_BufferHealth is an autogenerated conversion function.
+func Convert_audit_BufferHealth_To_v1alpha1_BufferHealth(in *audit.BufferHealth, out *BufferHealth, s conversion.Scope) error {
+	return autoConvert_audit_BufferHealth_To_v1alpha1_BufferHealth(in, out, s)
+}
+
+func autoConvert_v1alpha1_EffectiveBackend_To_audit_EffectiveBackend(in *EffectiveBackend, out *audit.EffectiveBackend, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Type = audit.BackendType(in.Type)
+	out.Origin = audit.BackendOrigin(in.Origin)
+	out.Splunk = (*audit.EffectiveSplunkBackend)(unsafe.Pointer(in.Splunk))
+	return nil
+}
+
+// Convert_v1alpha1_EffectiveBackend_To_audit_EffectiveBackend is an autogenerated conversion function.
+func Convert_v1alpha1_EffectiveBackend_To_audit_EffectiveBackend(in *EffectiveBackend, out *audit.EffectiveBackend, s conversion.Scope) error {
+	return autoConvert_v1alpha1_EffectiveBackend_To_audit_EffectiveBackend(in, out, s)
+}
+
+func autoConvert_audit_EffectiveBackend_To_v1alpha1_EffectiveBackend(in *audit.EffectiveBackend, out *EffectiveBackend, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Type = BackendType(in.Type)
+	out.Origin = BackendOrigin(in.Origin)
+	out.Splunk = (*EffectiveSplunkBackend)(unsafe.Pointer(in.Splunk))
+	return nil
+}
+
+// Convert_audit_EffectiveBackend_To_v1alpha1_EffectiveBackend is an autogenerated conversion function.
+func Convert_audit_EffectiveBackend_To_v1alpha1_EffectiveBackend(in *audit.EffectiveBackend, out *EffectiveBackend, s conversion.Scope) error {
+	return autoConvert_audit_EffectiveBackend_To_v1alpha1_EffectiveBackend(in, out, s)
+}
+
+func autoConvert_v1alpha1_EffectiveConfiguration_To_audit_EffectiveConfiguration(in *EffectiveConfiguration, out *audit.EffectiveConfiguration, s conversion.Scope) error {
+	out.WebhookMode = audit.AuditWebhookMode(in.WebhookMode)
+	out.DefaultProfiles = *(*[]string)(unsafe.Pointer(&in.DefaultProfiles))
+	out.Backends = *(*[]audit.EffectiveBackend)(unsafe.Pointer(&in.Backends))
+	out.ConfigChecksum = in.ConfigChecksum
+	return nil
+}
+
+// Convert_v1alpha1_EffectiveConfiguration_To_audit_EffectiveConfiguration is an autogenerated conversion function.
+func Convert_v1alpha1_EffectiveConfiguration_To_audit_EffectiveConfiguration(in *EffectiveConfiguration, out *audit.EffectiveConfiguration, s conversion.Scope) error {
+	return autoConvert_v1alpha1_EffectiveConfiguration_To_audit_EffectiveConfiguration(in, out, s)
+}
+
+func autoConvert_audit_EffectiveConfiguration_To_v1alpha1_EffectiveConfiguration(in *audit.EffectiveConfiguration, out *EffectiveConfiguration, s conversion.Scope) error {
+	out.WebhookMode = AuditWebhookMode(in.WebhookMode)
+	out.DefaultProfiles = *(*[]string)(unsafe.Pointer(&in.DefaultProfiles))
+	out.Backends = *(*[]EffectiveBackend)(unsafe.Pointer(&in.Backends))
+	out.ConfigChecksum = in.ConfigChecksum
+	return nil
+}
+
+// Convert_audit_EffectiveConfiguration_To_v1alpha1_EffectiveConfiguration is an autogenerated conversion function.
+func Convert_audit_EffectiveConfiguration_To_v1alpha1_EffectiveConfiguration(in *audit.EffectiveConfiguration, out *EffectiveConfiguration, s conversion.Scope) error {
+	return autoConvert_audit_EffectiveConfiguration_To_v1alpha1_EffectiveConfiguration(in, out, s)
+}
+
+func autoConvert_v1alpha1_EffectiveSplunkBackend_To_audit_EffectiveSplunkBackend(in *EffectiveSplunkBackend, out *audit.EffectiveSplunkBackend, s conversion.Scope) error {
+	out.Host = in.Host
+	out.Port = in.Port
+	out.Index = in.Index
+	out.TlsEnabled = in.TlsEnabled
+	out.SecretResourceName = in.SecretResourceName
+	return nil
+}
+
+// Convert_v1alpha1_EffectiveSplunkBackend_To_audit_EffectiveSplunkBackend is an autogenerated conversion function.
+func Convert_v1alpha1_EffectiveSplunkBackend_To_audit_EffectiveSplunkBackend(in *EffectiveSplunkBackend, out *audit.EffectiveSplunkBackend, s conversion.Scope) error {
+	return autoConvert_v1alpha1_EffectiveSplunkBackend_To_audit_EffectiveSplunkBackend(in, out, s)
+}
+
+func autoConvert_audit_EffectiveSplunkBackend_To_v1alpha1_EffectiveSplunkBackend(in *audit.EffectiveSplunkBackend, out *EffectiveSplunkBackend, s conversion.Scope) error {
+	out.Host = in.Host
+	out.Port = in.Port
+	out.Index = in.Index
+	out.TlsEnabled = in.TlsEnabled
+	out.SecretResourceName = in.SecretResourceName
+	return nil
+}
+
+// Convert_audit_EffectiveSplunkBackend_To_v1alpha1_EffectiveSplunkBackend is an autogenerated conversion function.
+func Convert_audit_EffectiveSplunkBackend_To_v1alpha1_EffectiveSplunkBackend(in *audit.EffectiveSplunkBackend, out *EffectiveSplunkBackend, s conversion.Scope) error {
+	return autoConvert_audit_EffectiveSplunkBackend_To_v1alpha1_EffectiveSplunkBackend(in, out, s)
+}
+
+func autoConvert_v1alpha1_InputHealth_To_audit_InputHealth(in *InputHealth, out *audit.InputHealth, s conversion.Scope) error {
+	out.Status = audit.OutputHealthStatus(in.Status)
+	out.Message = in.Message
+	out.LastReceivedTime = (*metav1.Time)(unsafe.Pointer(in.LastReceivedTime))
+	return nil
+}
+
+// Convert_v1alpha1_InputHealth_To_audit_InputHealth is an autogenerated conversion function.
+func Convert_v1alpha1_InputHealth_To_audit_InputHealth(in *InputHealth, out *audit.InputHealth, s conversion.Scope) error {
+	return autoConvert_v1alpha1_InputHealth_To_audit_InputHealth(in, out, s)
+}
+
+func autoConvert_audit_InputHealth_To_v1alpha1_InputHealth(in *audit.InputHealth, out *InputHealth, s conversion.Scope) error {
+	out.Status = OutputHealthStatus(in.Status)
+	out.Message = in.Message
+	out.LastReceivedTime = (*metav1.Time)(unsafe.Pointer(in.LastReceivedTime))
+	return nil
+}
+
+// Convert_audit_InputHealth_To_v1alpha1_InputHealth is an autogenerated conversion function.
+func Convert_audit_InputHealth_To_v1alpha1_InputHealth(in *audit.InputHealth, out *InputHealth, s conversion.Scope) error {
+	return autoConvert_audit_InputHealth_To_v1alpha1_InputHealth(in, out, s)
+}
+
+func autoConvert_v1alpha1_OutputCounters_To_audit_OutputCounters(in *OutputCounters, out *audit.OutputCounters, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Retries = in.Retries
+	out.DroppedRecords = in.DroppedRecords
+	out.ProcessedRecords = in.ProcessedRecords
+	return nil
+}
+
+// Convert_v1alpha1_OutputCounters_To_audit_OutputCounters is an autogenerated conversion function.
+func Convert_v1alpha1_OutputCounters_To_audit_OutputCounters(in *OutputCounters, out *audit.OutputCounters, s conversion.Scope) error {
+	return autoConvert_v1alpha1_OutputCounters_To_audit_OutputCounters(in, out, s)
+}
+
+func autoConvert_audit_OutputCounters_To_v1alpha1_OutputCounters(in *audit.OutputCounters, out *OutputCounters, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Retries = in.Retries
+	out.DroppedRecords = in.DroppedRecords
+	out.ProcessedRecords = in.ProcessedRecords
+	return nil
+}
+
+// Convert_audit_OutputCounters_To_v1alpha1_OutputCounters is an autogenerated conversion function.
+func Convert_audit_OutputCounters_To_v1alpha1_OutputCounters(in *audit.OutputCounters, out *OutputCounters, s conversion.Scope) error {
+	return autoConvert_audit_OutputCounters_To_v1alpha1_OutputCounters(in, out, s)
+}
+
+func autoConvert_v1alpha1_OutputHealth_To_audit_OutputHealth(in *OutputHealth, out *audit.OutputHealth, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Status = audit.OutputHealthStatus(in.Status)
+	out.Message = in.Message
+	out.LastSuccessfulFlushTime = (*metav1.Time)(unsafe.Pointer(in.LastSuccessfulFlushTime))
+	out.Retries = in.Retries
+	out.DroppedRecords = in.DroppedRecords
+	out.BufferUsagePercent = (*int32)(unsafe.Pointer(in.BufferUsagePercent))
+	return nil
+}
+
+// Convert_v1alpha1_OutputHealth_To_audit_OutputHealth is an autogenerated conversion function.
+func Convert_v1alpha1_OutputHealth_To_audit_OutputHealth(in *OutputHealth, out *audit.OutputHealth, s conversion.Scope) error {
+	return autoConvert_v1alpha1_OutputHealth_To_audit_OutputHealth(in, out, s)
+}
+
+func autoConvert_audit_OutputHealth_To_v1alpha1_OutputHealth(in *audit.OutputHealth, out *OutputHealth, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Status = OutputHealthStatus(in.Status)
+	out.Message = in.Message
+	out.LastSuccessfulFlushTime = (*metav1.Time)(unsafe.Pointer(in.LastSuccessfulFlushTime))
+	out.Retries = in.Retries
+	out.DroppedRecords = in.DroppedRecords
+	out.BufferUsagePercent = (*int32)(unsafe.Pointer(in.BufferUsagePercent))
+	return nil
+}
+
+// Convert_audit_OutputHealth_To_v1alpha1_OutputHealth is an autogenerated conversion function.
+func Convert_audit_OutputHealth_To_v1alpha1_OutputHealth(in *audit.OutputHealth, out *OutputHealth, s conversion.Scope) error {
+	return autoConvert_audit_OutputHealth_To_v1alpha1_OutputHealth(in, out, s)
+}
+
+func autoConvert_v1alpha1_PodCounters_To_audit_PodCounters(in *PodCounters, out *audit.PodCounters, s conversion.Scope) error {
+	out.Name = in.Name
+	out.UID = types.UID(in.UID)
+	out.InputRecords = in.InputRecords
+	out.Outputs = *(*[]audit.OutputCounters)(unsafe.Pointer(&in.Outputs))
+	return nil
+}
+
+// Convert_v1alpha1_PodCounters_To_audit_PodCounters is an autogenerated conversion function.
+func Convert_v1alpha1_PodCounters_To_audit_PodCounters(in *PodCounters, out *audit.PodCounters, s conversion.Scope) error {
+	return autoConvert_v1alpha1_PodCounters_To_audit_PodCounters(in, out, s)
+}
+
+func autoConvert_audit_PodCounters_To_v1alpha1_PodCounters(in *audit.PodCounters, out *PodCounters, s conversion.Scope) error {
+	out.Name = in.Name
+	out.UID = types.UID(in.UID)
+	out.InputRecords = in.InputRecords
+	out.Outputs = *(*[]OutputCounters)(unsafe.Pointer(&in.Outputs))
+	return nil
+}
+
+// Convert_audit_PodCounters_To_v1alpha1_PodCounters is an autogenerated conversion function.
+func Convert_audit_PodCounters_To_v1alpha1_PodCounters(in *audit.PodCounters, out *PodCounters, s conversion.Scope) error {
+	return autoConvert_audit_PodCounters_To_v1alpha1_PodCounters(in, out, s)
+}
@@ -10,6 +10,8 @@
 package v1alpha1
 
 import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -124,6 +126,11 @@ func (in *AuditConfig) DeepCopyInto(out *AuditConfig) {
 		*out = new(AuditBackends)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -145,6 +152,52 @@ func (in *AuditConfig) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditHealth) DeepCopyInto(out *AuditHealth) {
+	*out = *in
+	in.LastCheckTime.DeepCopyInto(&out.LastCheckTime)
+	if in.FluentBitVersions != nil {
+		in, out := &in.FluentBitVersions, &out.FluentBitVersions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]OutputHealth, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Buffer != nil {
+		in, out := &in.Buffer, &out.Buffer
+		*out = new(BufferHealth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Input != nil {
+		in, out := &in.Input, &out.Input
+		*out = new(InputHealth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]PodCounters, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditHealth.
+func (in *AuditHealth) DeepCopy() *AuditHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AuditPersistence) DeepCopyInto(out *AuditPersistence) {
 	*out = *in
@@ -170,3 +223,206 @@ func (in *AuditPersistence) DeepCopy() *AuditPersistence {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditStatus) DeepCopyInto(out *AuditStatus) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = new(AuditHealth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Configuration != nil {
+		in, out := &in.Configuration, &out.Configuration
+		*out = new(EffectiveConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditStatus.
+func (in *AuditStatus) DeepCopy() *AuditStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AuditStatus) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BufferHealth) DeepCopyInto(out *BufferHealth) {
+	*out = *in
+	if in.EstimatedTimeToFull != nil {
+		in, out := &in.EstimatedTimeToFull, &out.EstimatedTimeToFull
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BufferHealth.
+func (in *BufferHealth) DeepCopy() *BufferHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(BufferHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectiveBackend) DeepCopyInto(out *EffectiveBackend) {
+	*out = *in
+	if in.Splunk != nil {
+		in, out := &in.Splunk, &out.Splunk
+		*out = new(EffectiveSplunkBackend)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectiveBackend.
+func (in *EffectiveBackend) DeepCopy() *EffectiveBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectiveBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectiveConfiguration) DeepCopyInto(out *EffectiveConfiguration) {
+	*out = *in
+	if in.DefaultProfiles != nil {
+		in, out := &in.DefaultProfiles, &out.DefaultProfiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Backends != nil {
+		in, out := &in.Backends, &out.Backends
+		*out = make([]EffectiveBackend, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectiveConfiguration.
+func (in *EffectiveConfiguration) DeepCopy() *EffectiveConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectiveConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectiveSplunkBackend) DeepCopyInto(out *EffectiveSplunkBackend) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectiveSplunkBackend.
+func (in *EffectiveSplunkBackend) DeepCopy() *EffectiveSplunkBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectiveSplunkBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InputHealth) DeepCopyInto(out *InputHealth) {
+	*out = *in
+	if in.LastReceivedTime != nil {
+		in, out := &in.LastReceivedTime, &out.LastReceivedTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InputHealth.
+func (in *InputHealth) DeepCopy() *InputHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(InputHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutputCounters) DeepCopyInto(out *OutputCounters) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputCounters.
+func (in *OutputCounters) DeepCopy() *OutputCounters {
+	if in == nil {
+		return nil
+	}
+	out := new(OutputCounters)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutputHealth) DeepCopyInto(out *OutputHealth) {
+	*out = *in
+	if in.LastSuccessfulFlushTime != nil {
+		in, out := &in.LastSuccessfulFlushTime, &out.LastSuccessfulFlushTime
+		*out = (*in).DeepCopy()
+	}
+	if in.BufferUsagePercent != nil {
+		in, out := &in.BufferUsagePercent, &out.BufferUsagePercent
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputHealth.
+func (in *OutputHealth) DeepCopy() *OutputHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(OutputHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodCounters) DeepCopyInto(out *PodCounters) {
+	*out = *in
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]OutputCounters, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodCounters.
+func (in *PodCounters) DeepCopy() *PodCounters {
+	if in == nil {
+		return nil
+	}
+	out := new(PodCounters)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,119 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+)
+
+var availablePersistenceTypes = sets.New(
+	v1alpha1.AuditPersistenceTypePersistentVolume,
+	v1alpha1.AuditPersistenceTypeEphemeral,
+)
+
+// ValidateAuditConfig validates the audit config of a shoot.
+func ValidateAuditConfig(cfg *v1alpha1.AuditConfig) field.ErrorList {
+	var allErrs field.ErrorList
+
+	persistencePath := field.NewPath("persistence")
+
+	// the persistence type decides whether the volumes of the webhook backend are deleted, so it must be explicit
+	if t := cfg.Persistence.Type; t != "" && !availablePersistenceTypes.Has(t) {
+		allErrs = append(allErrs, field.NotSupported(persistencePath.Child("type"), t, sets.List(availablePersistenceTypes)))
+	}
+
+	if cfg.Backends != nil {
+		allErrs = append(allErrs, validateBackends(cfg.Backends, field.NewPath("backends"))...)
+	}
+
+	if cfg.Resources != nil {
+		allErrs = append(allErrs, validateResources(cfg.Resources, field.NewPath("resources"))...)
+	}
+
+	return allErrs
+}
+
+// validateBackends checks the values of the backends that end up in the fluent-bit configuration. fluent-bit expands
+// environment variables in any value, which would allow to send the tokens of other backends to an arbitrary host.
+func validateBackends(backends *v1alpha1.AuditBackends, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if cf := backends.ClusterForwarding; cf != nil {
+		allErrs = append(allErrs, validateFluentBitValue(fldPath.Child("clusterForwarding", "bufferSize"), pointer.SafeDeref(cf.FilesystemBufferSize))...)
+	}
+
+	if splunk := backends.Splunk; splunk != nil {
+		splunkPath := fldPath.Child("splunk")
+
+		allErrs = append(allErrs, validateFluentBitValue(splunkPath.Child("bufferSize"), pointer.SafeDeref(splunk.FilesystemBufferSize))...)
+		allErrs = append(allErrs, validateFluentBitValue(splunkPath.Child("index"), splunk.Index)...)
+		allErrs = append(allErrs, validateFluentBitValue(splunkPath.Child("host"), splunk.Host)...)
+		allErrs = append(allErrs, validateFluentBitValue(splunkPath.Child("port"), splunk.Port)...)
+		allErrs = append(allErrs, validateFluentBitValue(splunkPath.Child("tlshost"), splunk.TlsHost)...)
+
+		keys := make([]string, 0, len(splunk.CustomData))
+		for key := range splunk.CustomData {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			allErrs = append(allErrs, validateFluentBitValue(splunkPath.Child("customData").Key(key), splunk.CustomData[key])...)
+		}
+	}
+
+	return allErrs
+}
+
+func validateFluentBitValue(fldPath *field.Path, value string) field.ErrorList {
+	if strings.Contains(value, "${") {
+		return field.ErrorList{field.Invalid(fldPath, value, "must not reference environment variables")}
+	}
+
+	return nil
+}
+
+// validateResources checks that the quantities are not negative and that the requests do not exceed the limits,
+// the vertical pod autoscaler would otherwise scale the requests above the limits
+func validateResources(resources *corev1.ResourceRequirements, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for _, name := range resourceNames(resources.Requests) {
+		if q := resources.Requests[name]; q.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("requests").Key(string(name)), q.String(), "must be greater than or equal to 0"))
+		}
+	}
+	for _, name := range resourceNames(resources.Limits) {
+		if q := resources.Limits[name]; q.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("limits").Key(string(name)), q.String(), "must be greater than or equal to 0"))
+		}
+	}
+
+	for _, name := range resourceNames(resources.Requests) {
+		request := resources.Requests[name]
+		limit, ok := resources.Limits[name]
+		if ok && request.Cmp(limit) > 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("requests").Key(string(name)), request.String(), fmt.Sprintf("must be less than or equal to %s limit of %s", name, limit.String())))
+		}
+	}
+
+	return allErrs
+}
+
+// resourceNames returns the names of the resource list in a stable order
+func resourceNames(list corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	return names
+}
@@ -0,0 +1,126 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+)
+
+func TestValidateAuditConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *v1alpha1.AuditConfig
+		want   []string
+	}{
+		{
+			name:   "empty audit config",
+			config: &v1alpha1.AuditConfig{},
+		},
+		{
+			name: "known persistence types",
+			config: &v1alpha1.AuditConfig{
+				Persistence: v1alpha1.AuditPersistence{Type: v1alpha1.AuditPersistenceTypeEphemeral},
+			},
+		},
+		{
+			name: "unknown persistence type",
+			config: &v1alpha1.AuditConfig{
+				Persistence: v1alpha1.AuditPersistence{Type: "Ephemeral"},
+			},
+			want: []string{
+				`persistence.type: Unsupported value: "Ephemeral": supported values: "ephemeral", "persistentVolume"`,
+			},
+		},
+		{
+			name: "backends",
+			config: &v1alpha1.AuditConfig{
+				Backends: &v1alpha1.AuditBackends{
+					ClusterForwarding: &v1alpha1.AuditBackendClusterForwarding{Enabled: true, FilesystemBufferSize: pointer.Pointer("100M")},
+					Splunk: &v1alpha1.AuditBackendSplunk{
+						Enabled:    true,
+						Index:      "main",
+						Host:       "splunk.example.com",
+						Port:       "8088",
+						TlsHost:    "splunk.example.com",
+						CustomData: map[string]string{"cluster": "shoot.a"},
+					},
+				},
+			},
+		},
+		{
+			name: "backends exfiltrate the token of the enforced backend",
+			config: &v1alpha1.AuditConfig{
+				Backends: &v1alpha1.AuditBackends{
+					ClusterForwarding: &v1alpha1.AuditBackendClusterForwarding{Enabled: true, FilesystemBufferSize: pointer.Pointer("${STORAGE}")},
+					Splunk: &v1alpha1.AuditBackendSplunk{
+						Enabled:              true,
+						FilesystemBufferSize: pointer.Pointer("100M${A}"),
+						Index:                "${ENFORCED_SPLUNK_HEC_TOKEN}",
+						Host:                 "splunk.${ENFORCED_SPLUNK_HEC_TOKEN}.example.com",
+						Port:                 "${PORT}",
+						TlsHost:              "${HOSTNAME}",
+						CustomData:           map[string]string{"token": "${ENFORCED_SPLUNK_HEC_TOKEN}"},
+					},
+				},
+			},
+			want: []string{
+				`backends.clusterForwarding.bufferSize: Invalid value: "${STORAGE}": must not reference environment variables`,
+				`backends.splunk.bufferSize: Invalid value: "100M${A}": must not reference environment variables`,
+				`backends.splunk.index: Invalid value: "${ENFORCED_SPLUNK_HEC_TOKEN}": must not reference environment variables`,
+				`backends.splunk.host: Invalid value: "splunk.${ENFORCED_SPLUNK_HEC_TOKEN}.example.com": must not reference environment variables`,
+				`backends.splunk.port: Invalid value: "${PORT}": must not reference environment variables`,
+				`backends.splunk.tlshost: Invalid value: "${HOSTNAME}": must not reference environment variables`,
+				`backends.splunk.customData[token]: Invalid value: "${ENFORCED_SPLUNK_HEC_TOKEN}": must not reference environment variables`,
+			},
+		},
+		{
+			name: "resources",
+			config: &v1alpha1.AuditConfig{
+				Resources: &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("100m"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("100m"),
+					},
+				},
+			},
+		},
+		{
+			name: "invalid resources",
+			config: &v1alpha1.AuditConfig{
+				Resources: &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("-100m"),
+						corev1.ResourceMemory: resource.MustParse("2Gi"),
+					},
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:              resource.MustParse("1"),
+						corev1.ResourceMemory:           resource.MustParse("1Gi"),
+						corev1.ResourceEphemeralStorage: resource.MustParse("-1Gi"),
+					},
+				},
+			},
+			want: []string{
+				`resources.requests[cpu]: Invalid value: "-100m": must be greater than or equal to 0`,
+				`resources.limits[ephemeral-storage]: Invalid value: "-1Gi": must be greater than or equal to 0`,
+				`resources.requests[memory]: Invalid value: "2Gi": must be less than or equal to memory limit of 1Gi`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range ValidateAuditConfig(tt.config) {
+				got = append(got, err.Error())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	healthcheckconfig "github.com/gardener/gardener/extensions/pkg/apis/config"
@@ -16,6 +17,107 @@ type ControllerConfiguration struct {
 	// DefaultBackends can be used to configure provider-default backends that are not explicitly disabled from the user.
 	DefaultBackends *v1alpha1.AuditBackends
 
+	// EnforcedBackends are backends that are always added to the audit webhook backend of every shoot. In contrast to the
+	// default backends, they can neither be disabled nor overridden by the user and they do not show up in the user's
+	// configuration. Only the log and the splunk backend can be enforced.
+	EnforcedBackends *v1alpha1.AuditBackends
+
+	// BackendSecrets are the secrets that are referenced by the secretResourceName of the default and enforced backends.
+	BackendSecrets []BackendSecret
+
+	// BackendPolicy restricts the destinations of the backends configured by the users. If it is set, the audit webhook
+	// backend is only allowed to connect to the destinations of the configured backends.
+	BackendPolicy *BackendPolicy
+
+	// DefaultResources are the resource requirements of the audit webhook backend used for shoots that do not configure resources.
+	DefaultResources *corev1.ResourceRequirements
+
+	// DefaultProfiles contain defaults for the shoots matching their selector. They are applied on top of the default
+	// backends and default resources in the order of the list, such that the last matching profile takes precedence
+	// for every backend and the resources it defines.
+	DefaultProfiles []DefaultProfile
+
+	// FluentBitConfigFormat is the format in which the fluent-bit configuration of the audit webhook backend is rendered,
+	// either "classic" or "yaml". The yaml format requires fluent-bit 2.x, it attaches the custom data of a splunk
+	// backend to its output by a processor instead of copying the records to a tag of the backend. Defaults to "classic".
+	FluentBitConfigFormat string
+
 	// HealthCheckConfig is the config for the health check controller
 	HealthCheckConfig *healthcheckconfig.HealthCheckConfig
+
+	// BufferHealth contains the thresholds for the health check of the filesystem buffer of the audit webhook backend.
+	BufferHealth *BufferHealthConfiguration
+
+	// InputHealth contains the configuration for the health check of the audit events received by the audit webhook backend.
+	InputHealth *InputHealthConfiguration
+}
+
+// BufferHealthConfiguration contains the thresholds for the health check of the filesystem buffer of the audit webhook backend.
+type BufferHealthConfiguration struct {
+	// WarningThresholdPercent is the buffer usage in percent from which on the buffer is reported with a warning. Defaults to 70.
+	WarningThresholdPercent *int32
+
+	// FailureThresholdPercent is the buffer usage in percent from which on the buffer is reported as failed. Defaults to 90.
+	FailureThresholdPercent *int32
+
+	// TimeToFullWarningThreshold is the estimated time until the buffer runs full below which the buffer is reported with a warning. Defaults to 1h.
+	TimeToFullWarningThreshold *metav1.Duration
+}
+
+// InputHealthConfiguration contains the configuration for the health check of the audit events received by the audit webhook backend.
+type InputHealthConfiguration struct {
+	// SilencePeriod is the duration without any received audit events after which the audit webhook backend is reported
+	// as failed. A duration of zero disables the detection. Defaults to 15m.
+	SilencePeriod *metav1.Duration
+}
+
+// BackendSecret references a secret in the seed, which is used by the default and enforced backends of the operator.
+type BackendSecret struct {
+	// Name is the name by which the default and enforced backends reference the secret in their secretResourceName.
+	Name string
+
+	// SecretRef references the secret in the seed.
+	SecretRef corev1.SecretReference
+}
+
+// DefaultProfile contains defaults of the operator for the shoots matching the selector.
+type DefaultProfile struct {
+	// Name is the name of the profile.
+	Name string
+
+	// Selector selects the shoots to which the profile applies.
+	Selector DefaultProfileSelector
+
+	// Backends are the default backends for the selected shoots.
+	Backends *v1alpha1.AuditBackends
+
+	// Resources are the default resource requirements of the audit webhook backend for the selected shoots.
+	Resources *corev1.ResourceRequirements
+}
+
+// DefaultProfileSelector selects shoots, a shoot is selected if it matches all of the given criteria.
+type DefaultProfileSelector struct {
+	// Purposes selects shoots with one of the given purposes.
+	Purposes []string
+
+	// ProjectNamespaces selects shoots in one of the given project namespaces.
+	ProjectNamespaces []string
+
+	// ShootLabels selects shoots by their labels.
+	ShootLabels *metav1.LabelSelector
+}
+
+// BackendPolicy restricts the destinations of the backends configured by the users.
+type BackendPolicy struct {
+	// AllowedHosts are the hosts that the users may configure for their backends. A pattern starting with "*." matches
+	// all subdomains of the following domain. If empty, all hosts are allowed.
+	AllowedHosts []string
+
+	// AllowedCIDRs are the networks that the hosts of the users' backends may resolve to. If empty, all networks that are
+	// not denied are allowed.
+	AllowedCIDRs []string
+
+	// DeniedCIDRs are the networks that the hosts of the users' backends must not resolve to, e.g. the private networks
+	// of the seed. They take precedence over the allowed networks.
+	DeniedCIDRs []string
 }
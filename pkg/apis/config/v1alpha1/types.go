@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	healthcheckconfigv1alpha1 "github.com/gardener/gardener/extensions/pkg/apis/config/v1alpha1"
@@ -16,7 +17,128 @@ type ControllerConfiguration struct {
 	// DefaultBackends can be used to configure provider-default backends that are not explicitly disabled from the user.
 	DefaultBackends *v1alpha1.AuditBackends `json:"defaultBackends"`
 
+	// EnforcedBackends are backends that are always added to the audit webhook backend of every shoot. In contrast to the
+	// default backends, they can neither be disabled nor overridden by the user and they do not show up in the user's
+	// configuration. Only the log and the splunk backend can be enforced.
+	// +optional
+	EnforcedBackends *v1alpha1.AuditBackends `json:"enforcedBackends,omitempty"`
+
+	// BackendSecrets are the secrets that are referenced by the secretResourceName of the default and enforced backends.
+	// +optional
+	BackendSecrets []BackendSecret `json:"backendSecrets,omitempty"`
+
+	// BackendPolicy restricts the destinations of the backends configured by the users. If it is set, the audit webhook
+	// backend is only allowed to connect to the destinations of the configured backends.
+	// +optional
+	BackendPolicy *BackendPolicy `json:"backendPolicy,omitempty"`
+
+	// DefaultResources are the resource requirements of the audit webhook backend used for shoots that do not configure resources.
+	// +optional
+	DefaultResources *corev1.ResourceRequirements `json:"defaultResources,omitempty"`
+
+	// DefaultProfiles contain defaults for the shoots matching their selector. They are applied on top of the default
+	// backends and default resources in the order of the list, such that the last matching profile takes precedence
+	// for every backend and the resources it defines.
+	// +optional
+	DefaultProfiles []DefaultProfile `json:"defaultProfiles,omitempty"`
+
+	// FluentBitConfigFormat is the format in which the fluent-bit configuration of the audit webhook backend is rendered,
+	// either "classic" or "yaml". The yaml format requires fluent-bit 2.x, it attaches the custom data of a splunk
+	// backend to its output by a processor instead of copying the records to a tag of the backend. Defaults to "classic".
+	// +optional
+	FluentBitConfigFormat string `json:"fluentBitConfigFormat,omitempty"`
+
 	// HealthCheckConfig is the config for the health check controller
 	// +optional
 	HealthCheckConfig *healthcheckconfigv1alpha1.HealthCheckConfig `json:"healthCheckConfig,omitempty"`
+
+	// BufferHealth contains the thresholds for the health check of the filesystem buffer of the audit webhook backend.
+	// +optional
+	BufferHealth *BufferHealthConfiguration `json:"bufferHealth,omitempty"`
+
+	// InputHealth contains the configuration for the health check of the audit events received by the audit webhook backend.
+	// +optional
+	InputHealth *InputHealthConfiguration `json:"inputHealth,omitempty"`
+}
+
+// BufferHealthConfiguration contains the thresholds for the health check of the filesystem buffer of the audit webhook backend.
+type BufferHealthConfiguration struct {
+	// WarningThresholdPercent is the buffer usage in percent from which on the buffer is reported with a warning. Defaults to 70.
+	// +optional
+	WarningThresholdPercent *int32 `json:"warningThresholdPercent,omitempty"`
+
+	// FailureThresholdPercent is the buffer usage in percent from which on the buffer is reported as failed. Defaults to 90.
+	// +optional
+	FailureThresholdPercent *int32 `json:"failureThresholdPercent,omitempty"`
+
+	// TimeToFullWarningThreshold is the estimated time until the buffer runs full below which the buffer is reported with a warning. Defaults to 1h.
+	// +optional
+	TimeToFullWarningThreshold *metav1.Duration `json:"timeToFullWarningThreshold,omitempty"`
+}
+
+// InputHealthConfiguration contains the configuration for the health check of the audit events received by the audit webhook backend.
+type InputHealthConfiguration struct {
+	// SilencePeriod is the duration without any received audit events after which the audit webhook backend is reported
+	// as failed. A duration of zero disables the detection. Defaults to 15m.
+	// +optional
+	SilencePeriod *metav1.Duration `json:"silencePeriod,omitempty"`
+}
+
+// BackendSecret references a secret in the seed, which is used by the default and enforced backends of the operator.
+type BackendSecret struct {
+	// Name is the name by which the default and enforced backends reference the secret in their secretResourceName.
+	Name string `json:"name"`
+
+	// SecretRef references the secret in the seed.
+	SecretRef corev1.SecretReference `json:"secretRef"`
+}
+
+// DefaultProfile contains defaults of the operator for the shoots matching the selector.
+type DefaultProfile struct {
+	// Name is the name of the profile.
+	Name string `json:"name"`
+
+	// Selector selects the shoots to which the profile applies.
+	Selector DefaultProfileSelector `json:"selector"`
+
+	// Backends are the default backends for the selected shoots.
+	// +optional
+	Backends *v1alpha1.AuditBackends `json:"backends,omitempty"`
+
+	// Resources are the default resource requirements of the audit webhook backend for the selected shoots.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// DefaultProfileSelector selects shoots, a shoot is selected if it matches all of the given criteria.
+type DefaultProfileSelector struct {
+	// Purposes selects shoots with one of the given purposes.
+	// +optional
+	Purposes []string `json:"purposes,omitempty"`
+
+	// ProjectNamespaces selects shoots in one of the given project namespaces.
+	// +optional
+	ProjectNamespaces []string `json:"projectNamespaces,omitempty"`
+
+	// ShootLabels selects shoots by their labels.
+	// +optional
+	ShootLabels *metav1.LabelSelector `json:"shootLabels,omitempty"`
+}
+
+// BackendPolicy restricts the destinations of the backends configured by the users.
+type BackendPolicy struct {
+	// AllowedHosts are the hosts that the users may configure for their backends. A pattern starting with "*." matches
+	// all subdomains of the following domain. If empty, all hosts are allowed.
+	// +optional
+	AllowedHosts []string `json:"allowedHosts,omitempty"`
+
+	// AllowedCIDRs are the networks that the hosts of the users' backends may resolve to. If empty, all networks that are
+	// not denied are allowed.
+	// +optional
+	AllowedCIDRs []string `json:"allowedCIDRs,omitempty"`
+
+	// DeniedCIDRs are the networks that the hosts of the users' backends must not resolve to, e.g. the private networks
+	// of the seed. They take precedence over the allowed networks.
+	// +optional
+	DeniedCIDRs []string `json:"deniedCIDRs,omitempty"`
 }
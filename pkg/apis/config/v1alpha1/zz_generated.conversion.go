@@ -16,6 +16,8 @@ import (
 	configv1alpha1 "github.com/gardener/gardener/extensions/pkg/apis/config/v1alpha1"
 	auditv1alpha1 "github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
 	config "github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -27,6 +29,36 @@ func init() {
 // RegisterConversions adds conversion functions to the given scheme.
 // Public to allow building arbitrary schemes.
 func RegisterConversions(s *runtime.Scheme) error {
+	if err := s.AddGeneratedConversionFunc((*BackendPolicy)(nil), (*config.BackendPolicy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BackendPolicy_To_config_BackendPolicy(a.(*BackendPolicy), b.(*config.BackendPolicy), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.BackendPolicy)(nil), (*BackendPolicy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_BackendPolicy_To_v1alpha1_BackendPolicy(a.(*config.BackendPolicy), b.(*BackendPolicy), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BackendSecret)(nil), (*config.BackendSecret)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BackendSecret_To_config_BackendSecret(a.(*BackendSecret), b.(*config.BackendSecret), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.BackendSecret)(nil), (*BackendSecret)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_BackendSecret_To_v1alpha1_BackendSecret(a.(*config.BackendSecret), b.(*BackendSecret), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BufferHealthConfiguration)(nil), (*config.BufferHealthConfiguration)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BufferHealthConfiguration_To_config_BufferHealthConfiguration(a.(*BufferHealthConfiguration), b.(*config.BufferHealthConfiguration), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.BufferHealthConfiguration)(nil), (*BufferHealthConfiguration)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_BufferHealthConfiguration_To_v1alpha1_BufferHealthConfiguration(a.(*config.BufferHealthConfiguration), b.(*BufferHealthConfiguration), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*ControllerConfiguration)(nil), (*config.ControllerConfiguration)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_ControllerConfiguration_To_config_ControllerConfiguration(a.(*ControllerConfiguration), b.(*config.ControllerConfiguration), scope)
 	}); err != nil {
@@ -37,12 +69,120 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*DefaultProfile)(nil), (*config.DefaultProfile)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_DefaultProfile_To_config_DefaultProfile(a.(*DefaultProfile), b.(*config.DefaultProfile), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.DefaultProfile)(nil), (*DefaultProfile)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_DefaultProfile_To_v1alpha1_DefaultProfile(a.(*config.DefaultProfile), b.(*DefaultProfile), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*DefaultProfileSelector)(nil), (*config.DefaultProfileSelector)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_DefaultProfileSelector_To_config_DefaultProfileSelector(a.(*DefaultProfileSelector), b.(*config.DefaultProfileSelector), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.DefaultProfileSelector)(nil), (*DefaultProfileSelector)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_DefaultProfileSelector_To_v1alpha1_DefaultProfileSelector(a.(*config.DefaultProfileSelector), b.(*DefaultProfileSelector), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*InputHealthConfiguration)(nil), (*config.InputHealthConfiguration)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_InputHealthConfiguration_To_config_InputHealthConfiguration(a.(*InputHealthConfiguration), b.(*config.InputHealthConfiguration), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.InputHealthConfiguration)(nil), (*InputHealthConfiguration)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_InputHealthConfiguration_To_v1alpha1_InputHealthConfiguration(a.(*config.InputHealthConfiguration), b.(*InputHealthConfiguration), scope)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func autoConvert_v1alpha1_BackendPolicy_To_config_BackendPolicy(in *BackendPolicy, out *config.BackendPolicy, s conversion.Scope) error {
+	out.AllowedHosts = *(*[]string)(unsafe.Pointer(&in.AllowedHosts))
+	out.AllowedCIDRs = *(*[]string)(unsafe.Pointer(&in.AllowedCIDRs))
+	out.DeniedCIDRs = *(*[]string)(unsafe.Pointer(&in.DeniedCIDRs))
+	return nil
+}
+
+// Convert_v1alpha1_BackendPolicy_To_config_BackendPolicy is an autogenerated conversion function.
+func Convert_v1alpha1_BackendPolicy_To_config_BackendPolicy(in *BackendPolicy, out *config.BackendPolicy, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BackendPolicy_To_config_BackendPolicy(in, out, s)
+}
+
+func autoConvert_config_BackendPolicy_To_v1alpha1_BackendPolicy(in *config.BackendPolicy, out *BackendPolicy, s conversion.Scope) error {
+	out.AllowedHosts = *(*[]string)(unsafe.Pointer(&in.AllowedHosts))
+	out.AllowedCIDRs = *(*[]string)(unsafe.Pointer(&in.AllowedCIDRs))
+	out.DeniedCIDRs = *(*[]string)(unsafe.Pointer(&in.DeniedCIDRs))
+	return nil
+}
+
+// Convert_config_BackendPolicy_To_v1alpha1_BackendPolicy is an autogenerated conversion function.
+func Convert_config_BackendPolicy_To_v1alpha1_BackendPolicy(in *config.BackendPolicy, out *BackendPolicy, s conversion.Scope) error {
+	return autoConvert_config_BackendPolicy_To_v1alpha1_BackendPolicy(in, out, s)
+}
+
+func autoConvert_v1alpha1_BackendSecret_To_config_BackendSecret(in *BackendSecret, out *config.BackendSecret, s conversion.Scope) error {
+	out.Name = in.Name
+	out.SecretRef = in.SecretRef
+	return nil
+}
+
+// Convert_v1alpha1_BackendSecret_To_config_BackendSecret is an autogenerated conversion function.
+func Convert_v1alpha1_BackendSecret_To_config_BackendSecret(in *BackendSecret, out *config.BackendSecret, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BackendSecret_To_config_BackendSecret(in, out, s)
+}
+
+func autoConvert_config_BackendSecret_To_v1alpha1_BackendSecret(in *config.BackendSecret, out *BackendSecret, s conversion.Scope) error {
+	out.Name = in.Name
+	out.SecretRef = in.SecretRef
 	return nil
 }
 
+// Convert_config_BackendSecret_To_v1alpha1_BackendSecret is an autogenerated conversion function.
+func Convert_config_BackendSecret_To_v1alpha1_BackendSecret(in *config.BackendSecret, out *BackendSecret, s conversion.Scope) error {
+	return autoConvert_config_BackendSecret_To_v1alpha1_BackendSecret(in, out, s)
+}
+
+func autoConvert_v1alpha1_BufferHealthConfiguration_To_config_BufferHealthConfiguration(in *BufferHealthConfiguration, out *config.BufferHealthConfiguration, s conversion.Scope) error {
+	out.WarningThresholdPercent = (*int32)(unsafe.Pointer(in.WarningThresholdPercent))
+	out.FailureThresholdPercent = (*int32)(unsafe.Pointer(in.FailureThresholdPercent))
+	out.TimeToFullWarningThreshold = (*v1.Duration)(unsafe.Pointer(in.TimeToFullWarningThreshold))
+	return nil
+}
+
+// Convert_v1alpha1_BufferHealthConfiguration_To_config_BufferHealthConfiguration is an autogenerated conversion function.
+func Convert_v1alpha1_BufferHealthConfiguration_To_config_BufferHealthConfiguration(in *BufferHealthConfiguration, out *config.BufferHealthConfiguration, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BufferHealthConfiguration_To_config_BufferHealthConfiguration(in, out, s)
+}
+
+func autoConvert_config_BufferHealthConfiguration_To_v1alpha1_BufferHealthConfiguration(in *config.BufferHealthConfiguration, out *BufferHealthConfiguration, s conversion.Scope) error {
+	out.WarningThresholdPercent = (*int32)(unsafe.Pointer(in.WarningThresholdPercent))
+	out.FailureThresholdPercent = (*int32)(unsafe.Pointer(in.FailureThresholdPercent))
+	out.TimeToFullWarningThreshold = (*v1.Duration)(unsafe.Pointer(in.TimeToFullWarningThreshold))
+	return nil
+}
+
+// Convert_config_BufferHealthConfiguration_To_v1alpha1_BufferHealthConfiguration is an autogenerated conversion function.
+func Convert_config_BufferHealthConfiguration_To_v1alpha1_BufferHealthConfiguration(in *config.BufferHealthConfiguration, out *BufferHealthConfiguration, s conversion.Scope) error {
+	return autoConvert_config_BufferHealthConfiguration_To_v1alpha1_BufferHealthConfiguration(in, out, s)
+}
+
 func autoConvert_v1alpha1_ControllerConfiguration_To_config_ControllerConfiguration(in *ControllerConfiguration, out *config.ControllerConfiguration, s conversion.Scope) error {
 	out.DefaultBackends = (*auditv1alpha1.AuditBackends)(unsafe.Pointer(in.DefaultBackends))
+	out.EnforcedBackends = (*auditv1alpha1.AuditBackends)(unsafe.Pointer(in.EnforcedBackends))
+	out.BackendSecrets = *(*[]config.BackendSecret)(unsafe.Pointer(&in.BackendSecrets))
+	out.BackendPolicy = (*config.BackendPolicy)(unsafe.Pointer(in.BackendPolicy))
+	out.DefaultResources = (*corev1.ResourceRequirements)(unsafe.Pointer(in.DefaultResources))
+	out.DefaultProfiles = *(*[]config.DefaultProfile)(unsafe.Pointer(&in.DefaultProfiles))
+	out.FluentBitConfigFormat = in.FluentBitConfigFormat
 	out.HealthCheckConfig = (*apisconfig.HealthCheckConfig)(unsafe.Pointer(in.HealthCheckConfig))
+	out.BufferHealth = (*config.BufferHealthConfiguration)(unsafe.Pointer(in.BufferHealth))
+	out.InputHealth = (*config.InputHealthConfiguration)(unsafe.Pointer(in.InputHealth))
 	return nil
 }
 
@@ -53,7 +193,15 @@ func Convert_v1alpha1_ControllerConfiguration_To_config_ControllerConfiguration(
 
 func autoConvert_config_ControllerConfiguration_To_v1alpha1_ControllerConfiguration(in *config.ControllerConfiguration, out *ControllerConfiguration, s conversion.Scope) error {
 	out.DefaultBackends = (*auditv1alpha1.AuditBackends)(unsafe.Pointer(in.DefaultBackends))
+	out.EnforcedBackends = (*auditv1alpha1.AuditBackends)(unsafe.Pointer(in.EnforcedBackends))
+	out.BackendSecrets = *(*[]BackendSecret)(unsafe.Pointer(&in.BackendSecrets))
+	out.BackendPolicy = (*BackendPolicy)(unsafe.Pointer(in.BackendPolicy))
+	out.DefaultResources = (*corev1.ResourceRequirements)(unsafe.Pointer(in.DefaultResources))
+	out.DefaultProfiles = *(*[]DefaultProfile)(unsafe.Pointer(&in.DefaultProfiles))
+	out.FluentBitConfigFormat = in.FluentBitConfigFormat
 	out.HealthCheckConfig = (*configv1alpha1.HealthCheckConfig)(unsafe.Pointer(in.HealthCheckConfig))
+	out.BufferHealth = (*BufferHealthConfiguration)(unsafe.Pointer(in.BufferHealth))
+	out.InputHealth = (*InputHealthConfiguration)(unsafe.Pointer(in.InputHealth))
 	return nil
 }
 
@@ -61,3 +209,77 @@ func autoConvert_config_ControllerConfiguration_To_v1alpha1_ControllerConfigurat
 func Convert_config_ControllerConfiguration_To_v1alpha1_ControllerConfiguration(in *config.ControllerConfiguration, out *ControllerConfiguration, s conversion.Scope) error {
 	return autoConvert_config_ControllerConfiguration_To_v1alpha1_ControllerConfiguration(in, out, s)
 }
+
+func autoConvert_v1alpha1_DefaultProfile_To_config_DefaultProfile(in *DefaultProfile, out *config.DefaultProfile, s conversion.Scope) error {
+	out.Name = in.Name
+	if err := Convert_v1alpha1_DefaultProfileSelector_To_config_DefaultProfileSelector(&in.Selector, &out.Selector, s); err != nil {
+		return err
+	}
+	out.Backends = (*auditv1alpha1.AuditBackends)(unsafe.Pointer(in.Backends))
+	out.Resources = (*corev1.ResourceRequirements)(unsafe.Pointer(in.Resources))
+	return nil
+}
+
+// Convert_v1alpha1_DefaultProfile_To_config_DefaultProfile is an autogenerated conversion function.
+func Convert_v1alpha1_DefaultProfile_To_config_DefaultProfile(in *DefaultProfile, out *config.DefaultProfile, s conversion.Scope) error {
+	return autoConvert_v1alpha1_DefaultProfile_To_config_DefaultProfile(in, out, s)
+}
+
+func autoConvert_config_DefaultProfile_To_v1alpha1_DefaultProfile(in *config.DefaultProfile, out *DefaultProfile, s conversion.Scope) error {
+	out.Name = in.Name
+	if err := Convert_config_DefaultProfileSelector_To_v1alpha1_DefaultProfileSelector(&in.Selector, &out.Selector, s); err != nil {
+		return err
+	}
+	out.Backends = (*auditv1alpha1.AuditBackends)(unsafe.Pointer(in.Backends))
+	out.Resources = (*corev1.ResourceRequirements)(unsafe.Pointer(in.Resources))
+	return nil
+}
+
+// Convert_config_DefaultProfile_To_v1alpha1_DefaultProfile is an autogenerated conversion function.
+func Convert_config_DefaultProfile_To_v1alpha1_DefaultProfile(in *config.DefaultProfile, out *DefaultProfile, s conversion.Scope) error {
+	return autoConvert_config_DefaultProfile_To_v1alpha1_DefaultProfile(in, out, s)
+}
+
+func autoConvert_v1alpha1_DefaultProfileSelector_To_config_DefaultProfileSelector(in *DefaultProfileSelector, out *config.DefaultProfileSelector, s conversion.Scope) error {
+	out.Purposes = *(*[]string)(unsafe.Pointer(&in.Purposes))
+	out.ProjectNamespaces = *(*[]string)(unsafe.Pointer(&in.ProjectNamespaces))
+	out.ShootLabels = (*v1.LabelSelector)(unsafe.Pointer(in.ShootLabels))
+	return nil
+}
+
+// Convert_v1alpha1_DefaultProfileSelector_To_config_DefaultProfileSelector is an autogenerated conversion function.
+func Convert_v1alpha1_DefaultProfileSelector_To_config_DefaultProfileSelector(in *DefaultProfileSelector, out *config.DefaultProfileSelector, s conversion.Scope) error {
+	return autoConvert_v1alpha1_DefaultProfileSelector_To_config_DefaultProfileSelector(in, out, s)
+}
+
+func autoConvert_config_DefaultProfileSelector_To_v1alpha1_DefaultProfileSelector(in *config.DefaultProfileSelector, out *DefaultProfileSelector, s conversion.Scope) error {
+	out.Purposes = *(*[]string)(unsafe.Pointer(&in.Purposes))
+	out.ProjectNamespaces = *(*[]string)(unsafe.Pointer(&in.ProjectNamespaces))
+	out.ShootLabels = (*v1.LabelSelector)(unsafe.Pointer(in.ShootLabels))
+	return nil
+}
+
+// Convert_config_DefaultProfileSelector_To_v1alpha1_DefaultProfileSelector is an autogenerated conversion function.
+func Convert_config_DefaultProfileSelector_To_v1alpha1_DefaultProfileSelector(in *config.DefaultProfileSelector, out *DefaultProfileSelector, s conversion.Scope) error {
+	return autoConvert_config_DefaultProfileSelector_To_v1alpha1_DefaultProfileSelector(in, out, s)
+}
+
+func autoConvert_v1alpha1_InputHealthConfiguration_To_config_InputHealthConfiguration(in *InputHealthConfiguration, out *config.InputHealthConfiguration, s conversion.Scope) error {
+	out.SilencePeriod = (*v1.Duration)(unsafe.Pointer(in.SilencePeriod))
+	return nil
+}
+
+// Convert_v1alpha1_InputHealthConfiguration_To_config_InputHealthConfiguration is an autogenerated conversion function.
+func Convert_v1alpha1_InputHealthConfiguration_To_config_InputHealthConfiguration(in *InputHealthConfiguration, out *config.InputHealthConfiguration, s conversion.Scope) error {
+	return autoConvert_v1alpha1_InputHealthConfiguration_To_config_InputHealthConfiguration(in, out, s)
+}
+
+func autoConvert_config_InputHealthConfiguration_To_v1alpha1_InputHealthConfiguration(in *config.InputHealthConfiguration, out *InputHealthConfiguration, s conversion.Scope) error {
+	out.SilencePeriod = (*v1.Duration)(unsafe.Pointer(in.SilencePeriod))
+	return nil
+}
+
+// Convert_config_InputHealthConfiguration_To_v1alpha1_InputHealthConfiguration is an autogenerated conversion function.
+func Convert_config_InputHealthConfiguration_To_v1alpha1_InputHealthConfiguration(in *config.InputHealthConfiguration, out *InputHealthConfiguration, s conversion.Scope) error {
+	return autoConvert_config_InputHealthConfiguration_To_v1alpha1_InputHealthConfiguration(in, out, s)
+}
@@ -0,0 +1,143 @@
+package validation
+
+import (
+	"net"
+	"strings"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-audit/pkg/fluentbitconfig"
+)
+
+var availableFluentBitConfigFormats = sets.New(
+	string(fluentbitconfig.FormatClassic),
+	string(fluentbitconfig.FormatYAML),
+)
+
+var availablePurposes = sets.New(
+	gardencorev1beta1.ShootPurposeEvaluation,
+	gardencorev1beta1.ShootPurposeTesting,
+	gardencorev1beta1.ShootPurposeDevelopment,
+	gardencorev1beta1.ShootPurposeProduction,
+	gardencorev1beta1.ShootPurposeInfrastructure,
+)
+
+// ValidateConfiguration validates the controller configuration.
+func ValidateConfiguration(cfg *config.ControllerConfiguration) field.ErrorList {
+	var (
+		allErrs     field.ErrorList
+		secretNames = sets.New[string]()
+	)
+
+	secretsPath := field.NewPath("backendSecrets")
+	for i, secret := range cfg.BackendSecrets {
+		idxPath := secretsPath.Index(i)
+
+		if secret.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), "name must be set"))
+		} else if secretNames.Has(secret.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), secret.Name))
+		}
+		secretNames.Insert(secret.Name)
+
+		if secret.SecretRef.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("secretRef", "name"), "secret name must be set"))
+		}
+		if secret.SecretRef.Namespace == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("secretRef", "namespace"), "secret namespace must be set"))
+		}
+	}
+
+	// default backends are only applied when the user does not configure the backend, so their secrets are
+	// validated regardless of whether they are enabled
+	if splunk := pointer.SafeDeref(cfg.DefaultBackends).Splunk; splunk != nil {
+		allErrs = append(allErrs, validateSecretResourceName(splunk.SecretResourceName, secretNames, field.NewPath("defaultBackends", "splunk", "secretResourceName"))...)
+	}
+
+	profileNames := sets.New[string]()
+	profilesPath := field.NewPath("defaultProfiles")
+	for i, profile := range cfg.DefaultProfiles {
+		idxPath := profilesPath.Index(i)
+
+		if profile.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), "name must be set"))
+		} else if profileNames.Has(profile.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), profile.Name))
+		}
+		profileNames.Insert(profile.Name)
+
+		for j, purpose := range profile.Selector.Purposes {
+			if !availablePurposes.Has(gardencorev1beta1.ShootPurpose(purpose)) {
+				allErrs = append(allErrs, field.NotSupported(idxPath.Child("selector", "purposes").Index(j), purpose, sets.List(availablePurposes)))
+			}
+		}
+
+		if profile.Selector.ShootLabels != nil {
+			if _, err := metav1.LabelSelectorAsSelector(profile.Selector.ShootLabels); err != nil {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("selector", "shootLabels"), profile.Selector.ShootLabels, err.Error()))
+			}
+		}
+
+		if splunk := pointer.SafeDeref(profile.Backends).Splunk; splunk != nil {
+			allErrs = append(allErrs, validateSecretResourceName(splunk.SecretResourceName, secretNames, idxPath.Child("backends", "splunk", "secretResourceName"))...)
+		}
+	}
+
+	if policy := cfg.BackendPolicy; policy != nil {
+		policyPath := field.NewPath("backendPolicy")
+
+		for i, host := range policy.AllowedHosts {
+			if host == "" || strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+				allErrs = append(allErrs, field.Invalid(policyPath.Child("allowedHosts").Index(i), host, `host must be a domain name, optionally prefixed with "*."`))
+			}
+		}
+		allErrs = append(allErrs, validateCIDRs(policy.AllowedCIDRs, policyPath.Child("allowedCIDRs"))...)
+		allErrs = append(allErrs, validateCIDRs(policy.DeniedCIDRs, policyPath.Child("deniedCIDRs"))...)
+	}
+
+	if cfg.FluentBitConfigFormat != "" && !availableFluentBitConfigFormats.Has(cfg.FluentBitConfigFormat) {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("fluentBitConfigFormat"), cfg.FluentBitConfigFormat, sets.List(availableFluentBitConfigFormats)))
+	}
+
+	if enforced := cfg.EnforcedBackends; enforced != nil {
+		enforcedPath := field.NewPath("enforcedBackends")
+
+		if pointer.SafeDeref(enforced.ClusterForwarding).Enabled {
+			allErrs = append(allErrs, field.Forbidden(enforcedPath.Child("clusterForwarding"), "cluster forwarding can not be enforced as it forwards to the shoot, which is controlled by the user"))
+		}
+
+		if splunk := enforced.Splunk; pointer.SafeDeref(splunk).Enabled {
+			allErrs = append(allErrs, validateSecretResourceName(splunk.SecretResourceName, secretNames, enforcedPath.Child("splunk", "secretResourceName"))...)
+		}
+	}
+
+	return allErrs
+}
+
+func validateSecretResourceName(name string, secretNames sets.Set[string], fldPath *field.Path) field.ErrorList {
+	if name == "" {
+		return field.ErrorList{field.Required(fldPath, "secret resource name must be set")}
+	}
+	if !secretNames.Has(name) {
+		return field.ErrorList{field.Invalid(fldPath, name, "secret is not configured in backendSecrets")}
+	}
+
+	return nil
+}
+
+func validateCIDRs(cidrs []string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, cidr := range cidrs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), cidr, err.Error()))
+		}
+	}
+
+	return allErrs
+}
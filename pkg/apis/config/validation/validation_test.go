@@ -0,0 +1,170 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+)
+
+func TestValidateConfiguration(t *testing.T) {
+	splunkSecret := config.BackendSecret{
+		Name:      "splunk",
+		SecretRef: corev1.SecretReference{Name: "default-backend-secret-splunk", Namespace: "garden"},
+	}
+
+	tests := []struct {
+		name   string
+		config *config.ControllerConfiguration
+		want   []string
+	}{
+		{
+			name:   "empty configuration",
+			config: &config.ControllerConfiguration{},
+		},
+		{
+			name: "default and enforced backends with secrets",
+			config: &config.ControllerConfiguration{
+				DefaultBackends: &v1alpha1.AuditBackends{
+					Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, SecretResourceName: "splunk"},
+				},
+				EnforcedBackends: &v1alpha1.AuditBackends{
+					Log:    &v1alpha1.AuditBackendLog{Enabled: true},
+					Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, SecretResourceName: "splunk"},
+				},
+				BackendSecrets: []config.BackendSecret{splunkSecret},
+			},
+		},
+		{
+			name: "invalid backend secrets",
+			config: &config.ControllerConfiguration{
+				BackendSecrets: []config.BackendSecret{
+					splunkSecret,
+					splunkSecret,
+					{},
+				},
+			},
+			want: []string{
+				`backendSecrets[1].name: Duplicate value: "splunk"`,
+				"backendSecrets[2].name: Required value: name must be set",
+				"backendSecrets[2].secretRef.name: Required value: secret name must be set",
+				"backendSecrets[2].secretRef.namespace: Required value: secret namespace must be set",
+			},
+		},
+		{
+			name: "default backend secret not configured",
+			config: &config.ControllerConfiguration{
+				DefaultBackends: &v1alpha1.AuditBackends{
+					// also disabled default backends are applied to the users that do not configure the backend
+					Splunk: &v1alpha1.AuditBackendSplunk{Enabled: false, SecretResourceName: "splunk"},
+				},
+			},
+			want: []string{
+				`defaultBackends.splunk.secretResourceName: Invalid value: "splunk": secret is not configured in backendSecrets`,
+			},
+		},
+		{
+			name: "default profiles",
+			config: &config.ControllerConfiguration{
+				DefaultProfiles: []config.DefaultProfile{
+					{
+						Name: "production",
+						Selector: config.DefaultProfileSelector{
+							Purposes:    []string{"production"},
+							ShootLabels: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "a"}},
+						},
+						Backends: &v1alpha1.AuditBackends{
+							Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, SecretResourceName: "splunk"},
+						},
+					},
+				},
+				BackendSecrets: []config.BackendSecret{splunkSecret},
+			},
+		},
+		{
+			name: "invalid default profiles",
+			config: &config.ControllerConfiguration{
+				DefaultProfiles: []config.DefaultProfile{
+					{
+						Name: "a",
+						Selector: config.DefaultProfileSelector{
+							Purposes: []string{"staging"},
+							ShootLabels: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+								{Key: "tenant", Operator: "Near"},
+							}},
+						},
+						Backends: &v1alpha1.AuditBackends{
+							Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, SecretResourceName: "unknown"},
+						},
+					},
+					{Name: "a"},
+				},
+			},
+			want: []string{
+				`defaultProfiles[0].selector.purposes[0]: Unsupported value: "staging": supported values: "development", "evaluation", "infrastructure", "production", "testing"`,
+				`defaultProfiles[0].selector.shootLabels: Invalid value: v1.LabelSelector{MatchLabels:map[string]string(nil), MatchExpressions:[]v1.LabelSelectorRequirement{v1.LabelSelectorRequirement{Key:"tenant", Operator:"Near", Values:[]string(nil)}}}: "Near" is not a valid label selector operator`,
+				`defaultProfiles[0].backends.splunk.secretResourceName: Invalid value: "unknown": secret is not configured in backendSecrets`,
+				`defaultProfiles[1].name: Duplicate value: "a"`,
+			},
+		},
+		{
+			name: "backend policy",
+			config: &config.ControllerConfiguration{
+				BackendPolicy: &config.BackendPolicy{
+					AllowedHosts: []string{"splunk.example.com", "*.splunk.example.com", "", "splunk.*.com"},
+					AllowedCIDRs: []string{"203.0.113.0/24", "2001:db8::/32"},
+					DeniedCIDRs:  []string{"10.0.0.0/8", "10.0.0.1"},
+				},
+			},
+			want: []string{
+				`backendPolicy.allowedHosts[2]: Invalid value: "": host must be a domain name, optionally prefixed with "*."`,
+				`backendPolicy.allowedHosts[3]: Invalid value: "splunk.*.com": host must be a domain name, optionally prefixed with "*."`,
+				`backendPolicy.deniedCIDRs[1]: Invalid value: "10.0.0.1": invalid CIDR address: 10.0.0.1`,
+			},
+		},
+		{
+			name: "unsupported enforced backends",
+			config: &config.ControllerConfiguration{
+				EnforcedBackends: &v1alpha1.AuditBackends{
+					ClusterForwarding: &v1alpha1.AuditBackendClusterForwarding{Enabled: true},
+					Splunk:            &v1alpha1.AuditBackendSplunk{Enabled: true},
+				},
+			},
+			want: []string{
+				"enforcedBackends.clusterForwarding: Forbidden: cluster forwarding can not be enforced as it forwards to the shoot, which is controlled by the user",
+				"enforcedBackends.splunk.secretResourceName: Required value: secret resource name must be set",
+			},
+		},
+		{
+			name: "custom data of enforced splunk backend",
+			config: &config.ControllerConfiguration{
+				BackendSecrets: []config.BackendSecret{splunkSecret},
+				EnforcedBackends: &v1alpha1.AuditBackends{
+					Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, SecretResourceName: "splunk", CustomData: map[string]string{"a": "b"}},
+				},
+			},
+		},
+		{
+			name: "unsupported fluent-bit config format",
+			config: &config.ControllerConfiguration{
+				FluentBitConfigFormat: "toml",
+			},
+			want: []string{
+				`fluentBitConfigFormat: Unsupported value: "toml": supported values: "classic", "yaml"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range ValidateConfiguration(tt.config) {
+				got = append(got, err.Error())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
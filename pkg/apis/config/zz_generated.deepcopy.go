@@ -12,9 +12,90 @@ package config
 import (
 	apisconfig "github.com/gardener/gardener/extensions/pkg/apis/config"
 	v1alpha1 "github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendPolicy) DeepCopyInto(out *BackendPolicy) {
+	*out = *in
+	if in.AllowedHosts != nil {
+		in, out := &in.AllowedHosts, &out.AllowedHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedCIDRs != nil {
+		in, out := &in.AllowedCIDRs, &out.AllowedCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedCIDRs != nil {
+		in, out := &in.DeniedCIDRs, &out.DeniedCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendPolicy.
+func (in *BackendPolicy) DeepCopy() *BackendPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendSecret) DeepCopyInto(out *BackendSecret) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecret.
+func (in *BackendSecret) DeepCopy() *BackendSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BufferHealthConfiguration) DeepCopyInto(out *BufferHealthConfiguration) {
+	*out = *in
+	if in.WarningThresholdPercent != nil {
+		in, out := &in.WarningThresholdPercent, &out.WarningThresholdPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureThresholdPercent != nil {
+		in, out := &in.FailureThresholdPercent, &out.FailureThresholdPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeToFullWarningThreshold != nil {
+		in, out := &in.TimeToFullWarningThreshold, &out.TimeToFullWarningThreshold
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BufferHealthConfiguration.
+func (in *BufferHealthConfiguration) DeepCopy() *BufferHealthConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(BufferHealthConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControllerConfiguration) DeepCopyInto(out *ControllerConfiguration) {
 	*out = *in
@@ -24,11 +105,48 @@ func (in *ControllerConfiguration) DeepCopyInto(out *ControllerConfiguration) {
 		*out = new(v1alpha1.AuditBackends)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.EnforcedBackends != nil {
+		in, out := &in.EnforcedBackends, &out.EnforcedBackends
+		*out = new(v1alpha1.AuditBackends)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BackendSecrets != nil {
+		in, out := &in.BackendSecrets, &out.BackendSecrets
+		*out = make([]BackendSecret, len(*in))
+		copy(*out, *in)
+	}
+	if in.BackendPolicy != nil {
+		in, out := &in.BackendPolicy, &out.BackendPolicy
+		*out = new(BackendPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DefaultResources != nil {
+		in, out := &in.DefaultResources, &out.DefaultResources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DefaultProfiles != nil {
+		in, out := &in.DefaultProfiles, &out.DefaultProfiles
+		*out = make([]DefaultProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.HealthCheckConfig != nil {
 		in, out := &in.HealthCheckConfig, &out.HealthCheckConfig
 		*out = new(apisconfig.HealthCheckConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.BufferHealth != nil {
+		in, out := &in.BufferHealth, &out.BufferHealth
+		*out = new(BufferHealthConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InputHealth != nil {
+		in, out := &in.InputHealth, &out.InputHealth
+		*out = new(InputHealthConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -49,3 +167,82 @@ func (in *ControllerConfiguration) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultProfile) DeepCopyInto(out *DefaultProfile) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+	if in.Backends != nil {
+		in, out := &in.Backends, &out.Backends
+		*out = new(v1alpha1.AuditBackends)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultProfile.
+func (in *DefaultProfile) DeepCopy() *DefaultProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultProfileSelector) DeepCopyInto(out *DefaultProfileSelector) {
+	*out = *in
+	if in.Purposes != nil {
+		in, out := &in.Purposes, &out.Purposes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ProjectNamespaces != nil {
+		in, out := &in.ProjectNamespaces, &out.ProjectNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ShootLabels != nil {
+		in, out := &in.ShootLabels, &out.ShootLabels
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultProfileSelector.
+func (in *DefaultProfileSelector) DeepCopy() *DefaultProfileSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultProfileSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InputHealthConfiguration) DeepCopyInto(out *InputHealthConfiguration) {
+	*out = *in
+	if in.SilencePeriod != nil {
+		in, out := &in.SilencePeriod, &out.SilencePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InputHealthConfiguration.
+func (in *InputHealthConfiguration) DeepCopy() *InputHealthConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(InputHealthConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -7,6 +7,7 @@ import (
 	healthcheckconfig "github.com/gardener/gardener/extensions/pkg/apis/config"
 	configapi "github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
 	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config/validation"
 
 	"github.com/spf13/pflag"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -43,28 +44,38 @@ func (o *AuthOptions) Complete() error {
 	if o.ConfigLocation == "" {
 		return errors.New("config location is not set")
 	}
-	data, err := os.ReadFile(o.ConfigLocation)
+	config, err := LoadConfiguration(o.ConfigLocation)
 	if err != nil {
 		return err
 	}
 
-	config := configapi.ControllerConfiguration{}
-	_, _, err = decoder.Decode(data, nil, &config)
-	if err != nil {
-		return err
+	if errs := validation.ValidateConfiguration(config); len(errs) > 0 {
+		return errs.ToAggregate()
 	}
 
-	// if errs := validation.ValidateConfiguration(&config); len(errs) > 0 {
-	// 	return errs.ToAggregate()
-	// }
-
 	o.config = &AuthServiceConfig{
-		config: config,
+		config: *config,
 	}
 
 	return nil
 }
 
+// LoadConfiguration reads and decodes the controller configuration from the given file without validating it.
+func LoadConfiguration(path string) (*configapi.ControllerConfiguration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &configapi.ControllerConfiguration{}
+	_, _, err = decoder.Decode(data, nil, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
 // Completed returns the decoded RegistryServiceConfiguration instance. Only call this if `Complete` was successful.
 func (o *AuthOptions) Completed() *AuthServiceConfig {
 	return o.config
@@ -3,17 +3,22 @@ package audit
 import (
 	"context"
 	"fmt"
-	"os"
+	"net"
 	"path"
 	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gardener/gardener/extensions/pkg/controller/extension"
 
 	"github.com/gardener/gardener/extensions/pkg/controller"
 	extensionssecretsmanager "github.com/gardener/gardener/extensions/pkg/util/secret/manager"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
 	"github.com/gardener/gardener/pkg/apis/core/v1beta1/helper"
 	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	resourcesv1alpha1 "github.com/gardener/gardener/pkg/apis/resources/v1alpha1"
 	"github.com/gardener/gardener/pkg/client/kubernetes"
 	"github.com/gardener/gardener/pkg/extensions"
 	"github.com/gardener/gardener/pkg/utils"
@@ -28,104 +33,272 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	vpaautoscalingv1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/validation"
 	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
 	"github.com/metal-stack/gardener-extension-audit/pkg/fluentbitconfig"
 	"github.com/metal-stack/gardener-extension-audit/pkg/imagevector"
+	"github.com/metal-stack/gardener-extension-audit/pkg/metrics"
 
 	configlatest "k8s.io/client-go/tools/clientcmd/api/latest"
 	configv1 "k8s.io/client-go/tools/clientcmd/api/v1"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// fluentbitConfigDir is the directory into which the files of the fluent-bit configuration are mounted
+	fluentbitConfigDir = "/config"
+	// fluentbitParsersFile is the name of the file that contains the parsers of the fluent-bit configuration
+	fluentbitParsersFile = "parsers.conf"
+	// fluentbitConfigMapName is the name of the config map that contains the files of the fluent-bit configuration
+	fluentbitConfigMapName = "audit-fluent-bit-config"
+	// clusterForwardingAccessSecretName is the name of the shoot access secret of the vpn gateway of the cluster forwarding
+	clusterForwardingAccessSecretName = gutil.SecretNamePrefixShootAccess + "audit-cluster-forwarding-vpn-gateway"
+)
+
 // NewActuator returns an actuator responsible for Extension resources.
 func NewActuator(mgr manager.Manager, config config.ControllerConfiguration) extension.Actuator {
 	return &actuator{
-		client:  mgr.GetClient(),
-		decoder: serializer.NewCodecFactory(mgr.GetScheme(), serializer.EnableStrict).UniversalDecoder(),
-		config:  config,
+		client:   mgr.GetClient(),
+		decoder:  serializer.NewCodecFactory(mgr.GetScheme(), serializer.EnableStrict).UniversalDecoder(),
+		config:   config,
+		resolver: net.DefaultResolver,
+		recorder: mgr.GetEventRecorderFor(ControllerName),
 	}
 }
 
 type actuator struct {
-	client  client.Client
-	decoder runtime.Decoder
-	config  config.ControllerConfiguration
+	client   client.Client
+	decoder  runtime.Decoder
+	config   config.ControllerConfiguration
+	resolver resolver
+	recorder record.EventRecorder
 }
 
 // Reconcile the Extension resource.
-func (a *actuator) Reconcile(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) error {
+func (a *actuator) Reconcile(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) (err error) {
 	auditConfig := &v1alpha1.AuditConfig{}
+	defer func() {
+		err = withErrorCode(err)
+		recordReconcile(auditConfig, err)
+		if err != nil {
+			recordErrorEvent(ctx, a.client, a.recorder, ex, err)
+		}
+	}()
+
+	state, err := a.desiredState(ctx, log, ex, auditConfig)
+	if err != nil {
+		return err
+	}
+
+	recordWarningEvents(a.recorder, ex, state.warnings)
+
+	checksum, err := a.createResources(ctx, log, auditConfig, state.cluster, state.splunkSecret, state.enforced, ex.GetNamespace())
+	if err != nil {
+		return err
+	}
+
+	configuration := effectiveConfiguration(auditConfig, state.userBackends, state.enforced.backends, state.profiles, checksum)
+	if err := a.updateProviderStatus(ctx, ex, configuration); err != nil {
+		return fmt.Errorf("unable to update provider status: %w", err)
+	}
+
+	return nil
+}
+
+// desiredState contains the inputs from which the resources of the audit webhook backend of a shoot are rendered
+type desiredState struct {
+	cluster      *extensions.Cluster
+	userBackends *v1alpha1.AuditBackends
+	profiles     []string
+	splunkSecret *corev1.Secret
+	enforced     enforcedBackends
+	warnings     []string
+}
+
+// desiredState decodes the provider config of the extension into the given audit config, applies the defaults of the
+// operator and collects the secrets of the backends. it only reads from the seed, such that the resources can also be
+// rendered without changing anything.
+func (a *actuator) desiredState(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension, auditConfig *v1alpha1.AuditConfig) (*desiredState, error) {
 	if ex.Spec.ProviderConfig != nil {
 		if _, _, err := a.decoder.Decode(ex.Spec.ProviderConfig.Raw, nil, auditConfig); err != nil {
-			return fmt.Errorf("failed to decode provider config: %w", err)
+			return nil, configurationProblem(fmt.Errorf("failed to decode provider config: %w", err))
 		}
 	}
 
+	if errs := validation.ValidateAuditConfig(auditConfig); len(errs) > 0 {
+		return nil, configurationProblem(fmt.Errorf("failed to validate audit config: %w", errs.ToAggregate()))
+	}
+
 	err := validateSplunkCustomData(auditConfig)
 	if err != nil {
-		return fmt.Errorf("failed to validate audit config: customData for splunk may only contain letters, numbers, and _ or . %w", err)
+		return nil, configurationProblem(fmt.Errorf("failed to validate audit config: customData for splunk may only contain letters, numbers, and _ or . %w", err))
 	}
 
-	backends, defaultBackendSecrets, err := a.applyDefaultBackends(ctx, log, auditConfig.Backends)
+	// only the backends of the user are restricted, the default backends of the operator are applied afterwards
+	err = validateBackendPolicy(ctx, a.resolver, a.config.BackendPolicy, auditConfig.Backends)
 	if err != nil {
-		log.Error(err, "unable to apply default backends configured by operator, continuing anyway but configuration of this extension needs to be checked")
-	} else {
-		auditConfig.Backends = backends
+		return nil, fmt.Errorf("failed to validate audit config: %w", err)
 	}
 
-	namespace := ex.GetNamespace()
+	cluster, err := controller.GetCluster(ctx, a.client, ex.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
 
-	cluster, err := controller.GetCluster(ctx, a.client, namespace)
+	defaults, err := ResolveDefaults(a.config, cluster.Shoot)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("unable to resolve defaults configured by operator: %w", err)
+	}
+	if len(defaults.Profiles) > 0 {
+		log.Info("applying default profiles", "profiles", defaults.Profiles)
+	}
+
+	userBackends := auditConfig.Backends.DeepCopy()
+
+	backends, defaultBackendSecrets, err := a.applyDefaultBackends(ctx, log, auditConfig.Backends, defaults.Backends)
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply default backends configured by operator: %w", err)
+	}
+	auditConfig.Backends = backends
+
+	defaultPersistenceType(auditConfig, cluster)
+
+	if auditConfig.Resources == nil && defaults.Resources != nil {
+		auditConfig.Resources = defaults.Resources
+	}
+
+	warnings := persistenceWarnings(auditConfig)
+	for _, warning := range warnings {
+		log.Info("warning: questionable audit configuration", "reason", warning)
 	}
 
 	splunkSecret := &corev1.Secret{}
 	if pointer.SafeDeref(auditConfig.Backends.Splunk).Enabled {
 		splunkSecret, err = a.findBackendSecret(ctx, cluster, defaultBackendSecrets, auditConfig.Backends.Splunk.SecretResourceName)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if splunkSecret == nil {
+			err := fmt.Errorf("secret resource with name %q not found in shoot resources or default backend secrets", auditConfig.Backends.Splunk.SecretResourceName)
+			if pointer.SafeDeref(userBackends).Splunk == nil {
+				// the secret of a default backend is provided by the operator
+				return nil, err
+			}
+			return nil, configurationProblem(err)
 		}
 
 		_, ok := splunkSecret.Data[v1alpha1.SplunkSecretTokenKey]
 		if !ok {
-			return fmt.Errorf("referenced splunk secret does not contain contents under key %q", v1alpha1.SplunkSecretTokenKey)
+			err := fmt.Errorf("referenced splunk secret does not contain contents under key %q", v1alpha1.SplunkSecretTokenKey)
+			if pointer.SafeDeref(userBackends).Splunk == nil {
+				// the secret of a default backend is provided by the operator
+				return nil, err
+			}
+			return nil, configurationProblem(err)
 		}
 	}
 
-	if err := a.createResources(ctx, log, auditConfig, cluster, splunkSecret, namespace); err != nil {
-		return err
+	enforced, err := a.enforcedBackends(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply enforced backends configured by operator: %w", err)
 	}
 
-	return nil
+	return &desiredState{
+		cluster:      cluster,
+		userBackends: userBackends,
+		profiles:     defaults.Profiles,
+		splunkSecret: splunkSecret,
+		enforced:     enforced,
+		warnings:     warnings,
+	}, nil
+}
+
+// enforcedBackends are the backends enforced by the operator together with the secrets they reference
+type enforcedBackends struct {
+	backends     *v1alpha1.AuditBackends
+	splunkSecret *corev1.Secret
+}
+
+// enforcedBackends returns the backends enforced by the operator. they are taken from the controller configuration
+// only, such that the user's configuration and the secrets referenced in the shoot resources can not affect them.
+func (a *actuator) enforcedBackends(ctx context.Context) (enforcedBackends, error) {
+	enforced := enforcedBackends{
+		backends: &v1alpha1.AuditBackends{},
+	}
+
+	if a.config.EnforcedBackends == nil {
+		return enforced, nil
+	}
+
+	enforced.backends = a.config.EnforcedBackends.DeepCopy()
+	v1alpha1.DefaultBackends(enforced.backends)
+
+	// the enforced backends are validated on startup, only cluster forwarding is rejected as it forwards to the shoot
+	if splunk := enforced.backends.Splunk; pointer.SafeDeref(splunk).Enabled {
+		secret, err := a.operatorBackendSecret(ctx, splunk.SecretResourceName)
+		if err != nil {
+			return enforced, err
+		}
+
+		if _, ok := secret.Data[v1alpha1.SplunkSecretTokenKey]; !ok {
+			return enforced, fmt.Errorf("enforced splunk secret does not contain contents under key %q", v1alpha1.SplunkSecretTokenKey)
+		}
+
+		enforced.splunkSecret = secret
+	}
+
+	return enforced, nil
+}
+
+// operatorBackendSecret returns a secret for a backend configured by the operator
+func (a *actuator) operatorBackendSecret(ctx context.Context, secretName string) (*corev1.Secret, error) {
+	ref, ok := backendSecretRef(a.config.BackendSecrets, secretName)
+	if !ok {
+		return nil, fmt.Errorf("backend secret %q is not configured in the controller configuration", secretName)
+	}
+
+	secret := &corev1.Secret{}
+	err := a.client.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, secret)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get default backend secret: %w", err)
+	}
+
+	return secret, nil
+}
+
+// backendSecretRef returns the reference to the secret that the operator's backends refer to by the given name
+func backendSecretRef(backendSecrets []config.BackendSecret, name string) (corev1.SecretReference, bool) {
+	for _, s := range backendSecrets {
+		if s.Name == name {
+			return s.SecretRef, true
+		}
+	}
+
+	return corev1.SecretReference{}, false
 }
 
 // applyDefaultBackends adds default backends configured by the operator to the audit config in case this backend is not explcitly defined by the user.
 // it returns the backends to which defaults were applied and a map of secrets that contains secrets referenced by the operator's default backends.
-func (a *actuator) applyDefaultBackends(ctx context.Context, log logr.Logger, backends *v1alpha1.AuditBackends) (*v1alpha1.AuditBackends, map[string]*corev1.Secret, error) {
+func (a *actuator) applyDefaultBackends(ctx context.Context, log logr.Logger, backends, defaultBackends *v1alpha1.AuditBackends) (*v1alpha1.AuditBackends, map[string]*corev1.Secret, error) {
 	var (
 		secrets   = map[string]*corev1.Secret{}
 		addSecret = func(secretName string) error {
-			secret := &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      os.Getenv("BACKEND_SECRET_PREFIX") + secretName,
-					Namespace: os.Getenv("BACKEND_SECRET_NAMESPACE"),
-				},
-			}
-
-			err := a.client.Get(ctx, client.ObjectKeyFromObject(secret), secret)
+			secret, err := a.operatorBackendSecret(ctx, secretName)
 			if err != nil {
-				return fmt.Errorf("unable to get default backend secret: %w", err)
+				return err
 			}
 
 			secrets[secretName] = secret
@@ -139,22 +312,22 @@ func (a *actuator) applyDefaultBackends(ctx context.Context, log logr.Logger, ba
 	}
 	defaultedBackends := backends.DeepCopy()
 
-	if a.config.DefaultBackends == nil {
+	if defaultBackends == nil {
 		// no default backends configured by the operator, nothing needs to be defaulted
 		return defaultedBackends, secrets, nil
 	}
 
-	if a.config.DefaultBackends.Log != nil && backends.Log == nil {
+	if defaultBackends.Log != nil && backends.Log == nil {
 		log.Info(`configuring default backend "log"`)
-		defaultedBackends.Log = a.config.DefaultBackends.Log
+		defaultedBackends.Log = defaultBackends.Log
 	}
-	if a.config.DefaultBackends.ClusterForwarding != nil && backends.ClusterForwarding == nil {
+	if defaultBackends.ClusterForwarding != nil && backends.ClusterForwarding == nil {
 		log.Info(`configuring default backend "cluster forwarding"`)
-		defaultedBackends.ClusterForwarding = a.config.DefaultBackends.ClusterForwarding
+		defaultedBackends.ClusterForwarding = defaultBackends.ClusterForwarding
 	}
-	if a.config.DefaultBackends.Splunk != nil && backends.Splunk == nil {
+	if defaultBackends.Splunk != nil && backends.Splunk == nil {
 		log.Info(`configuring default backend "splunk"`)
-		defaultedBackends.Splunk = a.config.DefaultBackends.Splunk
+		defaultedBackends.Splunk = defaultBackends.Splunk
 
 		err := addSecret(defaultedBackends.Splunk.SecretResourceName)
 		if err != nil {
@@ -167,9 +340,39 @@ func (a *actuator) applyDefaultBackends(ctx context.Context, log logr.Logger, ba
 	return defaultedBackends, secrets, nil
 }
 
+// WithDefaultBackends returns the backends with the operator's default backends for backends that are not configured by the user
+func WithDefaultBackends(backends, defaultBackends *v1alpha1.AuditBackends) *v1alpha1.AuditBackends {
+	result := &v1alpha1.AuditBackends{}
+	if backends != nil {
+		result = backends.DeepCopy()
+	}
+
+	if defaultBackends == nil {
+		return result
+	}
+
+	if result.Log == nil {
+		result.Log = defaultBackends.Log.DeepCopy()
+	}
+	if result.ClusterForwarding == nil {
+		result.ClusterForwarding = defaultBackends.ClusterForwarding.DeepCopy()
+	}
+	if result.Splunk == nil {
+		result.Splunk = defaultBackends.Splunk.DeepCopy()
+	}
+
+	return result
+}
+
 // Delete the Extension resource.
 func (a *actuator) Delete(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) error {
-	return a.deleteResources(ctx, log, ex.GetNamespace())
+	if err := a.deleteResources(ctx, log, ex.GetNamespace()); err != nil {
+		return err
+	}
+
+	metrics.DeleteShoot(ex.GetNamespace())
+
+	return nil
 }
 
 func (a *actuator) ForceDelete(_ context.Context, _ logr.Logger, _ *extensionsv1alpha1.Extension) error {
@@ -186,54 +389,82 @@ func (a *actuator) Migrate(ctx context.Context, log logr.Logger, ex *extensionsv
 	return nil
 }
 
-func (a *actuator) createResources(ctx context.Context, log logr.Logger, auditConfig *v1alpha1.AuditConfig, cluster *extensions.Cluster, splunkSecret *corev1.Secret, namespace string) error {
-	const (
-		auditForwaderAccessSecretName = gutil.SecretNamePrefixShootAccess + "audit-cluster-forwarding-vpn-gateway"
-	)
-
-	shootAccessSecret := gutil.NewShootAccessSecret(auditForwaderAccessSecretName, namespace)
+func (a *actuator) createResources(ctx context.Context, log logr.Logger, auditConfig *v1alpha1.AuditConfig, cluster *extensions.Cluster, splunkSecret *corev1.Secret, enforced enforcedBackends, namespace string) (string, error) {
+	shootAccessSecret := gutil.NewShootAccessSecret(clusterForwardingAccessSecretName, namespace)
 	if err := shootAccessSecret.Reconcile(ctx, a.client); err != nil {
-		return err
+		return "", err
 	}
 
 	secrets, err := a.generateCerts(ctx, log, cluster)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	shootObjects, err := shootObjects(auditConfig, secrets)
-	if err != nil {
-		return err
+	if err := recordCertificateExpiry(namespace, secrets); err != nil {
+		log.Error(err, "unable to record expiry of audittailer certificates")
 	}
 
-	seedObjects, err := seedObjects(auditConfig, secrets, cluster, splunkSecret, shootAccessSecret.Secret.Name, namespace)
+	manifests, checksum, err := a.renderManifests(ctx, auditConfig, cluster, splunkSecret, enforced, secrets, shootAccessSecret.Secret.Name, namespace)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	shootResources, err := managedresources.NewRegistry(kubernetes.ShootScheme, kubernetes.ShootCodec, kubernetes.ShootSerializer).AddAllAndSerialize(shootObjects...)
-	if err != nil {
-		return err
+	if err := managedresources.CreateForShoot(ctx, a.client, namespace, v1alpha1.ShootAuditResourceName, "audit-extension", false, manifests.Shoot); err != nil {
+		return "", err
 	}
 
-	seedResources, err := managedresources.NewRegistry(kubernetes.SeedScheme, kubernetes.SeedCodec, kubernetes.SeedSerializer).AddAllAndSerialize(seedObjects...)
+	log.Info("managed resource created successfully", "name", v1alpha1.ShootAuditResourceName)
+
+	if err := managedresources.CreateForSeed(ctx, a.client, namespace, v1alpha1.SeedAuditResourceName, false, manifests.Seed); err != nil {
+		return "", err
+	}
+
+	log.Info("managed resource created successfully", "name", v1alpha1.SeedAuditResourceName)
+
+	if auditConfig.Persistence.Type == v1alpha1.AuditPersistenceTypeEphemeral {
+		// cleans up volumes from a previous persistent volume mode, pvc protection prevents deletion while still in use
+		err := a.client.DeleteAllOf(ctx, &corev1.PersistentVolumeClaim{}, client.MatchingLabels{"app": "audit-webhook-backend"}, client.InNamespace(namespace))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return checksum, nil
+}
+
+// renderManifests renders the objects of the shoot and the seed and serializes them into the manifests of the managed
+// resources. it returns the manifests together with the checksum of the seed objects.
+func (a *actuator) renderManifests(ctx context.Context, auditConfig *v1alpha1.AuditConfig, cluster *extensions.Cluster, splunkSecret *corev1.Secret, enforced enforcedBackends, secrets map[string]*corev1.Secret, shootAccessSecretName, namespace string) (*Manifests, string, error) {
+	shootObjects, err := shootObjects(auditConfig, secrets)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 
-	if err := managedresources.CreateForShoot(ctx, a.client, namespace, v1alpha1.ShootAuditResourceName, "audit-extension", false, shootResources); err != nil {
-		return err
+	seedObjects, err := seedObjects(auditConfig, secrets, cluster, splunkSecret, enforced, fluentbitconfig.Format(a.config.FluentBitConfigFormat), InputSilencePeriod(a.config), shootAccessSecretName, namespace)
+	if err != nil {
+		return nil, "", err
 	}
 
-	log.Info("managed resource created successfully", "name", v1alpha1.ShootAuditResourceName)
+	if a.config.BackendPolicy != nil {
+		destinations, err := backendDestinations(ctx, a.resolver, auditConfig.Backends.Splunk, pointer.SafeDeref(enforced.backends).Splunk)
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to resolve backend destinations: %w", err)
+		}
 
-	if err := managedresources.CreateForSeed(ctx, a.client, namespace, v1alpha1.SeedAuditResourceName, false, seedResources); err != nil {
-		return err
+		seedObjects = restrictBackendEgress(seedObjects, namespace, destinations)
 	}
 
-	log.Info("managed resource created successfully", "name", v1alpha1.SeedAuditResourceName)
+	shootResources, err := managedresources.NewRegistry(kubernetes.ShootScheme, kubernetes.ShootCodec, kubernetes.ShootSerializer).AddAllAndSerialize(shootObjects...)
+	if err != nil {
+		return nil, "", err
+	}
 
-	return nil
+	seedResources, err := managedresources.NewRegistry(kubernetes.SeedScheme, kubernetes.SeedCodec, kubernetes.SeedSerializer).AddAllAndSerialize(seedObjects...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &Manifests{Shoot: shootResources, Seed: seedResources}, configChecksum(seedObjects), nil
 }
 
 func (a *actuator) deleteResources(ctx context.Context, log logr.Logger, namespace string) error {
@@ -315,7 +546,7 @@ func (a *actuator) generateCerts(ctx context.Context, log logr.Logger, cluster *
 	return secrets, nil
 }
 
-func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.Secret, cluster *extensions.Cluster, splunkSecretFromResources *corev1.Secret, shootAccessSecretName, namespace string) ([]client.Object, error) {
+func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.Secret, cluster *extensions.Cluster, splunkSecretFromResources *corev1.Secret, enforced enforcedBackends, format fluentbitconfig.Format, silencePeriod time.Duration, shootAccessSecretName, namespace string) ([]client.Object, error) {
 	fluentBitImage, err := imagevector.ImageVector().FindImage("fluent-bit")
 	if err != nil {
 		return nil, fmt.Errorf("failed to find fluent-bit image: %w", err)
@@ -326,56 +557,18 @@ func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.S
 		return nil, fmt.Errorf("unable to generate webhook kubeconfig: %w", err)
 	}
 
+	fluentbitFiles, err := fluentbitBaseFiles(format)
+	if err != nil {
+		return nil, err
+	}
+
 	var (
 		fluentbitConfigMap = &corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      "audit-fluent-bit-config",
+				Name:      fluentbitConfigMapName,
 				Namespace: namespace,
 			},
-			Data: map[string]string{
-				"fluent-bit.conf": fluentbitconfig.Config{
-					Service: map[string]string{
-						"log_level": "info",
-
-						"http_server": "on",
-						"http_listen": "0.0.0.0",
-						"http_port":   "2020",
-
-						"storage.path":              "/data/",
-						"storage.sync":              "normal",
-						"storage.checksum":          "off",
-						"storage.max_chunks_up":     "128",
-						"storage.backlog.mem_limit": "5M",
-
-						"scheduler.base": "1",
-						"scheduler.cap":  "60", // try to send records every 60s
-
-						"health_check":           "on",
-						"hc_errors_count":        "0",
-						"hc_retry_failure_count": "0",
-						"hc_period":              "60",
-					},
-					Input: []fluentbitconfig.Input{
-						map[string]string{
-							"storage.type": "filesystem",
-							"name":         "http",
-						},
-					},
-					Includes: []fluentbitconfig.Include{
-						"*.backend.conf",
-					},
-				}.Generate(),
-				"null.backend.conf": fluentbitconfig.Config{
-					// the null backend is for the case when no backends are configured and fluentbit will still start up
-					// as when this happens, it will fail because the backend conf include does not match any file
-					Output: []fluentbitconfig.Output{
-						map[string]string{
-							"match": "audit",
-							"name":  "null",
-						},
-					},
-				}.Generate(),
-			},
+			Data: fluentbitFiles,
 		}
 
 		auditWebhookConfigSecret = &corev1.Secret{
@@ -390,13 +583,16 @@ func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.S
 
 		auditwebhookStatefulSet = &appsv1.StatefulSet{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:        "audit-webhook-backend",
-				Namespace:   namespace,
-				Annotations: map[string]string{},
-				Labels:      map[string]string{},
+				Name:      "audit-webhook-backend",
+				Namespace: namespace,
+				Annotations: map[string]string{
+					// volume claim templates are immutable, so the statefulset needs to be recreated when the persistence type changes
+					resourcesv1alpha1.DeleteOnInvalidUpdate: "true",
+				},
+				Labels: map[string]string{},
 			},
 			Spec: appsv1.StatefulSetSpec{
-				Replicas:    getReplicas(cluster, auditConfig.Replicas),
+				Replicas:    getReplicas(cluster, highAvailabilityReplicas(cluster, auditConfig.Replicas)),
 				ServiceName: "audit-webhook-backend",
 				Selector: &metav1.LabelSelector{
 					MatchLabels: map[string]string{
@@ -417,9 +613,6 @@ func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.S
 						Annotations: map[string]string{
 							"scheduler.alpha.kubernetes.io/critical-pod":              "",
 							"networking.resources.gardener.cloud/to-world-from-ports": `[{"port":2020,"protocol":"TCP"}]`,
-							"prometheus.io/scrape":                                    "true",
-							"prometheus.io/port":                                      "2020",
-							"prometheus.io/path":                                      "/api/v1/metrics/prometheus",
 						},
 					},
 					Spec: corev1.PodSpec{
@@ -429,7 +622,7 @@ func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.S
 								Image: fluentBitImage.String(),
 								Args: []string{
 									"--storage_path=/data",
-									"--config=/config/fluent-bit.conf",
+									"--config=" + path.Join(fluentbitConfigDir, fluentbitMainFile(format)),
 								},
 								Ports: []corev1.ContainerPort{
 									{
@@ -452,20 +645,20 @@ func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.S
 										},
 									},
 								},
-								Resources: corev1.ResourceRequirements{
-									Requests: corev1.ResourceList{
-										corev1.ResourceCPU:    resource.MustParse("200m"),
-										corev1.ResourceMemory: resource.MustParse("512Mi"),
-									},
-									Limits: corev1.ResourceList{
-										corev1.ResourceCPU:    resource.MustParse("1"),
-										corev1.ResourceMemory: resource.MustParse("1Gi"), // should never be reached because max_chunks_up and chunk_size is smaller than 1Gi
+								Resources: webhookBackendResources(auditConfig),
+								SecurityContext: &corev1.SecurityContext{
+									AllowPrivilegeEscalation: pointer.Pointer(false),
+									ReadOnlyRootFilesystem:   pointer.Pointer(true),
+									Capabilities: &corev1.Capabilities{
+										Drop: []corev1.Capability{
+											"ALL",
+										},
 									},
 								},
 								VolumeMounts: []corev1.VolumeMount{
 									{
 										Name:      "config",
-										MountPath: "/config",
+										MountPath: fluentbitConfigDir,
 									},
 									{
 										Name:      "audit-data",
@@ -474,30 +667,30 @@ func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.S
 								},
 							},
 						},
-						Affinity: &corev1.Affinity{
-							PodAntiAffinity: &corev1.PodAntiAffinity{
-								PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
-									{
-										Weight: 100,
-										PodAffinityTerm: corev1.PodAffinityTerm{
-											LabelSelector: &metav1.LabelSelector{
-												MatchLabels: map[string]string{
-													"app": "audit-webhook-backend",
-												},
-											},
-											TopologyKey: "kubernetes.io/hostname",
-										},
-									},
-								},
+						AutomountServiceAccountToken: pointer.Pointer(false),
+						PriorityClassName:            v1beta1constants.PriorityClassNameShootControlPlane400,
+						SecurityContext: &corev1.PodSecurityContext{
+							RunAsNonRoot: pointer.Pointer(true),
+							RunAsUser:    pointer.Pointer(int64(65534)),
+							RunAsGroup:   pointer.Pointer(int64(65534)),
+							// allows writing to the buffer volume, which is owned by root
+							FSGroup: pointer.Pointer(int64(65534)),
+							SeccompProfile: &corev1.SeccompProfile{
+								Type: corev1.SeccompProfileTypeRuntimeDefault,
 							},
 						},
+						TopologySpreadConstraints: topologySpreadConstraints(cluster, &metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"app": "audit-webhook-backend",
+							},
+						}),
 						Volumes: []corev1.Volume{
 							{
 								Name: "config",
 								VolumeSource: corev1.VolumeSource{
 									ConfigMap: &corev1.ConfigMapVolumeSource{
 										LocalObjectReference: corev1.LocalObjectReference{
-											Name: "audit-fluent-bit-config",
+											Name: fluentbitConfigMapName,
 										},
 									},
 								},
@@ -505,27 +698,40 @@ func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.S
 						},
 					},
 				},
-				VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
-					{
-						ObjectMeta: metav1.ObjectMeta{
-							Name: "audit-data",
-						},
-						Spec: corev1.PersistentVolumeClaimSpec{
-							AccessModes: []corev1.PersistentVolumeAccessMode{
-								corev1.ReadWriteOnce,
-							},
-							StorageClassName: auditConfig.Persistence.StorageClassName,
-							Resources: corev1.VolumeResourceRequirements{
-								Requests: corev1.ResourceList{
-									corev1.ResourceStorage: *auditConfig.Persistence.Size,
-								},
-							},
+			},
+		}
+	)
+
+	switch auditConfig.Persistence.Type {
+	case v1alpha1.AuditPersistenceTypeEphemeral:
+		auditwebhookStatefulSet.Spec.Template.Spec.Volumes = append(auditwebhookStatefulSet.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name: "audit-data",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{
+					SizeLimit: auditConfig.Persistence.Size,
+				},
+			},
+		})
+	default:
+		auditwebhookStatefulSet.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "audit-data",
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{
+						corev1.ReadWriteOnce,
+					},
+					StorageClassName: auditConfig.Persistence.StorageClassName,
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceStorage: *auditConfig.Persistence.Size,
 						},
 					},
 				},
 			},
 		}
-	)
+	}
 
 	objects := []client.Object{
 		auditwebhookStatefulSet,
@@ -535,9 +741,13 @@ func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.S
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      "audit-webhook-backend",
 				Namespace: namespace,
+				Labels: map[string]string{
+					"app": "audit-webhook-backend",
+				},
 				Annotations: map[string]string{
-					"networking.resources.gardener.cloud/pod-label-selector-namespace-alias": "all-shoots",
-					"networking.resources.gardener.cloud/namespace-selectors":                `[{"matchLabels":{"gardener.cloud/role":"extension"}}]`,
+					"networking.resources.gardener.cloud/pod-label-selector-namespace-alias":    "all-shoots",
+					"networking.resources.gardener.cloud/namespace-selectors":                   `[{"matchLabels":{"gardener.cloud/role":"extension"}}]`,
+					"networking.resources.gardener.cloud/from-all-scrape-targets-allowed-ports": `[{"protocol":"TCP","port":2020}]`,
 				},
 			},
 			Spec: corev1.ServiceSpec{
@@ -571,19 +781,19 @@ func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.S
 				Selector:     auditwebhookStatefulSet.Spec.Selector,
 			},
 		},
+		webhookBackendVPA(auditwebhookStatefulSet),
 	}
 
+	objects = append(objects, monitoringObjects(silencePeriod, namespace)...)
+
+	// every output is aliased with the name of its backend, which is used by the health check to report the health per backend
 	if pointer.SafeDeref(auditConfig.Backends.Log).Enabled {
-		fluentbitConfigMap.Data["log.backend.conf"] = fluentbitconfig.Config{
-			Output: []fluentbitconfig.Output{
-				map[string]string{
-					"match":                    "audit",
-					"name":                     "stdout",
-					"retry_limit":              "no_limits", // let fluent-bit never discard any data
-					"storage.total_limit_size": "10M",
-				},
-			},
-		}.Generate()
+		logConfig, err := logBackend(backendLog, format)
+		if err != nil {
+			return nil, err
+		}
+
+		fluentbitConfigMap.Data[fluentbitBackendFile(backendLog, format)] = logConfig
 	}
 
 	if pointer.SafeDeref(auditConfig.Backends.ClusterForwarding).Enabled {
@@ -592,27 +802,34 @@ func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.S
 			return nil, fmt.Errorf("failed to find gardener-vpn-gateway image: %w", err)
 		}
 
-		forwardingConfig := map[string]string{
-			"match":                    "audit",
-			"name":                     "forward",
-			"retry_limit":              "no_limits", // let fluent-bit never discard any data
-			"storage.total_limit_size": pointer.SafeDeref(auditConfig.Backends.ClusterForwarding.FilesystemBufferSize),
-			"host":                     "audit-cluster-forwarding-vpn-gateway",
-			"port":                     "9876",
-			"require_ack_response":     "True",
-			"compress":                 "gzip",
-			"tls":                      "On",
-			"tls.verify":               "On",
-			"tls.debug":                "2",
-			"tls.ca_file":              "/backends/cluster-forwarding/certs/ca.crt",
-			"tls.crt_file":             "/backends/cluster-forwarding/certs/tls.crt",
-			"tls.key_file":             "/backends/cluster-forwarding/certs/tls.key",
-			"tls.vhost":                "audittailer",
+		forwardingConfig, err := fluentbitconfig.Config{
+			Output: fluentbitconfig.Sections(fluentbitconfig.ForwardOutput{
+				OutputOptions: fluentbitconfig.OutputOptions{
+					Alias:                 backendClusterForwarding,
+					Match:                 "audit",
+					RetryLimit:            "no_limits", // let fluent-bit never discard any data
+					StorageTotalLimitSize: pointer.SafeDeref(auditConfig.Backends.ClusterForwarding.FilesystemBufferSize),
+				},
+				TLS: fluentbitconfig.TLS{
+					Enabled: true,
+					Verify:  true,
+					Debug:   "2",
+					CAFile:  "/backends/cluster-forwarding/certs/ca.crt",
+					CrtFile: "/backends/cluster-forwarding/certs/tls.crt",
+					KeyFile: "/backends/cluster-forwarding/certs/tls.key",
+					VHost:   "audittailer",
+				},
+				Host:               "audit-cluster-forwarding-vpn-gateway",
+				Port:               "9876",
+				RequireAckResponse: true,
+				Compress:           "gzip",
+			}),
+		}.Render(format)
+		if err != nil {
+			return nil, err
 		}
 
-		fluentbitConfigMap.Data["clusterforwarding.backend.conf"] = fluentbitconfig.Config{
-			Output: []fluentbitconfig.Output{forwardingConfig},
-		}.Generate()
+		fluentbitConfigMap.Data[fluentbitBackendFile(backendClusterForwarding, format)] = forwardingConfig
 
 		auditwebhookStatefulSet.Spec.Template.Spec.Containers[0].VolumeMounts = append(auditwebhookStatefulSet.Spec.Template.Spec.Containers[0].VolumeMounts,
 			corev1.VolumeMount{
@@ -663,7 +880,17 @@ func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.S
 						},
 					},
 					Spec: corev1.PodSpec{
+						// the service account token is not disabled as the gateway reads secrets from the shoot namespace in the seed
 						ServiceAccountName: "audit-cluster-forwarding-vpn-gateway",
+						PriorityClassName:  v1beta1constants.PriorityClassNameShootControlPlane300,
+						SecurityContext: &corev1.PodSecurityContext{
+							RunAsNonRoot: pointer.Pointer(true),
+							RunAsUser:    pointer.Pointer(int64(65534)),
+							RunAsGroup:   pointer.Pointer(int64(65534)),
+							SeccompProfile: &corev1.SeccompProfile{
+								Type: corev1.SeccompProfileTypeRuntimeDefault,
+							},
+						},
 						Containers: []corev1.Container{
 							{
 								Name:            "gardener-vpn-gateway",
@@ -687,6 +914,15 @@ func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.S
 										Value: "audittailer",
 									},
 								},
+								SecurityContext: &corev1.SecurityContext{
+									AllowPrivilegeEscalation: pointer.Pointer(false),
+									ReadOnlyRootFilesystem:   pointer.Pointer(true),
+									Capabilities: &corev1.Capabilities{
+										Drop: []corev1.Capability{
+											"ALL",
+										},
+									},
+								},
 							},
 						},
 					},
@@ -768,103 +1004,294 @@ func seedObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.S
 	}
 
 	if pointer.SafeDeref(auditConfig.Backends.Splunk).Enabled {
-		splunkConfig := map[string]string{
-			"match":                    "audit",
-			"name":                     "splunk",
-			"retry_limit":              "no_limits", // let fluent-bit never discard any data
-			"storage.total_limit_size": pointer.SafeDeref(auditConfig.Backends.Splunk.FilesystemBufferSize),
-			"host":                     auditConfig.Backends.Splunk.Host,
-			"port":                     auditConfig.Backends.Splunk.Port,
-			"splunk_token":             "${SPLUNK_HEC_TOKEN}",
-			"splunk_send_raw":          "off",
-			"event_source":             "statefulset:" + auditwebhookStatefulSet.Name,
-			"event_sourcetype":         "kube:apiserver:auditlog",
-			"event_index":              auditConfig.Backends.Splunk.Index,
-			"event_host":               cluster.ObjectMeta.Name,
+		splunkSecret, err := splunkBackend(backendSplunk, auditConfig.Backends.Splunk, splunkSecretFromResources, auditwebhookStatefulSet, fluentbitConfigMap, format, cluster)
+		if err != nil {
+			return nil, err
 		}
 
-		if auditConfig.Backends.Splunk.TlsEnabled {
-			splunkConfig["tls"] = "on"
-			splunkConfig["tls.verify"] = "on"
-			if auditConfig.Backends.Splunk.TlsHost != "" {
-				splunkConfig["tls.vhost"] = auditConfig.Backends.Splunk.TlsHost
-			}
+		objects = append(objects, splunkSecret)
+	}
+
+	// enforced backends are configured like the user's backends, their names are prefixed such that they do not collide
+	enforcedBackends := pointer.SafeDeref(enforced.backends)
+	if pointer.SafeDeref(enforcedBackends.Log).Enabled {
+		logConfig, err := logBackend(enforcedBackendPrefix+backendLog, format)
+		if err != nil {
+			return nil, err
+		}
+
+		fluentbitConfigMap.Data[fluentbitBackendFile(enforcedBackendPrefix+backendLog, format)] = logConfig
+	}
+	if pointer.SafeDeref(enforcedBackends.Splunk).Enabled {
+		splunkSecret, err := splunkBackend(enforcedBackendPrefix+backendSplunk, enforcedBackends.Splunk, enforced.splunkSecret, auditwebhookStatefulSet, fluentbitConfigMap, format, cluster)
+		if err != nil {
+			return nil, err
 		}
 
-		splunkConfigFilter := make(map[string]string, len(auditConfig.Backends.Splunk.CustomData)+2)
-		if len(auditConfig.Backends.Splunk.CustomData) > 0 {
-			splunkConfigFilter["name"] = "modify"
-			splunkConfigFilter["match"] = "*"
-			for key, value := range auditConfig.Backends.Splunk.CustomData {
-				splunkConfigFilter["add "+key] = value
+		objects = append(objects, splunkSecret)
+	}
+
+	if err := addFluentbitMainConfig(fluentbitConfigMap.Data, format); err != nil {
+		return nil, err
+	}
+
+	auditwebhookStatefulSet.Spec.Template.ObjectMeta.Annotations["checksum/secret-"+auditWebhookConfigSecret.Name] = utils.ComputeSecretChecksum(auditWebhookConfigSecret.Data)
+	auditwebhookStatefulSet.Spec.Template.ObjectMeta.Annotations["checksum/config-"+fluentbitConfigMap.Name] = utils.ComputeConfigMapChecksum(fluentbitConfigMap.Data)
+
+	fluentbitConfigMap.Annotations = map[string]string{}
+	for name, checksum := range fluentbitconfig.Files(fluentbitConfigMap.Data).Checksums() {
+		fluentbitConfigMap.Annotations["checksum/"+name] = checksum
+	}
+
+	return objects, nil
+}
+
+// fluentbitMainFile returns the name of the main file of the fluent-bit configuration in the given format
+func fluentbitMainFile(format fluentbitconfig.Format) string {
+	return "fluent-bit." + format.Extension()
+}
+
+// fluentbitBackendFile returns the name of the file that contains the configuration of the backend with the given alias
+func fluentbitBackendFile(alias string, format fluentbitconfig.Format) string {
+	return alias + ".backend." + format.Extension()
+}
+
+// addFluentbitMainConfig adds the main file of the fluent-bit configuration, which includes the files of the backends.
+// the classic format includes them by a wildcard, the yaml format needs to list them explicitly, so it must be added
+// after all backends.
+func addFluentbitMainConfig(files fluentbitconfig.Files, format fluentbitconfig.Format) error {
+	includes := []fluentbitconfig.Include{fluentbitconfig.Include(fluentbitBackendFile("*", format))}
+	if format == fluentbitconfig.FormatYAML {
+		var backends []string
+		for name := range files {
+			if strings.HasSuffix(name, fluentbitBackendFile("", format)) {
+				backends = append(backends, name)
 			}
 		}
+		sort.Strings(backends)
 
-		splunkSecret := &corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "audit-splunk-secret",
-				Namespace: namespace,
-			},
-			Data: map[string][]byte{
-				"splunk_hec_token": splunkSecretFromResources.Data[v1alpha1.SplunkSecretTokenKey],
-			},
+		includes = nil
+		for _, name := range backends {
+			includes = append(includes, fluentbitconfig.Include(path.Join(fluentbitConfigDir, name)))
 		}
+	}
 
-		auditwebhookStatefulSet.Spec.Template.Spec.Containers[0].Env = append(auditwebhookStatefulSet.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{
-			Name: "SPLUNK_HEC_TOKEN",
-			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: splunkSecret.ObjectMeta.Name,
-					},
-					Key: "splunk_hec_token",
+	main, err := fluentbitconfig.Config{
+		Service: fluentbitconfig.Section{
+			{Key: "log_level", Value: "info"},
+
+			{Key: "http_server", Value: "on"},
+			{Key: "http_listen", Value: "0.0.0.0"},
+			{Key: "http_port", Value: "2020"},
+
+			{Key: "storage.path", Value: "/data/"},
+			{Key: "storage.sync", Value: "normal"},
+			{Key: "storage.checksum", Value: "off"},
+			{Key: "storage.max_chunks_up", Value: "128"},
+			{Key: "storage.backlog.mem_limit", Value: "5M"},
+			{Key: "storage.metrics", Value: "on"}, // required for the buffer health check
+
+			{Key: "scheduler.base", Value: "1"},
+			{Key: "scheduler.cap", Value: "60"}, // try to send records every 60s
+
+			{Key: "health_check", Value: "on"},
+			{Key: "hc_errors_count", Value: "0"},
+			{Key: "hc_retry_failure_count", Value: "0"},
+			{Key: "hc_period", Value: "60"},
+
+			{Key: "parsers_file", Value: path.Join(fluentbitConfigDir, fluentbitParsersFile)},
+		},
+		Input: fluentbitconfig.Sections(
+			fluentbitconfig.HTTPInput{StorageType: "filesystem"},
+		),
+		Includes: includes,
+	}.Render(format)
+	if err != nil {
+		return err
+	}
+
+	return files.Add(fluentbitMainFile(format), main)
+}
+
+// fluentbitBaseFiles returns the files of the fluent-bit configuration that do not depend on the backends. they are
+// placed into the config directory, the backends are added as *.backend.conf files (or *.backend.yaml files in the
+// yaml format), which are included by the main configuration.
+func fluentbitBaseFiles(format fluentbitconfig.Format) (fluentbitconfig.Files, error) {
+	files := fluentbitconfig.Files{}
+
+	// parsers for the audit events, which can be used by the filters of the backends
+	// parsers files are always in the classic format, fluent-bit also reads them from a yaml configuration
+	err := files.AddDocument(fluentbitParsersFile, fluentbitconfig.ParsersFile{
+		Parser: fluentbitconfig.Sections(fluentbitconfig.Parser{
+			Name:       "audit-event",
+			Format:     "json",
+			TimeKey:    "requestReceivedTimestamp",
+			TimeFormat: "%Y-%m-%dT%H:%M:%S.%LZ",
+			TimeKeep:   true,
+		}),
+	}.Document())
+	if err != nil {
+		return nil, err
+	}
+
+	// the null backend is for the case when no backends are configured and fluentbit will still start up
+	// as when this happens, it will fail because the backend conf include does not match any file
+	nullBackend, err := fluentbitconfig.Config{
+		Output: []fluentbitconfig.Section{
+			fluentbitconfig.Section{}.Add("name", "null").Add("match", "audit").Add("alias", "null"),
+		},
+	}.Render(format)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := files.Add(fluentbitBackendFile("null", format), nullBackend); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// logBackend returns the configuration of an output with the given alias that writes the audit events to stdout
+func logBackend(alias string, format fluentbitconfig.Format) (string, error) {
+	return fluentbitconfig.Config{
+		Output: fluentbitconfig.Sections(fluentbitconfig.StdoutOutput{
+			OutputOptions: fluentbitconfig.OutputOptions{
+				Alias:                 alias,
+				Match:                 "audit",
+				RetryLimit:            "no_limits", // let fluent-bit never discard any data
+				StorageTotalLimitSize: "10M",
+			},
+		}),
+	}.Render(format)
+}
+
+// splunkBackend adds a splunk output with the given alias to the audit webhook backend and returns the secret that contains
+// the token for the output. all names are derived from the alias, such that multiple splunk outputs do not collide.
+func splunkBackend(alias string, splunk *v1alpha1.AuditBackendSplunk, splunkSecretFromResources *corev1.Secret, sts *appsv1.StatefulSet, fluentbitConfigMap *corev1.ConfigMap, format fluentbitconfig.Format, cluster *extensions.Cluster) (*corev1.Secret, error) {
+	var (
+		tokenEnv = strings.ToUpper(strings.ReplaceAll(alias, "-", "_")) + "_HEC_TOKEN"
+		certsDir = "/backends/" + alias + "/certs"
+	)
+
+	splunkConfig := fluentbitconfig.SplunkOutput{
+		OutputOptions: fluentbitconfig.OutputOptions{
+			Alias:                 alias,
+			Match:                 "audit",
+			RetryLimit:            "no_limits", // let fluent-bit never discard any data
+			StorageTotalLimitSize: pointer.SafeDeref(splunk.FilesystemBufferSize),
+		},
+		TLS: fluentbitconfig.TLS{
+			Enabled: splunk.TlsEnabled,
+			Verify:  true,
+			VHost:   splunk.TlsHost,
+		},
+		Host:            splunk.Host,
+		Port:            splunk.Port,
+		Token:           "${" + tokenEnv + "}",
+		EventSource:     "statefulset:" + sts.Name,
+		EventSourceType: "kube:apiserver:auditlog",
+		EventIndex:      splunk.Index,
+		EventHost:       cluster.ObjectMeta.Name,
+	}
+
+	splunkSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "audit-" + alias + "-secret",
+			Namespace: sts.Namespace,
+		},
+		Data: map[string][]byte{
+			"splunk_hec_token": splunkSecretFromResources.Data[v1alpha1.SplunkSecretTokenKey],
+		},
+	}
+
+	sts.Spec.Template.Spec.Containers[0].Env = append(sts.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{
+		Name: tokenEnv,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: splunkSecret.ObjectMeta.Name,
 				},
+				Key: "splunk_hec_token",
 			},
-		})
+		},
+	})
 
-		caFile := splunkSecretFromResources.Data[v1alpha1.SplunkSecretCaFileKey]
-		if len(caFile) > 0 {
-			splunkConfig["tls.ca_file"] = "/backends/splunk/certs/ca.crt"
+	caFile := splunkSecretFromResources.Data[v1alpha1.SplunkSecretCaFileKey]
+	if len(caFile) > 0 {
+		splunkConfig.CAFile = certsDir + "/ca.crt"
 
-			splunkSecret.Data["ca.crt"] = caFile
+		splunkSecret.Data["ca.crt"] = caFile
 
-			auditwebhookStatefulSet.Spec.Template.Spec.Volumes = append(auditwebhookStatefulSet.Spec.Template.Spec.Volumes, corev1.Volume{
-				Name: "splunk-secret",
-				VolumeSource: corev1.VolumeSource{
-					Secret: &corev1.SecretVolumeSource{
-						SecretName: splunkSecret.Name,
-						Items: []corev1.KeyToPath{
-							{
-								Key:  "ca.crt",
-								Path: "ca.crt",
-							},
+		sts.Spec.Template.Spec.Volumes = append(sts.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name: alias + "-secret",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: splunkSecret.Name,
+					Items: []corev1.KeyToPath{
+						{
+							Key:  "ca.crt",
+							Path: "ca.crt",
 						},
 					},
 				},
-			})
-			auditwebhookStatefulSet.Spec.Template.Spec.Containers[0].VolumeMounts = append(auditwebhookStatefulSet.Spec.Template.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
-				Name:      "splunk-secret",
-				MountPath: "/backends/splunk/certs",
-			})
-		}
+			},
+		})
+		sts.Spec.Template.Spec.Containers[0].VolumeMounts = append(sts.Spec.Template.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      alias + "-secret",
+			MountPath: certsDir,
+		})
+	}
 
-		auditwebhookStatefulSet.Spec.Template.ObjectMeta.Annotations["checksum/splunk-secret"] = utils.ComputeSecretChecksum(splunkSecret.Data)
+	sts.Spec.Template.ObjectMeta.Annotations["checksum/"+alias+"-secret"] = utils.ComputeSecretChecksum(splunkSecret.Data)
 
-		objects = append(objects, splunkSecret)
+	fluentbitBackendSplunk := fluentbitconfig.Config{
+		Environment: []string{tokenEnv},
+	}
+	if len(splunk.CustomData) > 0 {
+		keys := make([]string, 0, len(splunk.CustomData))
+		for key := range splunk.CustomData {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
 
-		fluentbitBackendSplunk := fluentbitconfig.Config{
-			Output: []fluentbitconfig.Output{splunkConfig},
+		customData := fluentbitconfig.ModifyFilter{}
+		for _, key := range keys {
+			customData = customData.Add(key, splunk.CustomData[key])
 		}
-		if len(splunkConfigFilter) > 0 {
-			fluentbitBackendSplunk.Filter = []fluentbitconfig.Filter{splunkConfigFilter}
+
+		// the yaml format allows to add the custom data by a processor of the output, such that it only applies to
+		// this backend. in the classic format filters apply to all outputs matching the tag, so the records are copied
+		// to a tag of this backend first. every audit event has a kind, such that all records are copied.
+		if format == fluentbitconfig.FormatYAML {
+			fluentbitBackendSplunk.Processors = map[string][]fluentbitconfig.Section{alias: fluentbitconfig.Sections(customData)}
+		} else {
+			tag := "audit." + alias
+
+			customData.Match = tag
+			splunkConfig.Match = tag
+
+			fluentbitBackendSplunk.Filter = fluentbitconfig.Sections(
+				fluentbitconfig.RewriteTagFilter{
+					Match:              "audit",
+					Rules:              []fluentbitconfig.RewriteTagRule{{Key: "$kind", Regex: "^.*$", NewTag: tag, Keep: true}},
+					EmitterName:        alias + "-emitter",
+					EmitterStorageType: "filesystem",
+				},
+				customData,
+			)
 		}
-		fluentbitConfigMap.Data["splunk.backend.conf"] = fluentbitBackendSplunk.Generate()
 	}
+	fluentbitBackendSplunk.Output = fluentbitconfig.Sections(splunkConfig)
 
-	auditwebhookStatefulSet.Spec.Template.ObjectMeta.Annotations["checksum/secret-"+auditWebhookConfigSecret.Name] = utils.ComputeSecretChecksum(auditWebhookConfigSecret.Data)
-	auditwebhookStatefulSet.Spec.Template.ObjectMeta.Annotations["checksum/config-"+fluentbitConfigMap.Name] = utils.ComputeConfigMapChecksum(fluentbitConfigMap.Data)
+	splunkBackendConfig, err := fluentbitBackendSplunk.Render(format)
+	if err != nil {
+		return nil, err
+	}
 
-	return objects, nil
+	fluentbitConfigMap.Data[fluentbitBackendFile(alias, format)] = splunkBackendConfig
+
+	return splunkSecret, nil
 }
 
 func webhookKubeconfig(namespace string) ([]byte, error) {
@@ -1145,6 +1572,8 @@ func shootObjects(auditConfig *v1alpha1.AuditConfig, secrets map[string]*corev1.
 	}, nil
 }
 
+// findBackendSecret returns the backend secret referenced in the shoot resources or from the default backend secrets
+// of the operator. if the secret can be found in neither of them, nil is returned.
 func (a *actuator) findBackendSecret(ctx context.Context, cluster *extensions.Cluster, defaultBackendSecrets map[string]*corev1.Secret, secretName string) (*corev1.Secret, error) {
 	fromShootResources := func() (*corev1.Secret, error) {
 		secretRef := helper.GetResourceByName(cluster.Shoot.Spec.Resources, secretName)
@@ -1168,20 +1597,154 @@ func (a *actuator) findBackendSecret(ctx context.Context, cluster *extensions.Cl
 
 	if secret == nil {
 		// if the secret is not referenced in the shoot resources it may be defined in the default backend secrets
-		if len(defaultBackendSecrets) > 0 {
-			var ok bool
-			secret, ok = defaultBackendSecrets[secretName]
-			if !ok {
-				return nil, fmt.Errorf("secret resource with name %q not found in default backend secrets", secretName)
-			}
-		} else {
-			return nil, fmt.Errorf("secret resource with name %q not found in shoot resources", secretName)
-		}
+		secret = defaultBackendSecrets[secretName]
 	}
 
 	return secret, nil
 }
 
+// defaultPersistenceType sets the persistence type in case it was not set by the user. Shoots that are not
+// intended for serious workloads do not need to pay for a persistent volume per replica.
+func defaultPersistenceType(auditConfig *v1alpha1.AuditConfig, cluster *extensions.Cluster) {
+	if auditConfig.Persistence.Type != "" {
+		return
+	}
+
+	auditConfig.Persistence.Type = v1alpha1.AuditPersistenceTypePersistentVolume
+
+	if cluster.Shoot == nil || cluster.Shoot.Spec.Purpose == nil {
+		return
+	}
+
+	switch *cluster.Shoot.Spec.Purpose {
+	case gardencorev1beta1.ShootPurposeEvaluation, gardencorev1beta1.ShootPurposeTesting:
+		auditConfig.Persistence.Type = v1alpha1.AuditPersistenceTypeEphemeral
+	}
+}
+
+// persistenceWarnings returns warnings for persistence settings that are valid but probably not what the user wants.
+func persistenceWarnings(auditConfig *v1alpha1.AuditConfig) []string {
+	var warnings []string
+
+	if auditConfig.Persistence.Type == v1alpha1.AuditPersistenceTypeEphemeral {
+		switch auditConfig.WebhookMode {
+		case v1alpha1.AuditWebhookModeBlockingStrict, "":
+			warnings = append(warnings, "ephemeral persistence is used in combination with webhook mode blocking-strict, buffered audit events are lost when the webhook backend pods are rescheduled")
+		}
+	}
+
+	return warnings
+}
+
+// webhookBackendResources returns the resource requirements for the fluent-bit container of the webhook backend.
+func webhookBackendResources(auditConfig *v1alpha1.AuditConfig) corev1.ResourceRequirements {
+	if auditConfig.Resources != nil {
+		return *auditConfig.Resources
+	}
+
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("200m"),
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"), // should never be reached because max_chunks_up and chunk_size is smaller than 1Gi
+		},
+	}
+}
+
+// webhookBackendVPA returns a vertical pod autoscaler that only controls the requests of the webhook backend
+// such that the limits stay in place as an upper boundary.
+func webhookBackendVPA(sts *appsv1.StatefulSet) *vpaautoscalingv1.VerticalPodAutoscaler {
+	var (
+		updateMode       = vpaautoscalingv1.UpdateModeAuto
+		controlledValues = vpaautoscalingv1.ContainerControlledValuesRequestsOnly
+		containerPolicy  = vpaautoscalingv1.ContainerResourcePolicy{
+			ContainerName:    "fluent-bit",
+			ControlledValues: &controlledValues,
+			MinAllowed: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("20m"),
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+			},
+		}
+	)
+
+	for _, c := range sts.Spec.Template.Spec.Containers {
+		if c.Name == containerPolicy.ContainerName && len(c.Resources.Limits) > 0 {
+			containerPolicy.MaxAllowed = c.Resources.Limits.DeepCopy()
+		}
+	}
+
+	return &vpaautoscalingv1.VerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sts.Name,
+			Namespace: sts.Namespace,
+		},
+		Spec: vpaautoscalingv1.VerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv1.CrossVersionObjectReference{
+				APIVersion: appsv1.SchemeGroupVersion.String(),
+				Kind:       "StatefulSet",
+				Name:       sts.Name,
+			},
+			UpdatePolicy: &vpaautoscalingv1.PodUpdatePolicy{
+				UpdateMode: &updateMode,
+			},
+			ResourcePolicy: &vpaautoscalingv1.PodResourcePolicy{
+				ContainerPolicies: []vpaautoscalingv1.ContainerResourcePolicy{containerPolicy},
+			},
+		},
+	}
+}
+
+// highAvailabilityReplicas ensures that there are enough replicas to tolerate the failure configured
+// for the shoot's control plane. a multi-zonal control plane is spread across three zones, so every zone
+// gets a replica.
+func highAvailabilityReplicas(cluster *extensions.Cluster, replicas *int32) *int32 {
+	var minReplicas int32
+	switch {
+	case helper.IsMultiZonalShootControlPlane(cluster.Shoot):
+		minReplicas = 3
+	case helper.IsHAControlPlaneConfigured(cluster.Shoot):
+		minReplicas = 2
+	}
+
+	if pointer.SafeDeref(replicas) < minReplicas {
+		return &minReplicas
+	}
+
+	return replicas
+}
+
+// topologySpreadConstraints spreads the pods across nodes and, for shoots with zone failure tolerance, across zones.
+// The constraints are only enforced for shoots with a highly available control plane.
+func topologySpreadConstraints(cluster *extensions.Cluster, selector *metav1.LabelSelector) []corev1.TopologySpreadConstraint {
+	whenUnsatisfiable := corev1.ScheduleAnyway
+	if helper.IsHAControlPlaneConfigured(cluster.Shoot) {
+		whenUnsatisfiable = corev1.DoNotSchedule
+	}
+
+	constraints := []corev1.TopologySpreadConstraint{
+		{
+			MaxSkew:           1,
+			TopologyKey:       corev1.LabelHostname,
+			WhenUnsatisfiable: whenUnsatisfiable,
+			LabelSelector:     selector,
+		},
+	}
+
+	if helper.IsMultiZonalShootControlPlane(cluster.Shoot) {
+		constraints = append(constraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       corev1.LabelTopologyZone,
+			WhenUnsatisfiable: corev1.DoNotSchedule,
+			LabelSelector:     selector,
+		})
+	}
+
+	return constraints
+}
+
 func getReplicas(cluster *extensions.Cluster, wokenUp *int32) *int32 {
 	if controller.IsHibernated(cluster) {
 		return pointer.Pointer(int32(0))
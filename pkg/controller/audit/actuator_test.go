@@ -1,16 +1,26 @@
 package audit
 
 import (
+	"context"
+	"path"
+	"strings"
 	"testing"
 
 	"github.com/gardener/gardener/pkg/apis/core/v1beta1"
 	"github.com/gardener/gardener/pkg/extensions"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vpaautoscalingv1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-audit/pkg/fluentbitconfig"
 )
 
 func TestSeedObjects_SplunkConfigCustomData(t *testing.T) {
@@ -71,7 +81,7 @@ func TestSeedObjects_SplunkConfigCustomData(t *testing.T) {
 				Enabled:    true,
 				CustomData: tc.customData,
 			}
-			objects, err := seedObjects(auditConfig, secrets, cluster, splunkSecretFromResources, shootAccessSecretName, namespace)
+			objects, err := seedObjects(auditConfig, secrets, cluster, splunkSecretFromResources, enforcedBackends{}, fluentbitconfig.FormatClassic, defaultInputSilencePeriod, shootAccessSecretName, namespace)
 			require.NoError(t, err)
 
 			// inspect output
@@ -87,6 +97,74 @@ func TestSeedObjects_SplunkConfigCustomData(t *testing.T) {
 	}
 }
 
+func TestSeedObjects_SplunkCustomDataIsolated(t *testing.T) {
+	auditConfig := &v1alpha1.AuditConfig{
+		Backends: &v1alpha1.AuditBackends{
+			Log: &v1alpha1.AuditBackendLog{Enabled: true},
+			Splunk: &v1alpha1.AuditBackendSplunk{
+				Enabled:    true,
+				Host:       "splunk.example.com",
+				CustomData: map[string]string{"tenant": "user"},
+			},
+		},
+		Persistence: v1alpha1.AuditPersistence{
+			Size: &resource.Quantity{},
+		},
+	}
+	enforced := enforcedBackends{
+		backends: &v1alpha1.AuditBackends{
+			Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, Host: "enforced.example.com"},
+		},
+		splunkSecret: &corev1.Secret{Data: map[string][]byte{v1alpha1.SplunkSecretTokenKey: []byte("token")}},
+	}
+
+	objects, err := seedObjects(auditConfig, map[string]*corev1.Secret{}, &extensions.Cluster{Shoot: &v1beta1.Shoot{}}, &corev1.Secret{}, enforced, fluentbitconfig.FormatClassic, defaultInputSilencePeriod, "", "")
+	require.NoError(t, err)
+
+	cm := findFluentbitConfigMap(objects)
+	require.NotNil(t, cm)
+
+	var (
+		modifyMatches []string
+		outputs       = map[string]string{}
+	)
+	for name, content := range cm.Data {
+		if !strings.HasSuffix(name, ".backend.conf") {
+			continue
+		}
+
+		c, err := fluentbitconfig.ParseConfig(content)
+		require.NoError(t, err)
+
+		for _, filter := range c.Filter {
+			if name, _ := filter.Get("name"); name == "modify" {
+				match, _ := filter.Get("match")
+				modifyMatches = append(modifyMatches, match)
+			}
+		}
+		for _, output := range c.Output {
+			alias, _ := output.Get("alias")
+			outputs[alias], _ = output.Get("match")
+		}
+	}
+
+	require.NotEmpty(t, modifyMatches)
+	require.Len(t, outputs, 4)
+	require.Contains(t, outputs, "enforced-splunk")
+
+	// the custom data of the user must only be added to the records of the user's splunk backend
+	for alias, tag := range outputs {
+		var modified bool
+		for _, pattern := range modifyMatches {
+			if ok, _ := path.Match(pattern, tag); ok {
+				modified = true
+			}
+		}
+
+		assert.Equal(t, alias == "splunk", modified, "custom data is applied to output %q", alias)
+	}
+}
+
 func TestValidateSplunkCustomData(t *testing.T) {
 	tt := []struct {
 		desc   string
@@ -174,3 +252,623 @@ func TestValidateSplunkCustomData(t *testing.T) {
 		})
 	}
 }
+
+func TestSeedObjects_Persistence(t *testing.T) {
+	size := resource.MustParse("2Gi")
+
+	tt := []struct {
+		desc            string
+		persistenceType v1alpha1.AuditPersistenceType
+		assertion       func(*testing.T, *appsv1.StatefulSet)
+	}{
+		{
+			desc:            "persistent volume",
+			persistenceType: v1alpha1.AuditPersistenceTypePersistentVolume,
+			assertion: func(t *testing.T, sts *appsv1.StatefulSet) {
+				require.Len(t, sts.Spec.VolumeClaimTemplates, 1)
+				assert.Equal(t, "audit-data", sts.Spec.VolumeClaimTemplates[0].Name)
+				assert.Equal(t, size, sts.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage])
+
+				for _, v := range sts.Spec.Template.Spec.Volumes {
+					assert.NotEqual(t, "audit-data", v.Name)
+				}
+			},
+		},
+		{
+			desc:            "ephemeral",
+			persistenceType: v1alpha1.AuditPersistenceTypeEphemeral,
+			assertion: func(t *testing.T, sts *appsv1.StatefulSet) {
+				assert.Empty(t, sts.Spec.VolumeClaimTemplates)
+
+				var found bool
+				for _, v := range sts.Spec.Template.Spec.Volumes {
+					if v.Name != "audit-data" {
+						continue
+					}
+					found = true
+					require.NotNil(t, v.EmptyDir)
+					assert.Equal(t, &size, v.EmptyDir.SizeLimit)
+				}
+				assert.True(t, found, "audit-data volume not found")
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.desc, func(t *testing.T) {
+			auditConfig := &v1alpha1.AuditConfig{
+				Backends: &v1alpha1.AuditBackends{},
+				Persistence: v1alpha1.AuditPersistence{
+					Type: tc.persistenceType,
+					Size: &size,
+				},
+			}
+			cluster := &extensions.Cluster{
+				Shoot: &v1beta1.Shoot{},
+			}
+
+			objects, err := seedObjects(auditConfig, map[string]*corev1.Secret{}, cluster, &corev1.Secret{}, enforcedBackends{}, fluentbitconfig.FormatClassic, defaultInputSilencePeriod, "", "")
+			require.NoError(t, err)
+
+			sts, ok := objects[0].(*appsv1.StatefulSet)
+			require.Truef(t, ok, "statefulset is of the wrong type %T", objects[0])
+
+			tc.assertion(t, sts)
+		})
+	}
+}
+
+func TestDefaultPersistenceType(t *testing.T) {
+	tt := []struct {
+		desc    string
+		current v1alpha1.AuditPersistenceType
+		purpose *v1beta1.ShootPurpose
+		want    v1alpha1.AuditPersistenceType
+	}{
+		{
+			desc: "no purpose",
+			want: v1alpha1.AuditPersistenceTypePersistentVolume,
+		},
+		{
+			desc:    "production shoot",
+			purpose: pointer.Pointer(v1beta1.ShootPurposeProduction),
+			want:    v1alpha1.AuditPersistenceTypePersistentVolume,
+		},
+		{
+			desc:    "evaluation shoot",
+			purpose: pointer.Pointer(v1beta1.ShootPurposeEvaluation),
+			want:    v1alpha1.AuditPersistenceTypeEphemeral,
+		},
+		{
+			desc:    "testing shoot",
+			purpose: pointer.Pointer(v1beta1.ShootPurposeTesting),
+			want:    v1alpha1.AuditPersistenceTypeEphemeral,
+		},
+		{
+			desc:    "explicitly set by user",
+			current: v1alpha1.AuditPersistenceTypePersistentVolume,
+			purpose: pointer.Pointer(v1beta1.ShootPurposeEvaluation),
+			want:    v1alpha1.AuditPersistenceTypePersistentVolume,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.desc, func(t *testing.T) {
+			auditConfig := &v1alpha1.AuditConfig{
+				Persistence: v1alpha1.AuditPersistence{
+					Type: tc.current,
+				},
+			}
+			cluster := &extensions.Cluster{
+				Shoot: &v1beta1.Shoot{
+					Spec: v1beta1.ShootSpec{
+						Purpose: tc.purpose,
+					},
+				},
+			}
+
+			defaultPersistenceType(auditConfig, cluster)
+
+			assert.Equal(t, tc.want, auditConfig.Persistence.Type)
+		})
+	}
+}
+
+func TestPersistenceWarnings(t *testing.T) {
+	tt := []struct {
+		desc            string
+		persistenceType v1alpha1.AuditPersistenceType
+		mode            v1alpha1.AuditWebhookMode
+		wantWarnings    int
+	}{
+		{
+			desc:            "persistent volume with blocking-strict",
+			persistenceType: v1alpha1.AuditPersistenceTypePersistentVolume,
+			mode:            v1alpha1.AuditWebhookModeBlockingStrict,
+		},
+		{
+			desc:            "ephemeral with batch",
+			persistenceType: v1alpha1.AuditPersistenceTypeEphemeral,
+			mode:            v1alpha1.AuditWebhookModeBatch,
+		},
+		{
+			desc:            "ephemeral with blocking-strict",
+			persistenceType: v1alpha1.AuditPersistenceTypeEphemeral,
+			mode:            v1alpha1.AuditWebhookModeBlockingStrict,
+			wantWarnings:    1,
+		},
+		{
+			desc:            "ephemeral with default webhook mode",
+			persistenceType: v1alpha1.AuditPersistenceTypeEphemeral,
+			wantWarnings:    1,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.desc, func(t *testing.T) {
+			warnings := persistenceWarnings(&v1alpha1.AuditConfig{
+				WebhookMode: tc.mode,
+				Persistence: v1alpha1.AuditPersistence{
+					Type: tc.persistenceType,
+				},
+			})
+			assert.Len(t, warnings, tc.wantWarnings)
+		})
+	}
+}
+
+func TestSeedObjects_HighAvailability(t *testing.T) {
+	tt := []struct {
+		desc              string
+		controlPlane      *v1beta1.ControlPlane
+		replicas          *int32
+		wantReplicas      int32
+		wantTopologyKeys  []string
+		wantUnsatisfiable corev1.UnsatisfiableConstraintAction
+	}{
+		{
+			desc:              "no high availability",
+			replicas:          pointer.Pointer(int32(1)),
+			wantReplicas:      1,
+			wantTopologyKeys:  []string{corev1.LabelHostname},
+			wantUnsatisfiable: corev1.ScheduleAnyway,
+		},
+		{
+			desc: "node failure tolerance",
+			controlPlane: &v1beta1.ControlPlane{
+				HighAvailability: &v1beta1.HighAvailability{
+					FailureTolerance: v1beta1.FailureTolerance{Type: v1beta1.FailureToleranceTypeNode},
+				},
+			},
+			replicas:          pointer.Pointer(int32(1)),
+			wantReplicas:      2,
+			wantTopologyKeys:  []string{corev1.LabelHostname},
+			wantUnsatisfiable: corev1.DoNotSchedule,
+		},
+		{
+			desc: "zone failure tolerance",
+			controlPlane: &v1beta1.ControlPlane{
+				HighAvailability: &v1beta1.HighAvailability{
+					FailureTolerance: v1beta1.FailureTolerance{Type: v1beta1.FailureToleranceTypeZone},
+				},
+			},
+			replicas:          pointer.Pointer(int32(2)),
+			wantReplicas:      3,
+			wantTopologyKeys:  []string{corev1.LabelHostname, corev1.LabelTopologyZone},
+			wantUnsatisfiable: corev1.DoNotSchedule,
+		},
+		{
+			desc: "zone failure tolerance with more replicas",
+			controlPlane: &v1beta1.ControlPlane{
+				HighAvailability: &v1beta1.HighAvailability{
+					FailureTolerance: v1beta1.FailureTolerance{Type: v1beta1.FailureToleranceTypeZone},
+				},
+			},
+			replicas:          pointer.Pointer(int32(4)),
+			wantReplicas:      4,
+			wantTopologyKeys:  []string{corev1.LabelHostname, corev1.LabelTopologyZone},
+			wantUnsatisfiable: corev1.DoNotSchedule,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.desc, func(t *testing.T) {
+			auditConfig := &v1alpha1.AuditConfig{
+				Backends: &v1alpha1.AuditBackends{},
+				Replicas: tc.replicas,
+				Persistence: v1alpha1.AuditPersistence{
+					Size: &resource.Quantity{},
+				},
+			}
+			cluster := &extensions.Cluster{
+				Shoot: &v1beta1.Shoot{
+					Spec: v1beta1.ShootSpec{
+						ControlPlane: tc.controlPlane,
+					},
+				},
+			}
+
+			objects, err := seedObjects(auditConfig, map[string]*corev1.Secret{}, cluster, &corev1.Secret{}, enforcedBackends{}, fluentbitconfig.FormatClassic, defaultInputSilencePeriod, "", "")
+			require.NoError(t, err)
+
+			sts, ok := objects[0].(*appsv1.StatefulSet)
+			require.Truef(t, ok, "statefulset is of the wrong type %T", objects[0])
+
+			assert.Equal(t, tc.wantReplicas, pointer.SafeDeref(sts.Spec.Replicas))
+			assert.Equal(t, "gardener-system-400", sts.Spec.Template.Spec.PriorityClassName)
+
+			var keys []string
+			for _, c := range sts.Spec.Template.Spec.TopologySpreadConstraints {
+				keys = append(keys, c.TopologyKey)
+				if c.TopologyKey == corev1.LabelHostname {
+					assert.Equal(t, tc.wantUnsatisfiable, c.WhenUnsatisfiable)
+				}
+			}
+			assert.Equal(t, tc.wantTopologyKeys, keys)
+		})
+	}
+}
+
+func TestSeedObjects_Resources(t *testing.T) {
+	custom := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("50m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		},
+	}
+
+	auditConfig := &v1alpha1.AuditConfig{
+		Backends:  &v1alpha1.AuditBackends{},
+		Resources: custom,
+		Persistence: v1alpha1.AuditPersistence{
+			Size: &resource.Quantity{},
+		},
+	}
+	cluster := &extensions.Cluster{
+		Shoot: &v1beta1.Shoot{},
+	}
+
+	objects, err := seedObjects(auditConfig, map[string]*corev1.Secret{}, cluster, &corev1.Secret{}, enforcedBackends{}, fluentbitconfig.FormatClassic, defaultInputSilencePeriod, "", "")
+	require.NoError(t, err)
+
+	sts, ok := objects[0].(*appsv1.StatefulSet)
+	require.Truef(t, ok, "statefulset is of the wrong type %T", objects[0])
+	assert.Equal(t, *custom, sts.Spec.Template.Spec.Containers[0].Resources)
+
+	var vpa *vpaautoscalingv1.VerticalPodAutoscaler
+	for _, obj := range objects {
+		if v, ok := obj.(*vpaautoscalingv1.VerticalPodAutoscaler); ok {
+			vpa = v
+		}
+	}
+	require.NotNil(t, vpa, "no vpa found in seed objects")
+	assert.Equal(t, "StatefulSet", vpa.Spec.TargetRef.Kind)
+	assert.Equal(t, sts.Name, vpa.Spec.TargetRef.Name)
+	require.Len(t, vpa.Spec.ResourcePolicy.ContainerPolicies, 1)
+	assert.Equal(t, custom.Limits, vpa.Spec.ResourcePolicy.ContainerPolicies[0].MaxAllowed)
+	assert.Equal(t, vpaautoscalingv1.ContainerControlledValuesRequestsOnly, *vpa.Spec.ResourcePolicy.ContainerPolicies[0].ControlledValues)
+}
+
+func TestObjects_PodSecurity(t *testing.T) {
+	var (
+		auditConfig = &v1alpha1.AuditConfig{
+			Backends: &v1alpha1.AuditBackends{
+				Log:               &v1alpha1.AuditBackendLog{Enabled: true},
+				ClusterForwarding: &v1alpha1.AuditBackendClusterForwarding{Enabled: true},
+				Splunk:            &v1alpha1.AuditBackendSplunk{Enabled: true},
+			},
+			Persistence: v1alpha1.AuditPersistence{
+				Size: &resource.Quantity{},
+			},
+		}
+		secrets = map[string]*corev1.Secret{
+			"audittailer-client": {ObjectMeta: metav1.ObjectMeta{Name: "audittailer-client"}},
+			"audittailer-server": {ObjectMeta: metav1.ObjectMeta{Name: "audittailer-server"}},
+		}
+		cluster = &extensions.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "shoot--project--name"},
+			Shoot:      &v1beta1.Shoot{},
+		}
+	)
+
+	seed, err := seedObjects(auditConfig, secrets, cluster, &corev1.Secret{}, enforcedBackends{}, fluentbitconfig.FormatClassic, defaultInputSilencePeriod, "shoot-access-audit", "shoot--project--name")
+	require.NoError(t, err)
+
+	shoot, err := shootObjects(auditConfig, secrets)
+	require.NoError(t, err)
+
+	podSpecs := map[string]*corev1.PodSpec{}
+	for _, obj := range append(seed, shoot...) {
+		switch o := obj.(type) {
+		case *appsv1.StatefulSet:
+			podSpecs[o.Name] = &o.Spec.Template.Spec
+		case *appsv1.Deployment:
+			podSpecs[o.Name] = &o.Spec.Template.Spec
+		}
+	}
+	require.Len(t, podSpecs, 3)
+
+	var (
+		// the vpn gateway needs its token for reading secrets in the seed
+		needsServiceAccountToken = map[string]bool{"audit-cluster-forwarding-vpn-gateway": true}
+		// the audittailer in the shoot runs fluentd without a read-only root filesystem
+		writesRootFilesystem = map[string]bool{"audittailer": true}
+	)
+
+	for name, ps := range podSpecs {
+		t.Run(name, func(t *testing.T) {
+			if !needsServiceAccountToken[name] {
+				assert.Equal(t, pointer.Pointer(false), ps.AutomountServiceAccountToken)
+			}
+
+			for _, c := range ps.Containers {
+				sc := c.SecurityContext
+				require.NotNil(t, sc, "container %q has no security context", c.Name)
+
+				runAsNonRoot := sc.RunAsNonRoot
+				runAsUser := sc.RunAsUser
+				seccomp := sc.SeccompProfile
+				if ps.SecurityContext != nil {
+					runAsNonRoot = pointer.Pointer(pointer.SafeDeref(runAsNonRoot) || pointer.SafeDeref(ps.SecurityContext.RunAsNonRoot))
+					if runAsUser == nil {
+						runAsUser = ps.SecurityContext.RunAsUser
+					}
+					if seccomp == nil {
+						seccomp = ps.SecurityContext.SeccompProfile
+					}
+				}
+
+				assert.True(t, pointer.SafeDeref(runAsNonRoot), "container %q may run as root", c.Name)
+				assert.NotZero(t, pointer.SafeDeref(runAsUser), "container %q runs with uid 0", c.Name)
+				require.NotNil(t, seccomp, "container %q has no seccomp profile", c.Name)
+				assert.Equal(t, corev1.SeccompProfileTypeRuntimeDefault, seccomp.Type)
+				assert.Equal(t, pointer.Pointer(false), sc.AllowPrivilegeEscalation)
+				require.NotNil(t, sc.Capabilities)
+				assert.Equal(t, []corev1.Capability{"ALL"}, sc.Capabilities.Drop)
+				assert.Empty(t, sc.Capabilities.Add)
+				assert.Nil(t, sc.Privileged)
+
+				if !writesRootFilesystem[name] {
+					assert.Equal(t, pointer.Pointer(true), sc.ReadOnlyRootFilesystem, "container %q has a writable root filesystem", c.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestSeedObjects_EnforcedBackends(t *testing.T) {
+	var (
+		auditConfig = &v1alpha1.AuditConfig{
+			Backends: &v1alpha1.AuditBackends{
+				Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, Host: "user.example.com", Port: "8088", Index: "user"},
+			},
+			Persistence: v1alpha1.AuditPersistence{
+				Size: &resource.Quantity{},
+			},
+		}
+		enforced = enforcedBackends{
+			backends: &v1alpha1.AuditBackends{
+				Log:    &v1alpha1.AuditBackendLog{Enabled: true},
+				Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, Host: "operator.example.com", Port: "8088", Index: "operator"},
+			},
+			splunkSecret: &corev1.Secret{
+				Data: map[string][]byte{v1alpha1.SplunkSecretTokenKey: []byte("operator-token")},
+			},
+		}
+		cluster = &extensions.Cluster{
+			Shoot: &v1beta1.Shoot{},
+		}
+	)
+
+	objects, err := seedObjects(auditConfig, map[string]*corev1.Secret{}, cluster, &corev1.Secret{
+		Data: map[string][]byte{v1alpha1.SplunkSecretTokenKey: []byte("user-token")},
+	}, enforced, fluentbitconfig.FormatClassic, defaultInputSilencePeriod, "", "shoot--project--name")
+	require.NoError(t, err)
+
+	sts, ok := objects[0].(*appsv1.StatefulSet)
+	require.Truef(t, ok, "statefulset is of the wrong type %T", objects[0])
+	fluentbitConfigMap, ok := objects[2].(*corev1.ConfigMap)
+	require.Truef(t, ok, "fluentbitConfigMap is of the wrong type %T", objects[2])
+
+	assert.NotContains(t, fluentbitConfigMap.Data, "log.backend.conf")
+	assert.Contains(t, fluentbitConfigMap.Data["enforced-log.backend.conf"], "alias enforced-log")
+	assert.Contains(t, fluentbitConfigMap.Data["splunk.backend.conf"], "user.example.com")
+	assert.Contains(t, fluentbitConfigMap.Data["enforced-splunk.backend.conf"], "operator.example.com")
+	assert.Contains(t, fluentbitConfigMap.Data["enforced-splunk.backend.conf"], "${ENFORCED_SPLUNK_HEC_TOKEN}")
+
+	secrets := map[string]*corev1.Secret{}
+	for _, obj := range objects {
+		if s, ok := obj.(*corev1.Secret); ok {
+			secrets[s.Name] = s
+		}
+	}
+	require.Contains(t, secrets, "audit-splunk-secret")
+	require.Contains(t, secrets, "audit-enforced-splunk-secret")
+	assert.Equal(t, []byte("user-token"), secrets["audit-splunk-secret"].Data["splunk_hec_token"])
+	assert.Equal(t, []byte("operator-token"), secrets["audit-enforced-splunk-secret"].Data["splunk_hec_token"])
+
+	env := map[string]string{}
+	for _, e := range sts.Spec.Template.Spec.Containers[0].Env {
+		if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil {
+			env[e.Name] = e.ValueFrom.SecretKeyRef.Name
+		}
+	}
+	assert.Equal(t, "audit-splunk-secret", env["SPLUNK_HEC_TOKEN"])
+	assert.Equal(t, "audit-enforced-splunk-secret", env["ENFORCED_SPLUNK_HEC_TOKEN"])
+	assert.Contains(t, sts.Spec.Template.Annotations, "checksum/enforced-splunk-secret")
+}
+
+func TestEnforcedBackends(t *testing.T) {
+	operatorSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "audit-splunk", Namespace: "garden"},
+		Data:       map[string][]byte{v1alpha1.SplunkSecretTokenKey: []byte("operator-token")},
+	}
+
+	tests := []struct {
+		name     string
+		enforced *v1alpha1.AuditBackends
+		wantErr  string
+	}{
+		{
+			name: "nothing enforced",
+		},
+		{
+			name: "splunk",
+			enforced: &v1alpha1.AuditBackends{
+				Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, SecretResourceName: "splunk"},
+			},
+		},
+		{
+			name: "splunk secret missing",
+			enforced: &v1alpha1.AuditBackends{
+				Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, SecretResourceName: "missing"},
+			},
+			wantErr: "unable to get default backend secret",
+		},
+		{
+			name: "splunk secret not configured",
+			enforced: &v1alpha1.AuditBackends{
+				Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, SecretResourceName: "other"},
+			},
+			wantErr: `backend secret "other" is not configured`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &actuator{
+				client: fake.NewClientBuilder().WithObjects(operatorSecret.DeepCopy()).Build(),
+				config: config.ControllerConfiguration{
+					EnforcedBackends: tt.enforced,
+					BackendSecrets: []config.BackendSecret{
+						{Name: "splunk", SecretRef: corev1.SecretReference{Name: "audit-splunk", Namespace: "garden"}},
+						{Name: "missing", SecretRef: corev1.SecretReference{Name: "audit-missing", Namespace: "garden"}},
+					},
+				},
+			}
+
+			got, err := a.enforcedBackends(context.Background())
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, got.backends)
+
+			if pointer.SafeDeref(got.backends.Splunk).Enabled {
+				require.NotNil(t, got.splunkSecret)
+				assert.Equal(t, []byte("operator-token"), got.splunkSecret.Data[v1alpha1.SplunkSecretTokenKey])
+				assert.NotNil(t, got.backends.Splunk.FilesystemBufferSize, "defaults are applied")
+			} else {
+				assert.Nil(t, got.splunkSecret)
+			}
+		})
+	}
+}
+
+func TestSeedObjects_SplunkSecretChecksum(t *testing.T) {
+	checksum := func(data map[string][]byte) string {
+		auditConfig := &v1alpha1.AuditConfig{
+			Backends: &v1alpha1.AuditBackends{
+				Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true},
+			},
+			Persistence: v1alpha1.AuditPersistence{
+				Size: &resource.Quantity{},
+			},
+		}
+
+		objects, err := seedObjects(auditConfig, map[string]*corev1.Secret{}, &extensions.Cluster{Shoot: &v1beta1.Shoot{}}, &corev1.Secret{Data: data}, enforcedBackends{}, fluentbitconfig.FormatClassic, defaultInputSilencePeriod, "", "")
+		require.NoError(t, err)
+
+		sts, ok := objects[0].(*appsv1.StatefulSet)
+		require.Truef(t, ok, "statefulset is of the wrong type %T", objects[0])
+
+		return sts.Spec.Template.Annotations["checksum/splunk-secret"]
+	}
+
+	original := checksum(map[string][]byte{v1alpha1.SplunkSecretTokenKey: []byte("a")})
+	require.NotEmpty(t, original)
+
+	assert.Equal(t, original, checksum(map[string][]byte{v1alpha1.SplunkSecretTokenKey: []byte("a")}))
+	assert.NotEqual(t, original, checksum(map[string][]byte{v1alpha1.SplunkSecretTokenKey: []byte("b")}), "rotated token rolls the statefulset")
+	assert.NotEqual(t, original, checksum(map[string][]byte{v1alpha1.SplunkSecretTokenKey: []byte("a"), v1alpha1.SplunkSecretCaFileKey: []byte("ca")}), "changed ca rolls the statefulset")
+}
+
+func TestSeedObjects_FluentBitFiles(t *testing.T) {
+	auditConfig := &v1alpha1.AuditConfig{
+		Backends: &v1alpha1.AuditBackends{
+			Log: &v1alpha1.AuditBackendLog{Enabled: true},
+		},
+		Persistence: v1alpha1.AuditPersistence{
+			Size: &resource.Quantity{},
+		},
+	}
+
+	objects, err := seedObjects(auditConfig, map[string]*corev1.Secret{}, &extensions.Cluster{Shoot: &v1beta1.Shoot{}}, nil, enforcedBackends{}, fluentbitconfig.FormatClassic, defaultInputSilencePeriod, "", "")
+	require.NoError(t, err)
+
+	var configMap *corev1.ConfigMap
+	for _, obj := range objects {
+		if cm, ok := obj.(*corev1.ConfigMap); ok && cm.Name == "audit-fluent-bit-config" {
+			configMap = cm
+		}
+	}
+	require.NotNil(t, configMap, "fluent-bit config map not found")
+
+	assert.Contains(t, configMap.Data["fluent-bit.conf"], "parsers_file /config/parsers.conf")
+	assert.Contains(t, configMap.Data["parsers.conf"], "[PARSER]")
+	assert.Contains(t, configMap.Data, "log.backend.conf")
+
+	for name := range configMap.Data {
+		assert.Len(t, configMap.Annotations["checksum/"+name], 64, "checksum of %s", name)
+	}
+}
+
+func TestSeedObjects_FluentBitYAML(t *testing.T) {
+	auditConfig := &v1alpha1.AuditConfig{
+		Backends: &v1alpha1.AuditBackends{
+			Log: &v1alpha1.AuditBackendLog{Enabled: true},
+			Splunk: &v1alpha1.AuditBackendSplunk{
+				Enabled:    true,
+				Host:       "splunk.example.com",
+				Port:       "443",
+				CustomData: map[string]string{"cluster": "shoot"},
+			},
+		},
+		Persistence: v1alpha1.AuditPersistence{
+			Size: &resource.Quantity{},
+		},
+	}
+
+	objects, err := seedObjects(auditConfig, map[string]*corev1.Secret{}, &extensions.Cluster{Shoot: &v1beta1.Shoot{}}, &corev1.Secret{}, enforcedBackends{}, fluentbitconfig.FormatYAML, defaultInputSilencePeriod, "", "")
+	require.NoError(t, err)
+
+	sts, ok := objects[0].(*appsv1.StatefulSet)
+	require.Truef(t, ok, "statefulset is of the wrong type %T", objects[0])
+	assert.Contains(t, sts.Spec.Template.Spec.Containers[0].Args, "--config=/config/fluent-bit.yaml")
+
+	var configMap *corev1.ConfigMap
+	for _, obj := range objects {
+		if cm, ok := obj.(*corev1.ConfigMap); ok && cm.Name == "audit-fluent-bit-config" {
+			configMap = cm
+		}
+	}
+	require.NotNil(t, configMap, "fluent-bit config map not found")
+
+	main, err := fluentbitconfig.ParseYAML(configMap.Data["fluent-bit.yaml"])
+	require.NoError(t, err)
+	assert.Equal(t, []fluentbitconfig.Include{
+		"/config/log.backend.yaml",
+		"/config/null.backend.yaml",
+		"/config/splunk.backend.yaml",
+	}, main.Includes)
+
+	splunk, err := fluentbitconfig.ParseYAML(configMap.Data["splunk.backend.yaml"])
+	require.NoError(t, err)
+	require.Len(t, splunk.Sections, 1, "custom data must not be added by a filter")
+	assert.Equal(t, "OUTPUT", splunk.Sections[0].Name)
+	assert.Equal(t, []fluentbitconfig.Section{
+		fluentbitconfig.Section{}.Add("name", "modify").Add("add", "cluster shoot"),
+	}, splunk.Sections[0].Processors)
+
+	assert.Contains(t, configMap.Data, "parsers.conf")
+	assert.NotContains(t, configMap.Data, "fluent-bit.conf")
+}
@@ -2,10 +2,13 @@ package audit
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/gardener/gardener/extensions/pkg/controller/extension"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
 )
@@ -42,6 +45,15 @@ func AddToManager(ctx context.Context, mgr manager.Manager) error {
 // AddToManagerWithOptions adds a controller with the given Options to the given manager.
 // The opts.Reconciler is being set with a newly instantiated actuator.
 func AddToManagerWithOptions(ctx context.Context, mgr manager.Manager, opts AddOptions) error {
+	decoder := serializer.NewCodecFactory(mgr.GetScheme()).UniversalDecoder()
+	if err := ctrlmetrics.Registry.Register(newShootCollector(mgr.GetClient(), decoder, opts.Config)); err != nil {
+		return fmt.Errorf("unable to register shoot metrics: %w", err)
+	}
+
+	if err := addBackendSecretController(mgr, opts.Config); err != nil {
+		return fmt.Errorf("unable to add backend secret controller: %w", err)
+	}
+
 	return extension.Add(ctx, mgr, extension.AddArgs{
 		Actuator:          NewActuator(mgr, opts.Config),
 		ControllerOptions: opts.ControllerOptions,
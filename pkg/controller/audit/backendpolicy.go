@@ -0,0 +1,217 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+)
+
+const (
+	// backendEgressPolicyName is the name of the network policy that allows the audit webhook backend to reach its backends
+	backendEgressPolicyName = "egress-from-audit-webhook-backend-to-backends"
+	// placeholderAddress is the address of a backend that was not resolved because the resources are only rendered
+	placeholderAddress = "<resolved during reconciliation>"
+)
+
+// resolver resolves the hosts of the backends, it is implemented by net.Resolver. without a resolver the hosts are not
+// looked up, which is used to render the resources without network access.
+type resolver interface {
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+}
+
+// backendDestination is a destination that the audit webhook backend connects to
+type backendDestination struct {
+	ips  []net.IP
+	port int32
+}
+
+// validateBackendPolicy checks the backends configured by the user against the backend policy of the operator.
+// the default and enforced backends of the operator are not restricted by the policy.
+func validateBackendPolicy(ctx context.Context, r resolver, policy *config.BackendPolicy, backends *v1alpha1.AuditBackends) error {
+	if policy == nil {
+		return nil
+	}
+
+	splunk := pointer.SafeDeref(backends).Splunk
+	if !pointer.SafeDeref(splunk).Enabled {
+		return nil
+	}
+
+	if !hostAllowed(policy.AllowedHosts, splunk.Host) {
+		return configurationProblem(fmt.Errorf("splunk host %q is not allowed by the backend policy", splunk.Host))
+	}
+
+	ips, err := resolve(ctx, r, splunk.Host)
+	if err != nil {
+		return err
+	}
+
+	// the addresses of hosts that are not looked up are only checked on reconciliation
+	for _, ip := range ips {
+		if err := ipAllowed(policy, ip); err != nil {
+			return configurationProblem(fmt.Errorf("splunk host %q resolves to %s, which %w", splunk.Host, ip, err))
+		}
+	}
+
+	return nil
+}
+
+// hostAllowed returns true if the host matches one of the patterns or if no patterns are given
+func hostAllowed(patterns []string, host string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		pattern = strings.ToLower(pattern)
+
+		if domain, ok := strings.CutPrefix(pattern, "*"); ok && strings.HasPrefix(domain, ".") {
+			return strings.HasSuffix(host, domain) && len(host) > len(domain)
+		}
+
+		return host == pattern
+	})
+}
+
+// ipAllowed returns an error if the ip is in a denied network or not in any of the allowed networks
+func ipAllowed(policy *config.BackendPolicy, ip net.IP) error {
+	contains := func(cidrs []string) bool {
+		return slices.ContainsFunc(cidrs, func(cidr string) bool {
+			_, network, err := net.ParseCIDR(cidr)
+			return err == nil && network.Contains(ip)
+		})
+	}
+
+	if contains(policy.DeniedCIDRs) {
+		return errors.New("is in a denied network")
+	}
+	if len(policy.AllowedCIDRs) > 0 && !contains(policy.AllowedCIDRs) {
+		return errors.New("is not in an allowed network")
+	}
+
+	return nil
+}
+
+// resolve returns the addresses of the host, it returns no addresses for hosts that are not ip addresses if there is
+// no resolver
+func resolve(ctx context.Context, r resolver, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	if r == nil {
+		return nil, nil
+	}
+
+	ips, err := r.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve host %q: %w", host, err)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("host %q does not resolve to any address", host)
+	}
+
+	return ips, nil
+}
+
+// backendDestinations resolves the destinations of all enabled splunk backends, including the ones of the operator
+func backendDestinations(ctx context.Context, r resolver, splunks ...*v1alpha1.AuditBackendSplunk) ([]backendDestination, error) {
+	var destinations []backendDestination
+
+	for _, splunk := range splunks {
+		if !pointer.SafeDeref(splunk).Enabled {
+			continue
+		}
+
+		port, err := strconv.ParseInt(splunk.Port, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid splunk port %q: %w", splunk.Port, err)
+		}
+
+		ips, err := resolve(ctx, r, splunk.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		destinations = append(destinations, backendDestination{ips: ips, port: int32(port)})
+	}
+
+	return destinations, nil
+}
+
+// restrictBackendEgress replaces the egress of the audit webhook backend to all public and private networks with a
+// network policy that only allows egress to the given destinations. the destinations are resolved on reconciliation,
+// a changed address of a backend host therefore requires a reconciliation of the extension.
+func restrictBackendEgress(objects []client.Object, namespace string, destinations []backendDestination) []client.Object {
+	for _, obj := range objects {
+		if sts, ok := obj.(*appsv1.StatefulSet); ok && sts.Name == "audit-webhook-backend" {
+			delete(sts.Spec.Template.Labels, "networking.gardener.cloud/to-public-networks")
+			delete(sts.Spec.Template.Labels, "networking.gardener.cloud/to-private-networks")
+		}
+	}
+
+	// network policies are additive, without destinations the policy does not allow any egress in addition to the
+	// egress allowed by the remaining labels of the pods
+	egress := []networkingv1.NetworkPolicyEgressRule{}
+	for _, destination := range destinations {
+		rule := networkingv1.NetworkPolicyEgressRule{
+			Ports: []networkingv1.NetworkPolicyPort{
+				{
+					Protocol: pointer.Pointer(corev1.ProtocolTCP),
+					Port:     pointer.Pointer(intstr.FromInt32(destination.port)),
+				},
+			},
+		}
+
+		if len(destination.ips) == 0 {
+			rule.To = append(rule.To, networkingv1.NetworkPolicyPeer{
+				IPBlock: &networkingv1.IPBlock{CIDR: placeholderAddress},
+			})
+		}
+
+		for _, ip := range destination.ips {
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+
+			rule.To = append(rule.To, networkingv1.NetworkPolicyPeer{
+				IPBlock: &networkingv1.IPBlock{CIDR: (&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}).String()},
+			})
+		}
+
+		egress = append(egress, rule)
+	}
+
+	return append(objects, &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backendEgressPolicyName,
+			Namespace: namespace,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "audit-webhook-backend",
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			Egress:      egress,
+		},
+	})
+}
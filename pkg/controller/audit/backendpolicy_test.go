@@ -0,0 +1,217 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	"github.com/gardener/gardener/pkg/extensions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-audit/pkg/fluentbitconfig"
+)
+
+// fakeResolver resolves hosts from a static map
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupIP(_ context.Context, _, host string) ([]net.IP, error) {
+	addresses, ok := r[host]
+	if !ok {
+		return nil, fmt.Errorf("no such host")
+	}
+
+	var ips []net.IP
+	for _, address := range addresses {
+		ips = append(ips, net.ParseIP(address))
+	}
+
+	return ips, nil
+}
+
+func TestValidateBackendPolicy(t *testing.T) {
+	var (
+		r = fakeResolver{
+			"splunk.example.com":          {"203.0.113.10"},
+			"Splunk.Example.com.":         {"203.0.113.10"},
+			"internal.splunk.example.com": {"10.1.2.3"},
+			"mixed.splunk.example.com":    {"203.0.113.11", "10.1.2.4"},
+			"other.example.org":           {"198.51.100.1"},
+		}
+		policy = &config.BackendPolicy{
+			AllowedHosts: []string{"splunk.example.com", "*.splunk.example.com", "198.51.100.1"},
+			DeniedCIDRs:  []string{"10.0.0.0/8"},
+		}
+		splunk = func(host string) *v1alpha1.AuditBackends {
+			return &v1alpha1.AuditBackends{
+				Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, Host: host, Port: "8088"},
+			}
+		}
+	)
+
+	tests := []struct {
+		name     string
+		policy   *config.BackendPolicy
+		backends *v1alpha1.AuditBackends
+		wantErr  string
+	}{
+		{
+			name:     "no policy",
+			backends: splunk("internal.splunk.example.com"),
+		},
+		{
+			name:   "no backends",
+			policy: policy,
+		},
+		{
+			name:     "disabled splunk is not checked",
+			policy:   policy,
+			backends: &v1alpha1.AuditBackends{Splunk: &v1alpha1.AuditBackendSplunk{Enabled: false, Host: "other.example.org"}},
+		},
+		{
+			name:     "allowed host",
+			policy:   policy,
+			backends: splunk("splunk.example.com"),
+		},
+		{
+			name:     "allowed host is case insensitive",
+			policy:   policy,
+			backends: splunk("Splunk.Example.com."),
+		},
+		{
+			name:     "allowed ip",
+			policy:   policy,
+			backends: splunk("198.51.100.1"),
+		},
+		{
+			name:     "host not allowed",
+			policy:   policy,
+			backends: splunk("other.example.org"),
+			wantErr:  `splunk host "other.example.org" is not allowed by the backend policy`,
+		},
+		{
+			name:     "wildcard does not match the domain itself",
+			policy:   &config.BackendPolicy{AllowedHosts: []string{"*.example.com"}},
+			backends: splunk("example.com"),
+			wantErr:  `splunk host "example.com" is not allowed by the backend policy`,
+		},
+		{
+			name:     "host resolves to denied network",
+			policy:   policy,
+			backends: splunk("internal.splunk.example.com"),
+			wantErr:  `splunk host "internal.splunk.example.com" resolves to 10.1.2.3, which is in a denied network`,
+		},
+		{
+			name:     "any address in a denied network",
+			policy:   policy,
+			backends: splunk("mixed.splunk.example.com"),
+			wantErr:  `splunk host "mixed.splunk.example.com" resolves to 10.1.2.4, which is in a denied network`,
+		},
+		{
+			name:     "denied ip",
+			policy:   policy,
+			backends: splunk("10.0.0.1"),
+			wantErr:  `splunk host "10.0.0.1" is not allowed by the backend policy`,
+		},
+		{
+			name:     "denied ip without host restriction",
+			policy:   &config.BackendPolicy{DeniedCIDRs: []string{"10.0.0.0/8"}},
+			backends: splunk("10.0.0.1"),
+			wantErr:  `splunk host "10.0.0.1" resolves to 10.0.0.1, which is in a denied network`,
+		},
+		{
+			name:     "not in allowed network",
+			policy:   &config.BackendPolicy{AllowedCIDRs: []string{"203.0.113.0/24"}},
+			backends: splunk("other.example.org"),
+			wantErr:  `splunk host "other.example.org" resolves to 198.51.100.1, which is not in an allowed network`,
+		},
+		{
+			name:     "unresolvable host",
+			policy:   &config.BackendPolicy{},
+			backends: splunk("unknown.example.com"),
+			wantErr:  `unable to resolve host "unknown.example.com": no such host`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBackendPolicy(context.Background(), r, tt.policy, tt.backends)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRestrictBackendEgress(t *testing.T) {
+	r := fakeResolver{
+		"splunk.example.com":   {"203.0.113.10", "2001:db8::10"},
+		"operator.example.com": {"198.51.100.1"},
+	}
+
+	destinations, err := backendDestinations(context.Background(), r,
+		&v1alpha1.AuditBackendSplunk{Enabled: true, Host: "splunk.example.com", Port: "8088"},
+		&v1alpha1.AuditBackendSplunk{Enabled: false, Host: "disabled.example.com", Port: "8088"},
+		nil,
+		&v1alpha1.AuditBackendSplunk{Enabled: true, Host: "operator.example.com", Port: "443"},
+	)
+	require.NoError(t, err)
+
+	auditConfig := &v1alpha1.AuditConfig{
+		Backends: &v1alpha1.AuditBackends{},
+		Persistence: v1alpha1.AuditPersistence{
+			Size: &resource.Quantity{},
+		},
+	}
+
+	objects, err := seedObjects(auditConfig, map[string]*corev1.Secret{}, &extensions.Cluster{Shoot: &v1beta1.Shoot{}}, &corev1.Secret{}, enforcedBackends{}, fluentbitconfig.FormatClassic, defaultInputSilencePeriod, "", "shoot--project--name")
+	require.NoError(t, err)
+
+	objects = restrictBackendEgress(objects, "shoot--project--name", destinations)
+
+	var (
+		sts    *appsv1.StatefulSet
+		policy *networkingv1.NetworkPolicy
+	)
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case *appsv1.StatefulSet:
+			sts = o
+		case *networkingv1.NetworkPolicy:
+			policy = o
+		}
+	}
+	require.NotNil(t, sts)
+	require.NotNil(t, policy)
+
+	assert.NotContains(t, sts.Spec.Template.Labels, "networking.gardener.cloud/to-public-networks")
+	assert.NotContains(t, sts.Spec.Template.Labels, "networking.gardener.cloud/to-private-networks")
+	assert.Equal(t, "allowed", sts.Spec.Template.Labels["networking.gardener.cloud/to-dns"])
+
+	assert.Equal(t, "shoot--project--name", policy.Namespace)
+	for key, value := range policy.Spec.PodSelector.MatchLabels {
+		assert.Equal(t, value, sts.Spec.Template.Labels[key], "network policy does not select the audit webhook backend")
+	}
+
+	require.Len(t, policy.Spec.Egress, 2)
+	assert.Equal(t, int32(8088), policy.Spec.Egress[0].Ports[0].Port.IntVal)
+	require.Len(t, policy.Spec.Egress[0].To, 2)
+	assert.Equal(t, "203.0.113.10/32", policy.Spec.Egress[0].To[0].IPBlock.CIDR)
+	assert.Equal(t, "2001:db8::10/128", policy.Spec.Egress[0].To[1].IPBlock.CIDR)
+	assert.Equal(t, int32(443), policy.Spec.Egress[1].Ports[0].Port.IntVal)
+	assert.Equal(t, "198.51.100.1/32", policy.Spec.Egress[1].To[0].IPBlock.CIDR)
+}
+
+func TestBackendDestinations_InvalidPort(t *testing.T) {
+	_, err := backendDestinations(context.Background(), fakeResolver{}, &v1alpha1.AuditBackendSplunk{Enabled: true, Host: "203.0.113.10", Port: "splunk"})
+	require.ErrorContains(t, err, `invalid splunk port "splunk"`)
+}
@@ -0,0 +1,246 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gardener/gardener/extensions/pkg/controller"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	"github.com/gardener/gardener/pkg/apis/core/v1beta1/helper"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+)
+
+// BackendSecretControllerName is the name of the controller that watches the secrets of the backends.
+const BackendSecretControllerName = "audit-backend-secret"
+
+// backendSecretReconciler triggers the reconciliation of the extensions that use a secret of the operator's default or
+// enforced backends or a secret referenced in the shoot resources when the secret changes, such that a rotated token
+// or ca is rolled out to the audit webhook backends through the checksum annotations of the statefulset
+type backendSecretReconciler struct {
+	client  client.Client
+	decoder runtime.Decoder
+	config  config.ControllerConfiguration
+	log     logr.Logger
+}
+
+func addBackendSecretController(mgr manager.Manager, cfg config.ControllerConfiguration) error {
+	r := &backendSecretReconciler{
+		client:  mgr.GetClient(),
+		decoder: serializer.NewCodecFactory(mgr.GetScheme()).UniversalDecoder(),
+		config:  cfg,
+		log:     mgr.GetLogger().WithName(BackendSecretControllerName),
+	}
+
+	return builder.ControllerManagedBy(mgr).
+		Named(BackendSecretControllerName).
+		For(&corev1.Secret{}, builder.WithPredicates(backendSecretPredicate(cfg.BackendSecrets))).
+		Complete(r)
+}
+
+// backendSecretPredicate only lets through changes to the contents of the configured backend secrets and of the secrets
+// that gardener copies from the shoot resources into the shoot namespace. creations are ignored as they occur for every
+// secret on startup, a missing secret fails the reconciliation which is retried anyway.
+func backendSecretPredicate(backendSecrets []config.BackendSecret) predicate.Predicate {
+	referenced := func(obj client.Object) bool {
+		if strings.HasPrefix(obj.GetName(), v1beta1constants.ReferencedResourcesPrefix) {
+			return true
+		}
+		return len(operatorBackendSecretNames(backendSecrets, obj.GetNamespace(), obj.GetName())) > 0
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldSecret, ok := e.ObjectOld.(*corev1.Secret)
+			if !ok {
+				return false
+			}
+			newSecret, ok := e.ObjectNew.(*corev1.Secret)
+			if !ok {
+				return false
+			}
+
+			return referenced(newSecret) && !reflect.DeepEqual(oldSecret.Data, newSecret.Data)
+		},
+		DeleteFunc: func(event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// operatorBackendSecretNames returns the names by which the operator's backends refer to the given secret
+func operatorBackendSecretNames(backendSecrets []config.BackendSecret, namespace, name string) []string {
+	var names []string
+	for _, s := range backendSecrets {
+		if s.SecretRef.Namespace == namespace && s.SecretRef.Name == name {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// Reconcile annotates the affected extensions with the reconcile operation
+func (r *backendSecretReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	var (
+		names              = operatorBackendSecretNames(r.config.BackendSecrets, req.Namespace, req.Name)
+		extensions         = &extensionsv1alpha1.ExtensionList{}
+		referencedResource *string
+		opts               []client.ListOption
+	)
+
+	if strings.HasPrefix(req.Name, v1beta1constants.ReferencedResourcesPrefix) {
+		referencedResource = pointer.Pointer(strings.TrimPrefix(req.Name, v1beta1constants.ReferencedResourcesPrefix))
+	}
+	if len(names) == 0 {
+		// secrets copied from the shoot resources are only used by the extension in the same namespace
+		opts = append(opts, client.InNamespace(req.Namespace))
+	}
+
+	if err := r.client.List(ctx, extensions, opts...); err != nil {
+		return reconcile.Result{}, fmt.Errorf("unable to list extensions: %w", err)
+	}
+
+	for i := range extensions.Items {
+		ex := &extensions.Items[i]
+
+		if ex.Spec.Type != Type || ex.DeletionTimestamp != nil {
+			continue
+		}
+		if ex.Annotations[v1beta1constants.GardenerOperation] == v1beta1constants.GardenerOperationReconcile {
+			continue
+		}
+		uses, err := r.usesBackendSecret(ctx, ex, names)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if !uses && referencedResource != nil && ex.Namespace == req.Namespace {
+			uses, err = r.usesReferencedSecret(ctx, ex, *referencedResource)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		if !uses {
+			continue
+		}
+
+		patch := client.MergeFrom(ex.DeepCopy())
+		metav1.SetMetaDataAnnotation(&ex.ObjectMeta, v1beta1constants.GardenerOperation, v1beta1constants.GardenerOperationReconcile)
+		if err := r.client.Patch(ctx, ex, patch); err != nil {
+			return reconcile.Result{}, fmt.Errorf("unable to trigger reconciliation of extension %s: %w", client.ObjectKeyFromObject(ex), err)
+		}
+
+		r.log.Info("triggered reconciliation because backend secret changed", "extension", client.ObjectKeyFromObject(ex), "secret", req.NamespacedName)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// usesBackendSecret returns true if one of the given backend secrets is used by the enforced backends or by a default
+// backend that is applied to the extension. extensions with an undecodable config are reconciled to be on the safe side.
+func (r *backendSecretReconciler) usesBackendSecret(ctx context.Context, ex *extensionsv1alpha1.Extension, names []string) (bool, error) {
+	uses := func(splunk *v1alpha1.AuditBackendSplunk) bool {
+		if splunk == nil {
+			return false
+		}
+		for _, name := range names {
+			if splunk.SecretResourceName == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(names) == 0 {
+		return false, nil
+	}
+
+	if enforced := r.config.EnforcedBackends; enforced != nil && pointer.SafeDeref(enforced.Splunk).Enabled && uses(enforced.Splunk) {
+		return true, nil
+	}
+
+	// the cluster is only fetched if one of the default backends uses the secret
+	candidate := uses(pointer.SafeDeref(r.config.DefaultBackends).Splunk)
+	for _, profile := range r.config.DefaultProfiles {
+		candidate = candidate || uses(pointer.SafeDeref(profile.Backends).Splunk)
+	}
+	if !candidate {
+		return false, nil
+	}
+
+	auditConfig := &v1alpha1.AuditConfig{}
+	if ex.Spec.ProviderConfig != nil {
+		if _, _, err := r.decoder.Decode(ex.Spec.ProviderConfig.Raw, nil, auditConfig); err != nil {
+			return true, nil
+		}
+	}
+	if pointer.SafeDeref(auditConfig.Backends).Splunk != nil {
+		return false, nil
+	}
+
+	var shoot *gardencorev1beta1.Shoot
+	if len(r.config.DefaultProfiles) > 0 {
+		cluster, err := controller.GetCluster(ctx, r.client, ex.Namespace)
+		if err != nil {
+			return false, fmt.Errorf("unable to get cluster: %w", err)
+		}
+		shoot = cluster.Shoot
+	}
+
+	defaults, err := ResolveDefaults(r.config, shoot)
+	if err != nil {
+		return false, err
+	}
+
+	return uses(pointer.SafeDeref(defaults.Backends).Splunk), nil
+}
+
+// usesReferencedSecret returns true if the splunk backend of the user uses the shoot resource, which refers to the
+// secret that was copied into the shoot namespace with the given name
+func (r *backendSecretReconciler) usesReferencedSecret(ctx context.Context, ex *extensionsv1alpha1.Extension, resourceName string) (bool, error) {
+	auditConfig := &v1alpha1.AuditConfig{}
+	if ex.Spec.ProviderConfig != nil {
+		if _, _, err := r.decoder.Decode(ex.Spec.ProviderConfig.Raw, nil, auditConfig); err != nil {
+			return true, nil
+		}
+	}
+
+	splunk := pointer.SafeDeref(auditConfig.Backends).Splunk
+	if !pointer.SafeDeref(splunk).Enabled {
+		return false, nil
+	}
+
+	cluster, err := controller.GetCluster(ctx, r.client, ex.Namespace)
+	if err != nil {
+		return false, fmt.Errorf("unable to get cluster: %w", err)
+	}
+	if cluster.Shoot == nil {
+		return false, nil
+	}
+
+	resource := helper.GetResourceByName(cluster.Shoot.Spec.Resources, splunk.SecretResourceName)
+
+	return resource != nil && resource.ResourceRef.Kind == "Secret" && resource.ResourceRef.Name == resourceName, nil
+}
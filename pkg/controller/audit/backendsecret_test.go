@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+)
+
+func TestBackendSecretPredicate(t *testing.T) {
+	p := backendSecretPredicate([]config.BackendSecret{
+		{Name: "splunk", SecretRef: corev1.SecretReference{Name: "default-backend-secret-splunk", Namespace: "garden"}},
+	})
+
+	secret := func(name string, token string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "garden"},
+			Data:       map[string][]byte{v1alpha1.SplunkSecretTokenKey: []byte(token)},
+		}
+	}
+
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: secret("default-backend-secret-splunk", "a"), ObjectNew: secret("default-backend-secret-splunk", "b")}), "rotated")
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: secret("default-backend-secret-splunk", "a"), ObjectNew: secret("default-backend-secret-splunk", "a")}), "unchanged")
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: secret("ref-splunk", "a"), ObjectNew: secret("ref-splunk", "b")}), "copied from the shoot resources")
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: secret("other", "a"), ObjectNew: secret("other", "b")}), "not referenced")
+	assert.False(t, p.Create(event.CreateEvent{Object: secret("default-backend-secret-splunk", "a")}))
+}
+
+func TestBackendSecretReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, extensionsv1alpha1.AddToScheme(scheme))
+	install.Install(scheme)
+
+	extension := func(name string, providerConfig string) *extensionsv1alpha1.Extension {
+		ex := &extensionsv1alpha1.Extension{
+			ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: name},
+			Spec: extensionsv1alpha1.ExtensionSpec{
+				DefaultSpec: extensionsv1alpha1.DefaultSpec{Type: Type},
+			},
+		}
+		if providerConfig != "" {
+			ex.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(providerConfig)}
+		}
+		return ex
+	}
+
+	const userSplunk = `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","backends":{"splunk":{"enabled":false}}}`
+
+	tests := []struct {
+		name     string
+		config   config.ControllerConfiguration
+		want     []string
+		dontWant []string
+	}{
+		{
+			name: "default backend secret",
+			config: config.ControllerConfiguration{
+				DefaultBackends: &v1alpha1.AuditBackends{
+					Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, SecretResourceName: "splunk"},
+				},
+			},
+			want:     []string{"shoot--a"},
+			dontWant: []string{"shoot--b"},
+		},
+		{
+			name: "enforced backend secret",
+			config: config.ControllerConfiguration{
+				EnforcedBackends: &v1alpha1.AuditBackends{
+					Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, SecretResourceName: "splunk"},
+				},
+			},
+			want: []string{"shoot--a", "shoot--b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				extension("shoot--a", ""),
+				extension("shoot--b", userSplunk),
+			).Build()
+
+			tt.config.BackendSecrets = []config.BackendSecret{
+				{Name: "splunk", SecretRef: corev1.SecretReference{Name: "default-backend-secret-splunk", Namespace: "garden"}},
+			}
+
+			r := &backendSecretReconciler{
+				client:  c,
+				decoder: serializer.NewCodecFactory(scheme).UniversalDecoder(),
+				config:  tt.config,
+				log:     logr.Discard(),
+			}
+
+			_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "default-backend-secret-splunk", Namespace: "garden"}})
+			require.NoError(t, err)
+
+			for _, namespace := range tt.want {
+				ex := &extensionsv1alpha1.Extension{}
+				require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "audit"}, ex))
+				assert.Equal(t, v1beta1constants.GardenerOperationReconcile, ex.Annotations[v1beta1constants.GardenerOperation], namespace)
+			}
+			for _, namespace := range tt.dontWant {
+				ex := &extensionsv1alpha1.Extension{}
+				require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "audit"}, ex))
+				assert.NotContains(t, ex.Annotations, v1beta1constants.GardenerOperation, namespace)
+			}
+		})
+	}
+}
+
+func TestBackendSecretReconciler_ReferencedSecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, extensionsv1alpha1.AddToScheme(scheme))
+	install.Install(scheme)
+
+	shoot := &gardencorev1beta1.Shoot{
+		Spec: gardencorev1beta1.ShootSpec{
+			Resources: []gardencorev1beta1.NamedResourceReference{
+				{Name: "splunk", ResourceRef: autoscalingv1.CrossVersionObjectReference{Kind: "Secret", Name: "my-splunk-token", APIVersion: "v1"}},
+			},
+		},
+	}
+	shootRaw, err := json.Marshal(shoot)
+	require.NoError(t, err)
+
+	const splunkConfig = `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","backends":{"splunk":{"enabled":true,"secretResourceName":"splunk"}}}`
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&extensionsv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "shoot--a"},
+			Spec: extensionsv1alpha1.ClusterSpec{
+				Shoot:        runtime.RawExtension{Raw: shootRaw},
+				Seed:         runtime.RawExtension{Raw: []byte("{}")},
+				CloudProfile: runtime.RawExtension{Raw: []byte("{}")},
+			},
+		},
+		&extensionsv1alpha1.Extension{
+			ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: "shoot--a"},
+			Spec: extensionsv1alpha1.ExtensionSpec{
+				DefaultSpec: extensionsv1alpha1.DefaultSpec{
+					Type:           Type,
+					ProviderConfig: &runtime.RawExtension{Raw: []byte(splunkConfig)},
+				},
+			},
+		},
+	).Build()
+
+	r := &backendSecretReconciler{
+		client:  c,
+		decoder: serializer.NewCodecFactory(scheme).UniversalDecoder(),
+		log:     logr.Discard(),
+	}
+
+	reconcileSecret := func(name string) string {
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "shoot--a"}})
+		require.NoError(t, err)
+
+		ex := &extensionsv1alpha1.Extension{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "shoot--a", Name: "audit"}, ex))
+		return ex.Annotations[v1beta1constants.GardenerOperation]
+	}
+
+	assert.Empty(t, reconcileSecret("ref-other"), "secret is not used by the audit extension")
+	assert.Equal(t, v1beta1constants.GardenerOperationReconcile, reconcileSecret("ref-my-splunk-token"))
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"fmt"
+	"slices"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+)
+
+// Defaults are the defaults of the operator that apply to a shoot
+type Defaults struct {
+	// Profiles are the names of the default profiles matching the shoot in the order they were applied
+	Profiles []string
+	// Backends are the default backends, which are used for backends that are not configured by the user, nil if the
+	// operator does not configure any default backends for the shoot
+	Backends *v1alpha1.AuditBackends
+	// Resources are the default resources of the audit webhook backend
+	Resources *corev1.ResourceRequirements
+}
+
+// ResolveDefaults returns the defaults of the operator for the given shoot. the default backends and default resources
+// are the base, the matching profiles are applied in the order of their definition and replace every backend and the
+// resources they define, such that the last matching profile takes precedence.
+func ResolveDefaults(cfg config.ControllerConfiguration, shoot *gardencorev1beta1.Shoot) (Defaults, error) {
+	defaults := Defaults{
+		Backends:  cfg.DefaultBackends.DeepCopy(),
+		Resources: cfg.DefaultResources.DeepCopy(),
+	}
+
+	for _, profile := range cfg.DefaultProfiles {
+		matches, err := profileMatches(profile.Selector, shoot)
+		if err != nil {
+			return defaults, fmt.Errorf("unable to evaluate default profile %q: %w", profile.Name, err)
+		}
+		if !matches {
+			continue
+		}
+
+		defaults.Profiles = append(defaults.Profiles, profile.Name)
+
+		if backends := profile.Backends; backends != nil {
+			if defaults.Backends == nil {
+				defaults.Backends = &v1alpha1.AuditBackends{}
+			}
+			if backends.Log != nil {
+				defaults.Backends.Log = backends.Log.DeepCopy()
+			}
+			if backends.ClusterForwarding != nil {
+				defaults.Backends.ClusterForwarding = backends.ClusterForwarding.DeepCopy()
+			}
+			if backends.Splunk != nil {
+				defaults.Backends.Splunk = backends.Splunk.DeepCopy()
+			}
+		}
+		if profile.Resources != nil {
+			defaults.Resources = profile.Resources.DeepCopy()
+		}
+	}
+
+	return defaults, nil
+}
+
+// profileMatches returns true if the shoot matches all criteria of the selector. a shoot without purpose is treated
+// as an evaluation shoot as gardener defaults the purpose to evaluation.
+func profileMatches(selector config.DefaultProfileSelector, shoot *gardencorev1beta1.Shoot) (bool, error) {
+	if shoot == nil {
+		return false, nil
+	}
+
+	if len(selector.Purposes) > 0 {
+		purpose := gardencorev1beta1.ShootPurposeEvaluation
+		if shoot.Spec.Purpose != nil {
+			purpose = *shoot.Spec.Purpose
+		}
+
+		if !slices.Contains(selector.Purposes, string(purpose)) {
+			return false, nil
+		}
+	}
+
+	if len(selector.ProjectNamespaces) > 0 && !slices.Contains(selector.ProjectNamespaces, shoot.Namespace) {
+		return false, nil
+	}
+
+	if selector.ShootLabels != nil {
+		s, err := metav1.LabelSelectorAsSelector(selector.ShootLabels)
+		if err != nil {
+			return false, err
+		}
+
+		if !s.Matches(labels.Set(shoot.Labels)) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
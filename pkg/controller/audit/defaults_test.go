@@ -0,0 +1,170 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	"github.com/gardener/gardener/pkg/extensions"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+)
+
+func TestResolveDefaults(t *testing.T) {
+	var (
+		globalSplunk = &v1alpha1.AuditBackendSplunk{Enabled: true, Index: "global"}
+		tenantSplunk = &v1alpha1.AuditBackendSplunk{Enabled: true, Index: "tenant-a"}
+		prodSplunk   = &v1alpha1.AuditBackendSplunk{Enabled: true, Index: "production"}
+
+		globalResources = &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")}}
+		prodResources   = &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}}
+
+		cfg = config.ControllerConfiguration{
+			DefaultBackends: &v1alpha1.AuditBackends{
+				Log:    &v1alpha1.AuditBackendLog{Enabled: true},
+				Splunk: globalSplunk,
+			},
+			DefaultResources: globalResources,
+			DefaultProfiles: []config.DefaultProfile{
+				{
+					Name:      "production",
+					Selector:  config.DefaultProfileSelector{Purposes: []string{"production", "infrastructure"}},
+					Backends:  &v1alpha1.AuditBackends{Splunk: prodSplunk},
+					Resources: prodResources,
+				},
+				{
+					Name: "tenant-a",
+					Selector: config.DefaultProfileSelector{
+						ProjectNamespaces: []string{"garden-tenant-a"},
+					},
+					Backends: &v1alpha1.AuditBackends{Splunk: tenantSplunk},
+				},
+				{
+					Name: "no-log-for-evaluation",
+					Selector: config.DefaultProfileSelector{
+						Purposes:    []string{"evaluation"},
+						ShootLabels: &metav1.LabelSelector{MatchLabels: map[string]string{"audit.metal-stack.io/log": "false"}},
+					},
+					Backends: &v1alpha1.AuditBackends{Log: &v1alpha1.AuditBackendLog{Enabled: false}},
+				},
+			},
+		}
+	)
+
+	cluster := func(namespace string, purpose *v1beta1.ShootPurpose, labels map[string]string) *extensions.Cluster {
+		return &extensions.Cluster{
+			Shoot: &v1beta1.Shoot{
+				ObjectMeta: metav1.ObjectMeta{Name: "shoot", Namespace: namespace, Labels: labels},
+				Spec:       v1beta1.ShootSpec{Purpose: purpose},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		cluster       *extensions.Cluster
+		wantProfiles  []string
+		wantLog       bool
+		wantSplunk    *v1alpha1.AuditBackendSplunk
+		wantResources *corev1.ResourceRequirements
+	}{
+		{
+			name:          "no profile matches",
+			cluster:       cluster("garden-other", pointer.Pointer(v1beta1.ShootPurposeDevelopment), nil),
+			wantLog:       true,
+			wantSplunk:    globalSplunk,
+			wantResources: globalResources,
+		},
+		{
+			name:          "purpose",
+			cluster:       cluster("garden-other", pointer.Pointer(v1beta1.ShootPurposeInfrastructure), nil),
+			wantProfiles:  []string{"production"},
+			wantLog:       true,
+			wantSplunk:    prodSplunk,
+			wantResources: prodResources,
+		},
+		{
+			name:          "project namespace",
+			cluster:       cluster("garden-tenant-a", pointer.Pointer(v1beta1.ShootPurposeDevelopment), nil),
+			wantProfiles:  []string{"tenant-a"},
+			wantLog:       true,
+			wantSplunk:    tenantSplunk,
+			wantResources: globalResources,
+		},
+		{
+			name:          "later profiles take precedence for the backends they define",
+			cluster:       cluster("garden-tenant-a", pointer.Pointer(v1beta1.ShootPurposeProduction), nil),
+			wantProfiles:  []string{"production", "tenant-a"},
+			wantLog:       true,
+			wantSplunk:    tenantSplunk,
+			wantResources: prodResources,
+		},
+		{
+			name:          "shoot labels and missing purpose defaulting to evaluation",
+			cluster:       cluster("garden-other", nil, map[string]string{"audit.metal-stack.io/log": "false"}),
+			wantProfiles:  []string{"no-log-for-evaluation"},
+			wantLog:       false,
+			wantSplunk:    globalSplunk,
+			wantResources: globalResources,
+		},
+		{
+			name:          "shoot labels do not match",
+			cluster:       cluster("garden-other", nil, map[string]string{"audit.metal-stack.io/log": "true"}),
+			wantLog:       true,
+			wantSplunk:    globalSplunk,
+			wantResources: globalResources,
+		},
+		{
+			name:          "unknown shoot",
+			cluster:       &extensions.Cluster{},
+			wantLog:       true,
+			wantSplunk:    globalSplunk,
+			wantResources: globalResources,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveDefaults(cfg, tt.cluster.Shoot)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantProfiles, got.Profiles)
+			require.NotNil(t, got.Backends)
+			assert.Equal(t, tt.wantLog, pointer.SafeDeref(got.Backends.Log).Enabled)
+			assert.Equal(t, tt.wantSplunk, got.Backends.Splunk)
+			assert.Equal(t, tt.wantResources, got.Resources)
+		})
+	}
+}
+
+func TestResolveDefaults_NoDefaults(t *testing.T) {
+	got, err := ResolveDefaults(config.ControllerConfiguration{
+		DefaultProfiles: []config.DefaultProfile{
+			{Name: "resources-only", Resources: &corev1.ResourceRequirements{}},
+		},
+	}, &v1beta1.Shoot{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"resources-only"}, got.Profiles)
+	assert.Nil(t, got.Backends, "backends are not defaulted if neither the defaults nor the profiles define backends")
+	assert.NotNil(t, got.Resources)
+}
+
+func TestResolveDefaults_DoesNotModifyConfig(t *testing.T) {
+	cfg := config.ControllerConfiguration{
+		DefaultBackends: &v1alpha1.AuditBackends{Log: &v1alpha1.AuditBackendLog{Enabled: true}},
+		DefaultProfiles: []config.DefaultProfile{
+			{Name: "all", Backends: &v1alpha1.AuditBackends{Log: &v1alpha1.AuditBackendLog{Enabled: false}}},
+		},
+	}
+
+	got, err := ResolveDefaults(cfg, &v1beta1.Shoot{})
+	require.NoError(t, err)
+	assert.False(t, got.Backends.Log.Enabled)
+	assert.True(t, cfg.DefaultBackends.Log.Enabled)
+}
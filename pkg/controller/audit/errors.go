@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"context"
+	"slices"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1helper "github.com/gardener/gardener/pkg/apis/core/v1beta1/helper"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// EventReasonConfigurationProblem is the reason of the events emitted for errors in the audit configuration of the user
+	EventReasonConfigurationProblem = "ConfigurationProblem"
+	// EventReasonReconcileFailed is the reason of the events emitted for all other errors
+	EventReasonReconcileFailed = "ReconcileFailed"
+	// EventReasonQuestionableConfiguration is the reason of the events emitted for audit configurations of the user that
+	// are valid but likely not intended
+	EventReasonQuestionableConfiguration = "QuestionableConfiguration"
+)
+
+// configurationProblem marks an error that is caused by the audit configuration of the user. gardener reports the
+// error with the ERR_CONFIGURATION_PROBLEM code in the shoot status, which tells the user to fix the configuration.
+func configurationProblem(err error) error {
+	return v1beta1helper.NewErrorWithCodes(err, gardencorev1beta1.ErrorConfigurationProblem)
+}
+
+// withErrorCode returns the error with the ERR_RETRYABLE_INFRA_DEPENDENCIES code if it does not carry an error code
+// yet, such that all errors that are not caused by the user are reported as infrastructure errors.
+func withErrorCode(err error) error {
+	if err == nil || len(v1beta1helper.ExtractErrorCodes(err)) > 0 {
+		return err
+	}
+
+	return v1beta1helper.NewErrorWithCodes(err, gardencorev1beta1.ErrorRetryableInfraDependencies)
+}
+
+// isConfigurationProblem returns true if the error is caused by the audit configuration of the user
+func isConfigurationProblem(err error) bool {
+	return slices.Contains(v1beta1helper.ExtractErrorCodes(err), gardencorev1beta1.ErrorConfigurationProblem)
+}
+
+// recordErrorEvent emits a warning event for a failed reconciliation on the extension and on the cluster resource of the
+// shoot. the shoot itself lives in the garden cluster, which the extension has no access to, so the event is emitted on
+// the cluster resource, which represents the shoot in the seed and is named after its namespace.
+func recordErrorEvent(ctx context.Context, c client.Client, recorder record.EventRecorder, ex *extensionsv1alpha1.Extension, err error) {
+	reason := EventReasonReconcileFailed
+	if isConfigurationProblem(err) {
+		reason = EventReasonConfigurationProblem
+	}
+
+	recorder.Event(ex, corev1.EventTypeWarning, reason, err.Error())
+
+	cluster := &extensionsv1alpha1.Cluster{}
+	if getErr := c.Get(ctx, client.ObjectKey{Name: ex.Namespace}, cluster); getErr != nil {
+		return
+	}
+
+	recorder.Event(cluster, corev1.EventTypeWarning, reason, err.Error())
+}
+
+// recordWarningEvents emits a warning event on the extension for every questionable part of the audit configuration,
+// such that the user notices them without access to the logs of the extension.
+func recordWarningEvents(recorder record.EventRecorder, ex *extensionsv1alpha1.Extension, warnings []string) {
+	for _, warning := range warnings {
+		recorder.Event(ex, corev1.EventTypeWarning, EventReasonQuestionableConfiguration, warning)
+	}
+}
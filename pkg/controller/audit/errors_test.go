@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1helper "github.com/gardener/gardener/pkg/apis/core/v1beta1/helper"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+)
+
+func TestWithErrorCode(t *testing.T) {
+	assert.NoError(t, withErrorCode(nil))
+
+	err := withErrorCode(errors.New("connection refused"))
+	assert.Equal(t, []gardencorev1beta1.ErrorCode{gardencorev1beta1.ErrorRetryableInfraDependencies}, v1beta1helper.ExtractErrorCodes(err))
+	assert.False(t, isConfigurationProblem(err))
+
+	err = withErrorCode(configurationProblem(errors.New("invalid config")))
+	assert.Equal(t, []gardencorev1beta1.ErrorCode{gardencorev1beta1.ErrorConfigurationProblem}, v1beta1helper.ExtractErrorCodes(err))
+	assert.True(t, isConfigurationProblem(err))
+	assert.EqualError(t, err, "invalid config")
+}
+
+func TestReconcile_Errors(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, extensionsv1alpha1.AddToScheme(scheme))
+	install.Install(scheme)
+
+	cluster := &extensionsv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "shoot--a"},
+		Spec: extensionsv1alpha1.ClusterSpec{
+			Shoot:        runtime.RawExtension{Raw: []byte("{}")},
+			Seed:         runtime.RawExtension{Raw: []byte("{}")},
+			CloudProfile: runtime.RawExtension{Raw: []byte("{}")},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		config         config.ControllerConfiguration
+		providerConfig string
+		wantErr        string
+		wantCode       gardencorev1beta1.ErrorCode
+		wantReason     string
+	}{
+		{
+			name:           "invalid provider config",
+			providerConfig: `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","unknown":true}`,
+			wantErr:        `failed to decode provider config`,
+			wantCode:       gardencorev1beta1.ErrorConfigurationProblem,
+			wantReason:     EventReasonConfigurationProblem,
+		},
+		{
+			name:           "invalid custom data",
+			providerConfig: `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","backends":{"splunk":{"enabled":true,"customData":{"a b":"c"}}}}`,
+			wantErr:        `failed to validate audit config`,
+			wantCode:       gardencorev1beta1.ErrorConfigurationProblem,
+			wantReason:     EventReasonConfigurationProblem,
+		},
+		{
+			name:           "splunk secret not referenced in the shoot",
+			providerConfig: `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","backends":{"splunk":{"enabled":true,"secretResourceName":"splunk"}}}`,
+			wantErr:        `secret resource with name "splunk" not found in shoot resources or default backend secrets`,
+			wantCode:       gardencorev1beta1.ErrorConfigurationProblem,
+			wantReason:     EventReasonConfigurationProblem,
+		},
+		{
+			name: "default backends can not be applied",
+			config: config.ControllerConfiguration{
+				DefaultBackends: &v1alpha1.AuditBackends{
+					Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, SecretResourceName: "splunk"},
+				},
+			},
+			wantErr:    `unable to apply default backends configured by operator: backend secret "splunk" is not configured in the controller configuration`,
+			wantCode:   gardencorev1beta1.ErrorRetryableInfraDependencies,
+			wantReason: EventReasonReconcileFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := &extensionsv1alpha1.Extension{
+				ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: "shoot--a"},
+			}
+			if tt.providerConfig != "" {
+				ex.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(tt.providerConfig)}
+			}
+
+			recorder := &objectRecorder{FakeRecorder: record.NewFakeRecorder(2)}
+			a := &actuator{
+				client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, ex).Build(),
+				decoder:  serializer.NewCodecFactory(scheme, serializer.EnableStrict).UniversalDecoder(),
+				config:   tt.config,
+				resolver: fakeResolver{},
+				recorder: recorder,
+			}
+
+			err := a.Reconcile(context.Background(), logr.Discard(), ex)
+			require.ErrorContains(t, err, tt.wantErr)
+			assert.Equal(t, []gardencorev1beta1.ErrorCode{tt.wantCode}, v1beta1helper.ExtractErrorCodes(err))
+
+			// the event is emitted on the extension and on the cluster resource, which represents the shoot in the seed
+			require.Len(t, recorder.Events, 2)
+			for range recorder.objects {
+				event := <-recorder.Events
+				assert.Contains(t, event, corev1.EventTypeWarning+" "+tt.wantReason+" ")
+				assert.Contains(t, event, tt.wantErr)
+			}
+			require.Len(t, recorder.objects, 2)
+			assert.IsType(t, &extensionsv1alpha1.Extension{}, recorder.objects[0])
+			assert.IsType(t, &extensionsv1alpha1.Cluster{}, recorder.objects[1])
+		})
+	}
+}
+
+func TestRecordWarningEvents(t *testing.T) {
+	ex := &extensionsv1alpha1.Extension{
+		ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: "shoot--a"},
+	}
+	warnings := persistenceWarnings(&v1alpha1.AuditConfig{
+		WebhookMode: v1alpha1.AuditWebhookModeBlockingStrict,
+		Persistence: v1alpha1.AuditPersistence{Type: v1alpha1.AuditPersistenceTypeEphemeral},
+	})
+	require.Len(t, warnings, 1)
+
+	recorder := &objectRecorder{FakeRecorder: record.NewFakeRecorder(1)}
+	recordWarningEvents(recorder, ex, warnings)
+
+	require.Len(t, recorder.Events, 1)
+	assert.Equal(t, corev1.EventTypeWarning+" "+EventReasonQuestionableConfiguration+" "+warnings[0], <-recorder.Events)
+	assert.Equal(t, []runtime.Object{ex}, recorder.objects)
+}
+
+// objectRecorder is a fake recorder that also records the objects of the events
+type objectRecorder struct {
+	*record.FakeRecorder
+	objects []runtime.Object
+}
+
+func (r *objectRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.objects = append(r.objects, object)
+	r.FakeRecorder.Event(object, eventtype, reason, message)
+}
@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	"github.com/gardener/gardener/pkg/extensions"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/fluentbitconfig"
+)
+
+// TestSeedObjects_FluentBitGolden compares the rendered fluent-bit configuration with the files in
+// testdata/fluentbit/<name>. The files are compared semantically, such that changes in the formatting of the
+// renderer do not require to touch them.
+func TestSeedObjects_FluentBitGolden(t *testing.T) {
+	splunk := func() *v1alpha1.AuditBackendSplunk {
+		return &v1alpha1.AuditBackendSplunk{
+			Enabled:              true,
+			Host:                 "splunk.example.com",
+			Port:                 "443",
+			Index:                "audit",
+			TlsEnabled:           true,
+			TlsHost:              "splunk.example.com",
+			FilesystemBufferSize: pointer.Pointer("500M"),
+			CustomData:           map[string]string{"cluster": "shoot", "environment": "test"},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		backends *v1alpha1.AuditBackends
+		enforced enforcedBackends
+		format   fluentbitconfig.Format
+	}{
+		{
+			name:     "no-backends",
+			backends: &v1alpha1.AuditBackends{},
+		},
+		{
+			name: "log",
+			backends: &v1alpha1.AuditBackends{
+				Log: &v1alpha1.AuditBackendLog{Enabled: true},
+			},
+		},
+		{
+			name: "cluster-forwarding",
+			backends: &v1alpha1.AuditBackends{
+				ClusterForwarding: &v1alpha1.AuditBackendClusterForwarding{Enabled: true, FilesystemBufferSize: pointer.Pointer("900M")},
+			},
+		},
+		{
+			name: "splunk",
+			backends: &v1alpha1.AuditBackends{
+				Splunk: splunk(),
+			},
+		},
+		{
+			name: "enforced",
+			backends: &v1alpha1.AuditBackends{
+				Log: &v1alpha1.AuditBackendLog{Enabled: true},
+			},
+			enforced: enforcedBackends{
+				backends: &v1alpha1.AuditBackends{
+					Log: &v1alpha1.AuditBackendLog{Enabled: true},
+					Splunk: &v1alpha1.AuditBackendSplunk{
+						Enabled: true,
+						Host:    "enforced.example.com",
+						Port:    "8088",
+						Index:   "operator",
+					},
+				},
+				splunkSecret: &corev1.Secret{Data: map[string][]byte{v1alpha1.SplunkSecretTokenKey: []byte("token")}},
+			},
+		},
+		{
+			name: "yaml",
+			backends: &v1alpha1.AuditBackends{
+				Log:    &v1alpha1.AuditBackendLog{Enabled: true},
+				Splunk: splunk(),
+			},
+			format: fluentbitconfig.FormatYAML,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditConfig := &v1alpha1.AuditConfig{
+				Backends: tt.backends,
+				Persistence: v1alpha1.AuditPersistence{
+					Size: &resource.Quantity{},
+				},
+			}
+			secrets := map[string]*corev1.Secret{
+				"audittailer-client": {ObjectMeta: metav1.ObjectMeta{Name: "audittailer-client"}},
+			}
+			splunkSecret := &corev1.Secret{Data: map[string][]byte{
+				v1alpha1.SplunkSecretTokenKey:  []byte("token"),
+				v1alpha1.SplunkSecretCaFileKey: []byte("ca"),
+			}}
+
+			objects, err := seedObjects(auditConfig, secrets, &extensions.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "shoot--project--name"}, Shoot: &v1beta1.Shoot{}}, splunkSecret, tt.enforced, tt.format, defaultInputSilencePeriod, "", "shoot--project--name")
+			require.NoError(t, err)
+
+			cm := findFluentbitConfigMap(objects)
+			require.NotNil(t, cm)
+
+			dir := filepath.Join("testdata", "fluentbit", tt.name)
+
+			golden, err := readFluentbitFiles(dir)
+			require.NoError(t, err)
+
+			diffs, err := golden.Diff(fluentbitconfig.Files(cm.Data))
+			require.NoError(t, err)
+
+			for _, d := range diffs {
+				t.Errorf("rendered fluent-bit configuration differs from %s:\n%s", dir, d)
+			}
+		})
+	}
+}
+
+// readFluentbitFiles reads the files of a fluent-bit configuration from the given directory
+func readFluentbitFiles(dir string) (fluentbitconfig.Files, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := fluentbitconfig.Files{}
+	for _, e := range entries {
+		content, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := files.Add(e.Name(), strings.TrimSuffix(string(content), "\n")); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,207 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	gardenerextensions "github.com/gardener/gardener/pkg/extensions"
+	"github.com/gardener/gardener/pkg/utils"
+	"github.com/gardener/gardener/pkg/utils/secrets"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-audit/pkg/metrics"
+)
+
+const (
+	backendLog               = "log"
+	backendClusterForwarding = "clusterforwarding"
+	backendSplunk            = "splunk"
+	// backendNone is used for metrics of shoots that do not have any backend enabled
+	backendNone = "none"
+
+	// enforcedBackendPrefix is the prefix of the names of the backends enforced by the operator
+	enforcedBackendPrefix = "enforced-"
+)
+
+// enabledBackends returns the names of the enabled backends, which are also used as aliases of the fluent-bit outputs
+func enabledBackends(backends *v1alpha1.AuditBackends) []string {
+	if backends == nil {
+		return nil
+	}
+
+	var result []string
+
+	if pointer.SafeDeref(backends.Log).Enabled {
+		result = append(result, backendLog)
+	}
+	if pointer.SafeDeref(backends.ClusterForwarding).Enabled {
+		result = append(result, backendClusterForwarding)
+	}
+	if pointer.SafeDeref(backends.Splunk).Enabled {
+		result = append(result, backendSplunk)
+	}
+
+	return result
+}
+
+// recordReconcile counts the outcome of a reconciliation for every enabled backend
+func recordReconcile(auditConfig *v1alpha1.AuditConfig, err error) {
+	result := metrics.ResultSuccess
+	if err != nil {
+		result = metrics.ResultError
+	}
+
+	backends := enabledBackends(auditConfig.Backends)
+	if len(backends) == 0 {
+		backends = []string{backendNone}
+	}
+
+	for _, backend := range backends {
+		metrics.Reconciles.WithLabelValues(backend, result).Inc()
+	}
+}
+
+// recordCertificateExpiry exports the expiry of the certificates generated for the audittailer
+func recordCertificateExpiry(namespace string, certificateSecrets map[string]*corev1.Secret) error {
+	for name, secret := range certificateSecrets {
+		data, ok := secret.Data[secrets.DataKeyCertificate]
+		if !ok {
+			data, ok = secret.Data[secrets.DataKeyCertificateCA]
+		}
+		if !ok {
+			continue
+		}
+
+		cert, err := utils.DecodeCertificate(data)
+		if err != nil {
+			return fmt.Errorf("unable to decode certificate %q: %w", name, err)
+		}
+
+		metrics.CertificateExpiry.WithLabelValues(namespace, name).Set(float64(cert.NotAfter.Unix()))
+	}
+
+	return nil
+}
+
+// shootCollector counts the shoots per enabled backend and webhook mode. the counts are calculated from the extensions
+// at the time of the scrape, such that they are also correct after a restart of the controller.
+type shootCollector struct {
+	client  client.Reader
+	decoder runtime.Decoder
+	config  config.ControllerConfiguration
+
+	shootsByBackend     *prometheus.Desc
+	shootsByWebhookMode *prometheus.Desc
+}
+
+func newShootCollector(c client.Reader, decoder runtime.Decoder, cfg config.ControllerConfiguration) *shootCollector {
+	return &shootCollector{
+		client:  c,
+		decoder: decoder,
+		config:  cfg,
+		shootsByBackend: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "", "shoots"),
+			"Number of shoots using an audit backend.",
+			[]string{metrics.LabelBackend}, nil,
+		),
+		shootsByWebhookMode: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "", "shoots_by_webhook_mode"),
+			"Number of shoots using an audit webhook mode.",
+			[]string{metrics.LabelWebhookMode}, nil,
+		),
+	}
+}
+
+func (c *shootCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.shootsByBackend
+	ch <- c.shootsByWebhookMode
+}
+
+func (c *shootCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	extensions := &extensionsv1alpha1.ExtensionList{}
+	if err := c.client.List(ctx, extensions); err != nil {
+		ch <- prometheus.NewInvalidMetric(c.shootsByBackend, fmt.Errorf("unable to list extensions: %w", err))
+		return
+	}
+
+	// the shoots are only needed to resolve the default profiles
+	shoots := map[string]*gardencorev1beta1.Shoot{}
+	if len(c.config.DefaultProfiles) > 0 {
+		clusters := &extensionsv1alpha1.ClusterList{}
+		if err := c.client.List(ctx, clusters); err != nil {
+			ch <- prometheus.NewInvalidMetric(c.shootsByBackend, fmt.Errorf("unable to list clusters: %w", err))
+			return
+		}
+
+		for i := range clusters.Items {
+			shoot, err := gardenerextensions.ShootFromCluster(&clusters.Items[i])
+			if err != nil {
+				continue
+			}
+			shoots[clusters.Items[i].Name] = shoot
+		}
+	}
+
+	var (
+		byBackend = map[string]int{
+			backendLog:               0,
+			backendClusterForwarding: 0,
+			backendSplunk:            0,
+			backendNone:              0,
+		}
+		byWebhookMode = map[v1alpha1.AuditWebhookMode]int{
+			v1alpha1.AuditWebhookModeBatch:          0,
+			v1alpha1.AuditWebhookModeBlocking:       0,
+			v1alpha1.AuditWebhookModeBlockingStrict: 0,
+		}
+	)
+
+	for _, ex := range extensions.Items {
+		if ex.Spec.Type != Type || ex.DeletionTimestamp != nil {
+			continue
+		}
+
+		auditConfig := &v1alpha1.AuditConfig{}
+		if ex.Spec.ProviderConfig != nil {
+			if _, _, err := c.decoder.Decode(ex.Spec.ProviderConfig.Raw, nil, auditConfig); err != nil {
+				// invalid configurations are reported by the reconciliation
+				continue
+			}
+		}
+
+		defaults, err := ResolveDefaults(c.config, shoots[ex.Namespace])
+		if err != nil {
+			continue
+		}
+
+		backends := enabledBackends(WithDefaultBackends(auditConfig.Backends, defaults.Backends))
+		if len(backends) == 0 {
+			backends = []string{backendNone}
+		}
+
+		for _, backend := range backends {
+			byBackend[backend]++
+		}
+
+		byWebhookMode[v1alpha1.EffectiveWebhookMode(auditConfig.WebhookMode)]++
+	}
+
+	for backend, count := range byBackend {
+		ch <- prometheus.MustNewConstMetric(c.shootsByBackend, prometheus.GaugeValue, float64(count), backend)
+	}
+	for mode, count := range byWebhookMode {
+		ch <- prometheus.MustNewConstMetric(c.shootsByWebhookMode, prometheus.GaugeValue, float64(count), string(mode))
+	}
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/utils/secrets"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-audit/pkg/metrics"
+)
+
+func TestRecordReconcile(t *testing.T) {
+	var (
+		splunkErrors = testutil.ToFloat64(metrics.Reconciles.WithLabelValues(backendSplunk, metrics.ResultError))
+		logErrors    = testutil.ToFloat64(metrics.Reconciles.WithLabelValues(backendLog, metrics.ResultError))
+		noneSuccess  = testutil.ToFloat64(metrics.Reconciles.WithLabelValues(backendNone, metrics.ResultSuccess))
+	)
+
+	recordReconcile(&v1alpha1.AuditConfig{
+		Backends: &v1alpha1.AuditBackends{
+			Log:    &v1alpha1.AuditBackendLog{Enabled: true},
+			Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true},
+		},
+	}, assert.AnError)
+	recordReconcile(&v1alpha1.AuditConfig{}, nil)
+
+	assert.Equal(t, splunkErrors+1, testutil.ToFloat64(metrics.Reconciles.WithLabelValues(backendSplunk, metrics.ResultError)))
+	assert.Equal(t, logErrors+1, testutil.ToFloat64(metrics.Reconciles.WithLabelValues(backendLog, metrics.ResultError)))
+	assert.Equal(t, noneSuccess+1, testutil.ToFloat64(metrics.Reconciles.WithLabelValues(backendNone, metrics.ResultSuccess)))
+}
+
+func TestRecordCertificateExpiry(t *testing.T) {
+	ca, err := (&secrets.CertificateSecretConfig{
+		Name:       "ca-audittailer",
+		CommonName: "ca-audittailer",
+		CertType:   secrets.CACert,
+	}).GenerateCertificate()
+	require.NoError(t, err)
+
+	server, err := (&secrets.CertificateSecretConfig{
+		Name:         "audittailer-server",
+		CommonName:   "audittailer",
+		CertType:     secrets.ServerCert,
+		SigningCA:    ca,
+		Validity:     pointer.Pointer(24 * time.Hour),
+		DNSNames:     []string{"audittailer"},
+		Organization: []string{"test"},
+	}).GenerateCertificate()
+	require.NoError(t, err)
+
+	err = recordCertificateExpiry("shoot--test--test", map[string]*corev1.Secret{
+		"ca-audittailer":     {Data: ca.SecretData()},
+		"audittailer-server": {Data: server.SecretData()},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(ca.Certificate.NotAfter.Unix()), testutil.ToFloat64(metrics.CertificateExpiry.WithLabelValues("shoot--test--test", "ca-audittailer")))
+	assert.Equal(t, float64(server.Certificate.NotAfter.Unix()), testutil.ToFloat64(metrics.CertificateExpiry.WithLabelValues("shoot--test--test", "audittailer-server")))
+
+	err = recordCertificateExpiry("shoot--test--test", map[string]*corev1.Secret{
+		"broken": {Data: map[string][]byte{secrets.DataKeyCertificate: []byte("not a certificate")}},
+	})
+	require.Error(t, err)
+}
+
+func TestShootCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, extensionsv1alpha1.AddToScheme(scheme))
+	install.Install(scheme)
+
+	extension := func(name, providerConfig string) *extensionsv1alpha1.Extension {
+		ex := &extensionsv1alpha1.Extension{
+			ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: name},
+			Spec: extensionsv1alpha1.ExtensionSpec{
+				DefaultSpec: extensionsv1alpha1.DefaultSpec{Type: Type},
+			},
+		}
+		if providerConfig != "" {
+			ex.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(providerConfig)}
+		}
+		return ex
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		extension("shoot-a", `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","webhookMode":"batch","backends":{"log":{"enabled":true},"splunk":{"enabled":true}}}`),
+		extension("shoot-b", `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","backends":{"log":{"enabled":false}}}`),
+		extension("shoot-c", `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","webhookMode":"blocking"}`),
+		extension("shoot-d", `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","webhookMode":"unknown"}`),
+		&extensionsv1alpha1.Extension{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "shoot-a"},
+			Spec:       extensionsv1alpha1.ExtensionSpec{DefaultSpec: extensionsv1alpha1.DefaultSpec{Type: "other"}},
+		},
+	).Build()
+
+	collector := newShootCollector(c, serializer.NewCodecFactory(scheme).UniversalDecoder(), config.ControllerConfiguration{
+		DefaultBackends: &v1alpha1.AuditBackends{
+			Log: &v1alpha1.AuditBackendLog{Enabled: true},
+		},
+	})
+
+	expected := `
+# HELP gardener_extension_audit_shoots Number of shoots using an audit backend.
+# TYPE gardener_extension_audit_shoots gauge
+gardener_extension_audit_shoots{backend="clusterforwarding"} 0
+gardener_extension_audit_shoots{backend="log"} 3
+gardener_extension_audit_shoots{backend="none"} 1
+gardener_extension_audit_shoots{backend="splunk"} 1
+# HELP gardener_extension_audit_shoots_by_webhook_mode Number of shoots using an audit webhook mode.
+# TYPE gardener_extension_audit_shoots_by_webhook_mode gauge
+gardener_extension_audit_shoots_by_webhook_mode{webhook_mode="batch"} 1
+gardener_extension_audit_shoots_by_webhook_mode{webhook_mode="blocking"} 1
+gardener_extension_audit_shoots_by_webhook_mode{webhook_mode="blocking-strict"} 2
+`
+
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+}
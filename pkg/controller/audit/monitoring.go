@@ -0,0 +1,177 @@
+package audit
+
+import (
+	_ "embed"
+	"time"
+
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	monitoringutils "github.com/gardener/gardener/pkg/component/observability/monitoring/utils"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+)
+
+const (
+	// shootPrometheus is the name of the prometheus in the shoot namespace, which picks up the monitoring configuration
+	shootPrometheus = "shoot"
+	// webhookBackendJob is the job of the audit webhook backend metrics, it defaults to the name of the scraped service
+	webhookBackendJob = "audit-webhook-backend"
+	// defaultInputSilencePeriod is the default duration without any received audit events after which the audit webhook
+	// backend is reported as failed
+	defaultInputSilencePeriod = 15 * time.Minute
+)
+
+var (
+	//go:embed dashboards/audit-webhook-backend.json
+	webhookBackendDashboard string
+
+	// webhookBackendMetrics are the fluent-bit metrics that are kept by the shoot prometheus, all others are dropped
+	webhookBackendMetrics = []string{
+		"fluentbit_input_records_total",
+		"fluentbit_input_bytes_total",
+		"fluentbit_output_proc_records_total",
+		"fluentbit_output_proc_bytes_total",
+		"fluentbit_output_errors_total",
+		"fluentbit_output_retries_total",
+		"fluentbit_output_retries_failed_total",
+		"fluentbit_output_dropped_records_total",
+		"fluentbit_output_chunk_available_capacity_percent",
+	}
+)
+
+// InputSilencePeriod returns the duration without any received audit events after which the audit webhook backend is
+// reported as failed, zero disables the detection. it is shared by the health check and the alerting rules.
+func InputSilencePeriod(cfg config.ControllerConfiguration) time.Duration {
+	// an explicit zero must not fall back to the default, so pointer.SafeDerefOrDefault can not be used here
+	silencePeriod := pointer.SafeDeref(cfg.InputHealth).SilencePeriod
+	if silencePeriod == nil {
+		return defaultInputSilencePeriod
+	}
+
+	return silencePeriod.Duration
+}
+
+// monitoringObjects returns the objects that integrate the audit webhook backend into the monitoring stack of the shoot
+// control plane, which is operated by the prometheus-operator in the shoot namespace
+func monitoringObjects(silencePeriod time.Duration, namespace string) []client.Object {
+	return []client.Object{
+		&monitoringv1.ServiceMonitor{
+			ObjectMeta: monitoringutils.ConfigObjectMeta("audit-webhook-backend", namespace, shootPrometheus),
+			Spec: monitoringv1.ServiceMonitorSpec{
+				Selector: metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"app": "audit-webhook-backend",
+					},
+				},
+				Endpoints: []monitoringv1.Endpoint{
+					{
+						Port:                 "api",
+						Path:                 "/api/v2/metrics/prometheus",
+						MetricRelabelConfigs: monitoringutils.StandardMetricRelabelConfig(webhookBackendMetrics...),
+					},
+				},
+			},
+		},
+		&monitoringv1.PrometheusRule{
+			ObjectMeta: monitoringutils.ConfigObjectMeta("audit-webhook-backend", namespace, shootPrometheus),
+			Spec: monitoringv1.PrometheusRuleSpec{
+				Groups: []monitoringv1.RuleGroup{
+					{
+						Name:  "audit-webhook-backend.rules",
+						Rules: webhookBackendRules(silencePeriod),
+					},
+				},
+			},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "audit-webhook-backend-dashboard",
+				Namespace: namespace,
+				Labels: map[string]string{
+					v1beta1constants.LabelPrefixMonitoringDashboard + shootPrometheus: "true",
+				},
+			},
+			Data: map[string]string{
+				"audit-webhook-backend.json": webhookBackendDashboard,
+			},
+		},
+	}
+}
+
+// webhookBackendRules returns the alerting rules of the audit webhook backend. the null output, which is only present
+// when no backend is enabled, is not alerted. the buffer thresholds correspond to the defaults of the buffer health check,
+// the missing input is alerted after the silence period of the input health check and not at all if it is disabled.
+func webhookBackendRules(silencePeriod time.Duration) []monitoringv1.Rule {
+	var (
+		selector = `job="` + webhookBackendJob + `"`
+		outputs  = selector + `,name!="null"`
+	)
+
+	rule := func(alert, expr, duration, severity, summary, description string) monitoringv1.Rule {
+		return monitoringv1.Rule{
+			Alert: alert,
+			Expr:  intstr.FromString(expr),
+			For:   pointer.Pointer(monitoringv1.Duration(duration)),
+			Labels: map[string]string{
+				"service":    webhookBackendJob,
+				"severity":   severity,
+				"type":       "seed",
+				"visibility": "operator",
+			},
+			Annotations: map[string]string{
+				"summary":     summary,
+				"description": description,
+			},
+		}
+	}
+
+	rules := []monitoringv1.Rule{
+		rule(
+			"AuditWebhookBackendOutputRetrying",
+			`sum by (pod, name) (rate(fluentbit_output_retries_total{`+outputs+`}[10m])) > 0`,
+			"30m", "warning",
+			"Audit backend retries sending audit events.",
+			"The output {{ $labels.name }} of pod {{ $labels.pod }} has been retrying to send audit events for 30 minutes, the events are buffered until the backend is reachable again.",
+		),
+		rule(
+			"AuditWebhookBackendOutputDroppingRecords",
+			`sum by (pod, name) (increase(fluentbit_output_dropped_records_total{`+outputs+`}[10m])) > 0`,
+			"1m", "critical",
+			"Audit backend drops audit events.",
+			"The output {{ $labels.name }} of pod {{ $labels.pod }} dropped audit events, which are lost.",
+		),
+		rule(
+			"AuditWebhookBackendBufferFillingUp",
+			`min by (pod, name) (fluentbit_output_chunk_available_capacity_percent{`+outputs+`}) < 30`,
+			"15m", "warning",
+			"Audit backend buffer is filling up.",
+			"The buffer of output {{ $labels.name }} of pod {{ $labels.pod }} is more than 70% full.",
+		),
+		rule(
+			"AuditWebhookBackendBufferFull",
+			`min by (pod, name) (fluentbit_output_chunk_available_capacity_percent{`+outputs+`}) < 10`,
+			"5m", "critical",
+			"Audit backend buffer is almost full.",
+			"The buffer of output {{ $labels.name }} of pod {{ $labels.pod }} is more than 90% full, audit events are dropped when it runs full.",
+		),
+	}
+
+	if silencePeriod > 0 {
+		period := model.Duration(silencePeriod).String()
+		rules = append(rules, rule(
+			"AuditWebhookBackendNoInput",
+			`sum(increase(fluentbit_input_records_total{`+selector+`}[`+period+`])) == 0`,
+			"1m", "warning",
+			"Audit backend does not receive audit events.",
+			"The audit webhook backend has not received any audit events from the kube-apiserver for "+period+".",
+		))
+	}
+
+	return rules
+}
@@ -0,0 +1,204 @@
+package audit
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	"github.com/gardener/gardener/pkg/extensions"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-audit/pkg/fluentbitconfig"
+)
+
+// fluentbitMetrics are the metrics exposed by fluent-bit 2.1 at /api/v2/metrics/prometheus for inputs and outputs
+var fluentbitMetrics = sets.New(
+	"fluentbit_uptime",
+	"fluentbit_build_info",
+	"fluentbit_input_bytes_total",
+	"fluentbit_input_records_total",
+	"fluentbit_output_proc_records_total",
+	"fluentbit_output_proc_bytes_total",
+	"fluentbit_output_errors_total",
+	"fluentbit_output_retries_total",
+	"fluentbit_output_retries_failed_total",
+	"fluentbit_output_dropped_records_total",
+	"fluentbit_output_retried_records_total",
+	"fluentbit_output_chunk_available_capacity_percent",
+	"fluentbit_output_upstream_total_connections",
+	"fluentbit_output_upstream_busy_connections",
+)
+
+var metricName = regexp.MustCompile(`\bfluentbit_[a-z_]+\b`)
+
+func TestWebhookBackendMetrics(t *testing.T) {
+	for _, m := range webhookBackendMetrics {
+		assert.Truef(t, fluentbitMetrics.Has(m), "%s is not a fluent-bit metric", m)
+	}
+}
+
+func TestWebhookBackendRules(t *testing.T) {
+	kept := sets.New(webhookBackendMetrics...)
+	alerts := sets.New[string]()
+
+	for _, rule := range webhookBackendRules(defaultInputSilencePeriod) {
+		t.Run(rule.Alert, func(t *testing.T) {
+			assert.False(t, alerts.Has(rule.Alert), "alert names must be unique")
+			alerts.Insert(rule.Alert)
+
+			names := metricName.FindAllString(rule.Expr.String(), -1)
+			require.NotEmpty(t, names, "rule does not refer to a fluent-bit metric")
+
+			for _, name := range names {
+				assert.Truef(t, fluentbitMetrics.Has(name), "%s is not a fluent-bit metric", name)
+				assert.Truef(t, kept.Has(name), "%s is dropped by the service monitor", name)
+			}
+
+			assert.Contains(t, rule.Expr.String(), `job="`+webhookBackendJob+`"`)
+			assert.Contains(t, []string{"info", "warning", "critical"}, rule.Labels["severity"])
+			assert.NotEmpty(t, rule.Annotations["summary"])
+			assert.NotEmpty(t, rule.Annotations["description"])
+			assert.NotNil(t, rule.For)
+		})
+	}
+}
+
+func TestWebhookBackendRules_InputSilencePeriod(t *testing.T) {
+	tests := []struct {
+		name            string
+		cfg             config.ControllerConfiguration
+		wantExpr        string
+		wantDescription string
+	}{
+		{
+			name:            "default silence period",
+			wantExpr:        `sum(increase(fluentbit_input_records_total{job="audit-webhook-backend"}[15m])) == 0`,
+			wantDescription: "The audit webhook backend has not received any audit events from the kube-apiserver for 15m.",
+		},
+		{
+			name: "configured silence period",
+			cfg: config.ControllerConfiguration{
+				InputHealth: &config.InputHealthConfiguration{SilencePeriod: &metav1.Duration{Duration: 90 * time.Minute}},
+			},
+			wantExpr:        `sum(increase(fluentbit_input_records_total{job="audit-webhook-backend"}[1h30m])) == 0`,
+			wantDescription: "The audit webhook backend has not received any audit events from the kube-apiserver for 1h30m.",
+		},
+		{
+			name: "disabled silence detection",
+			cfg: config.ControllerConfiguration{
+				InputHealth: &config.InputHealthConfiguration{SilencePeriod: &metav1.Duration{}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var noInput *monitoringv1.Rule
+			for _, rule := range webhookBackendRules(InputSilencePeriod(tt.cfg)) {
+				if rule.Alert == "AuditWebhookBackendNoInput" {
+					noInput = &rule
+				}
+			}
+
+			if tt.wantExpr == "" {
+				assert.Nil(t, noInput)
+				return
+			}
+
+			require.NotNil(t, noInput)
+			assert.Equal(t, tt.wantExpr, noInput.Expr.String())
+			assert.Equal(t, tt.wantDescription, noInput.Annotations["description"])
+		})
+	}
+}
+
+func TestWebhookBackendDashboard(t *testing.T) {
+	var dashboard struct {
+		Panels []struct {
+			Title   string `json:"title"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(webhookBackendDashboard), &dashboard))
+	require.NotEmpty(t, dashboard.Panels)
+
+	kept := sets.New(webhookBackendMetrics...)
+
+	for _, panel := range dashboard.Panels {
+		for _, target := range panel.Targets {
+			for _, name := range metricName.FindAllString(target.Expr, -1) {
+				assert.Truef(t, kept.Has(name), "%s in panel %q is dropped by the service monitor", name, panel.Title)
+			}
+		}
+	}
+}
+
+func TestSeedObjectsMonitoring(t *testing.T) {
+	auditConfig := &v1alpha1.AuditConfig{
+		Backends: &v1alpha1.AuditBackends{
+			Log: &v1alpha1.AuditBackendLog{Enabled: true},
+		},
+		Persistence: v1alpha1.AuditPersistence{
+			Size: &resource.Quantity{},
+		},
+	}
+
+	objects, err := seedObjects(auditConfig, map[string]*corev1.Secret{}, &extensions.Cluster{Shoot: &v1beta1.Shoot{}}, &corev1.Secret{}, enforcedBackends{}, fluentbitconfig.FormatClassic, defaultInputSilencePeriod, "", "shoot--test--test")
+	require.NoError(t, err)
+
+	var (
+		service        *corev1.Service
+		serviceMonitor *monitoringv1.ServiceMonitor
+		prometheusRule *monitoringv1.PrometheusRule
+		dashboard      *corev1.ConfigMap
+	)
+
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case *corev1.Service:
+			if o.Name == webhookBackendJob {
+				service = o
+			}
+		case *monitoringv1.ServiceMonitor:
+			serviceMonitor = o
+		case *monitoringv1.PrometheusRule:
+			prometheusRule = o
+		case *corev1.ConfigMap:
+			if o.Name == "audit-webhook-backend-dashboard" {
+				dashboard = o
+			}
+		}
+	}
+
+	require.NotNil(t, service)
+	require.NotNil(t, serviceMonitor)
+	require.NotNil(t, prometheusRule)
+	require.NotNil(t, dashboard)
+
+	assert.Equal(t, "shoot", serviceMonitor.Labels["prometheus"])
+	assert.Equal(t, "shoot", prometheusRule.Labels["prometheus"])
+	assert.Equal(t, "true", dashboard.Labels["dashboard.monitoring.gardener.cloud/shoot"])
+
+	for key, value := range serviceMonitor.Spec.Selector.MatchLabels {
+		assert.Equal(t, value, service.Labels[key], "service monitor does not select the service")
+	}
+
+	ports := sets.New[string]()
+	for _, port := range service.Spec.Ports {
+		ports.Insert(port.Name)
+	}
+	for _, endpoint := range serviceMonitor.Spec.Endpoints {
+		assert.True(t, ports.Has(endpoint.Port), "service does not expose the port %s", endpoint.Port)
+	}
+}
@@ -0,0 +1,287 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+)
+
+var update = flag.Bool("update", false, "updates the golden files in testdata instead of comparing against them")
+
+// TestObjects_Golden renders all objects of the shoot and the seed for a matrix of audit configs and compares them
+// with the files in testdata/objects. Run with -update to write the golden files after an intended change, e.g.
+//
+//	go test ./pkg/controller/audit -run TestObjects_Golden -update
+func TestObjects_Golden(t *testing.T) {
+	const namespace = "shoot--project--name"
+
+	var (
+		splunk = func(secretResourceName string) *v1alpha1.AuditBackendSplunk {
+			return &v1alpha1.AuditBackendSplunk{
+				Enabled:            true,
+				Host:               "splunk.example.com",
+				Port:               "443",
+				Index:              "audit",
+				SecretResourceName: secretResourceName,
+				TlsEnabled:         true,
+				TlsHost:            "splunk.example.com",
+			}
+		}
+		operatorSplunk = &v1alpha1.AuditBackendSplunk{
+			Enabled:            true,
+			Host:               "operator.example.com",
+			Port:               "8088",
+			Index:              "operator",
+			SecretResourceName: "operator-splunk",
+		}
+		operatorSecrets = []config.BackendSecret{
+			{Name: "operator-splunk", SecretRef: corev1.SecretReference{Name: "audit-operator-splunk", Namespace: "garden"}},
+		}
+	)
+
+	tests := []struct {
+		name       string
+		backends   *v1alpha1.AuditBackends
+		config     config.ControllerConfiguration
+		hibernated bool
+		configure  func(auditConfig *v1alpha1.AuditConfig)
+	}{
+		{
+			name:     "no-backends",
+			backends: &v1alpha1.AuditBackends{},
+		},
+		{
+			name: "log",
+			backends: &v1alpha1.AuditBackends{
+				Log: &v1alpha1.AuditBackendLog{Enabled: true},
+			},
+		},
+		{
+			name: "cluster-forwarding",
+			backends: &v1alpha1.AuditBackends{
+				ClusterForwarding: &v1alpha1.AuditBackendClusterForwarding{Enabled: true},
+			},
+		},
+		{
+			name: "splunk-tls-with-ca",
+			backends: &v1alpha1.AuditBackends{
+				Splunk: splunk("splunk"),
+			},
+		},
+		{
+			name: "splunk-tls-without-ca",
+			backends: &v1alpha1.AuditBackends{
+				Splunk: splunk("splunk-without-ca"),
+			},
+		},
+		{
+			name: "splunk-without-tls",
+			backends: &v1alpha1.AuditBackends{
+				Splunk: &v1alpha1.AuditBackendSplunk{
+					Enabled:            true,
+					Host:               "splunk.example.com",
+					Port:               "8088",
+					Index:              "audit",
+					SecretResourceName: "splunk-without-ca",
+					CustomData:         map[string]string{"cluster": "shoot"},
+				},
+			},
+		},
+		{
+			name: "all-backends",
+			backends: &v1alpha1.AuditBackends{
+				Log:               &v1alpha1.AuditBackendLog{Enabled: true},
+				ClusterForwarding: &v1alpha1.AuditBackendClusterForwarding{Enabled: true, FilesystemBufferSize: pointer.Pointer("500M")},
+				Splunk:            splunk("splunk"),
+			},
+		},
+		{
+			name: "hibernated",
+			backends: &v1alpha1.AuditBackends{
+				Log:               &v1alpha1.AuditBackendLog{Enabled: true},
+				ClusterForwarding: &v1alpha1.AuditBackendClusterForwarding{Enabled: true},
+			},
+			hibernated: true,
+		},
+		{
+			name: "ephemeral",
+			backends: &v1alpha1.AuditBackends{
+				Log: &v1alpha1.AuditBackendLog{Enabled: true},
+			},
+			configure: func(auditConfig *v1alpha1.AuditConfig) {
+				auditConfig.Persistence.Type = v1alpha1.AuditPersistenceTypeEphemeral
+				auditConfig.Replicas = pointer.Pointer(int32(3))
+			},
+		},
+		{
+			name: "default-backends",
+			config: config.ControllerConfiguration{
+				DefaultBackends: &v1alpha1.AuditBackends{
+					Log:    &v1alpha1.AuditBackendLog{Enabled: true},
+					Splunk: operatorSplunk,
+				},
+				BackendSecrets: operatorSecrets,
+			},
+		},
+		{
+			name: "default-backends-overridden",
+			backends: &v1alpha1.AuditBackends{
+				Log:    &v1alpha1.AuditBackendLog{Enabled: false},
+				Splunk: splunk("splunk"),
+			},
+			config: config.ControllerConfiguration{
+				DefaultBackends: &v1alpha1.AuditBackends{
+					Log:    &v1alpha1.AuditBackendLog{Enabled: true},
+					Splunk: operatorSplunk,
+				},
+				BackendSecrets: operatorSecrets,
+			},
+		},
+		{
+			name: "enforced-backends",
+			backends: &v1alpha1.AuditBackends{
+				Log: &v1alpha1.AuditBackendLog{Enabled: true},
+			},
+			config: config.ControllerConfiguration{
+				EnforcedBackends: &v1alpha1.AuditBackends{
+					Splunk: operatorSplunk,
+				},
+				BackendSecrets: operatorSecrets,
+			},
+		},
+		{
+			name: "yaml-format",
+			backends: &v1alpha1.AuditBackends{
+				Log: &v1alpha1.AuditBackendLog{Enabled: true},
+				Splunk: &v1alpha1.AuditBackendSplunk{
+					Enabled:            true,
+					Host:               "splunk.example.com",
+					Port:               "443",
+					Index:              "audit",
+					SecretResourceName: "splunk",
+					TlsEnabled:         true,
+					CustomData:         map[string]string{"cluster": "shoot"},
+				},
+			},
+			config: config.ControllerConfiguration{
+				FluentBitConfigFormat: "yaml",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditConfig := &v1alpha1.AuditConfig{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: v1alpha1.SchemeGroupVersion.String(),
+					Kind:       "AuditConfig",
+				},
+				Backends: tt.backends,
+			}
+			if tt.configure != nil {
+				tt.configure(auditConfig)
+			}
+
+			c := goldenClient(t, namespace, tt.hibernated)
+
+			providerConfig, err := json.Marshal(auditConfig)
+			require.NoError(t, err)
+
+			manifests, err := Render(context.Background(), logr.Discard(), c, tt.config, &extensionsv1alpha1.Extension{
+				ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: namespace},
+				Spec: extensionsv1alpha1.ExtensionSpec{
+					DefaultSpec: extensionsv1alpha1.DefaultSpec{
+						Type:           Type,
+						ProviderConfig: &runtime.RawExtension{Raw: providerConfig},
+					},
+				},
+			})
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", "objects", tt.name+".yaml")
+
+			if *update {
+				require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0755))
+				require.NoError(t, os.WriteFile(golden, manifests.Bytes(), 0644))
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err, "run with -update to create the golden file")
+
+			assert.Equal(t, string(want), string(manifests.Bytes()), "rendered objects differ from %s, run with -update if the change is intended", golden)
+		})
+	}
+}
+
+// goldenClient returns a client with the cluster of the shoot and the secrets referenced by the backends of the
+// golden tests
+func goldenClient(t *testing.T, namespace string, hibernated bool) client.Client {
+	scheme := runtime.NewScheme()
+	require.NoError(t, extensionsv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	install.Install(scheme)
+
+	shoot := &gardencorev1beta1.Shoot{
+		ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "garden-project"},
+		Spec: gardencorev1beta1.ShootSpec{
+			Hibernation: &gardencorev1beta1.Hibernation{Enabled: &hibernated},
+			Resources: []gardencorev1beta1.NamedResourceReference{
+				{Name: "splunk", ResourceRef: autoscalingv1.CrossVersionObjectReference{Kind: "Secret", Name: "splunk-secret", APIVersion: "v1"}},
+				{Name: "splunk-without-ca", ResourceRef: autoscalingv1.CrossVersionObjectReference{Kind: "Secret", Name: "splunk-secret-without-ca", APIVersion: "v1"}},
+			},
+		},
+		Status: gardencorev1beta1.ShootStatus{IsHibernated: hibernated},
+	}
+	shootJSON, err := json.Marshal(shoot)
+	require.NoError(t, err)
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&extensionsv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: namespace},
+			Spec: extensionsv1alpha1.ClusterSpec{
+				Shoot:        runtime.RawExtension{Raw: shootJSON},
+				Seed:         runtime.RawExtension{Raw: []byte("{}")},
+				CloudProfile: runtime.RawExtension{Raw: []byte("{}")},
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ref-splunk-secret", Namespace: namespace},
+			Data: map[string][]byte{
+				v1alpha1.SplunkSecretTokenKey:  []byte("token"),
+				v1alpha1.SplunkSecretCaFileKey: []byte("ca"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ref-splunk-secret-without-ca", Namespace: namespace},
+			Data: map[string][]byte{
+				v1alpha1.SplunkSecretTokenKey: []byte("token"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "audit-operator-splunk", Namespace: "garden"},
+			Data: map[string][]byte{
+				v1alpha1.SplunkSecretTokenKey: []byte("operator-token"),
+			},
+		},
+	).Build()
+}
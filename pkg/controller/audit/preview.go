@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-audit/pkg/fluentbitconfig"
+)
+
+// PreviewFluentBitConfig returns the files of the fluent-bit configuration that this version of the extension would
+// deploy for the given extension with the given controller configuration. It only reads from the seed, so it can be
+// used to review the changes of a rollout before it happens.
+func PreviewFluentBitConfig(ctx context.Context, log logr.Logger, c client.Client, config config.ControllerConfiguration, ex *extensionsv1alpha1.Extension) (fluentbitconfig.Files, error) {
+	a := newReadOnlyActuator(c, config)
+
+	auditConfig := &v1alpha1.AuditConfig{}
+
+	state, err := a.desiredState(ctx, log, ex, auditConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// the certificates of the cluster forwarding are not part of the fluent-bit configuration, so they are not generated
+	objects, err := seedObjects(auditConfig, placeholderCertificates(), state.cluster, state.splunkSecret, state.enforced, fluentbitconfig.Format(config.FluentBitConfigFormat), InputSilencePeriod(config), "", ex.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	cm := findFluentbitConfigMap(objects)
+	if cm == nil {
+		return nil, fmt.Errorf("config map %q is not part of the seed objects", fluentbitConfigMapName)
+	}
+
+	return fluentbitconfig.Files(cm.Data), nil
+}
+
+// DeployedFluentBitConfig returns the files of the fluent-bit configuration that is currently deployed in the given
+// shoot namespace, it returns no files if the configuration was not deployed yet.
+func DeployedFluentBitConfig(ctx context.Context, c client.Client, namespace string) (fluentbitconfig.Files, error) {
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: fluentbitConfigMapName}, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fluentbitconfig.Files{}, nil
+		}
+
+		return nil, err
+	}
+
+	return fluentbitconfig.Files(cm.Data), nil
+}
+
+// DiffFluentBitConfig returns the changes of the fluent-bit configuration of the given extension that a rollout of
+// this version of the extension with the given controller configuration would cause.
+func DiffFluentBitConfig(ctx context.Context, log logr.Logger, c client.Client, config config.ControllerConfiguration, ex *extensionsv1alpha1.Extension) ([]fluentbitconfig.FileDiff, error) {
+	deployed, err := DeployedFluentBitConfig(ctx, c, ex.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get deployed fluent-bit configuration: %w", err)
+	}
+
+	preview, err := PreviewFluentBitConfig(ctx, log, c, config, ex)
+	if err != nil {
+		return nil, fmt.Errorf("unable to render fluent-bit configuration: %w", err)
+	}
+
+	return deployed.Diff(preview)
+}
+
+// findFluentbitConfigMap returns the config map with the fluent-bit configuration contained in the seed objects
+func findFluentbitConfigMap(objects []client.Object) *corev1.ConfigMap {
+	for _, obj := range objects {
+		if cm, ok := obj.(*corev1.ConfigMap); ok && cm.Name == fluentbitConfigMapName {
+			return cm
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-audit/pkg/fluentbitconfig"
+)
+
+func TestDiffFluentBitConfig(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, extensionsv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	install.Install(scheme)
+
+	var (
+		ctx     = context.Background()
+		cluster = &extensionsv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "shoot--a"},
+			Spec: extensionsv1alpha1.ClusterSpec{
+				Shoot:        runtime.RawExtension{Raw: []byte("{}")},
+				Seed:         runtime.RawExtension{Raw: []byte("{}")},
+				CloudProfile: runtime.RawExtension{Raw: []byte("{}")},
+			},
+		}
+		ex = &extensionsv1alpha1.Extension{
+			ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: "shoot--a"},
+			Spec: extensionsv1alpha1.ExtensionSpec{
+				DefaultSpec: extensionsv1alpha1.DefaultSpec{
+					ProviderConfig: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","backends":{"log":{"enabled":true}}}`)},
+				},
+			},
+		}
+	)
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, ex).Build()
+
+	t.Run("not deployed yet", func(t *testing.T) {
+		diffs, err := DiffFluentBitConfig(ctx, logr.Discard(), c, config.ControllerConfiguration{}, ex)
+		require.NoError(t, err)
+
+		var added []string
+		for _, d := range diffs {
+			assert.True(t, d.Added, "%s is added", d.Name)
+			added = append(added, d.Name)
+		}
+		assert.Equal(t, []string{"fluent-bit.conf", "log.backend.conf", "null.backend.conf", "parsers.conf"}, added)
+	})
+
+	deployed, err := PreviewFluentBitConfig(ctx, logr.Discard(), c, config.ControllerConfiguration{}, ex)
+	require.NoError(t, err)
+
+	// simulates a deployment by a previous version, which did not reference the parsers
+	main, err := fluentbitconfig.Parse(deployed["fluent-bit.conf"])
+	require.NoError(t, err)
+	for i, e := range main.Sections[0].Entries {
+		if e.Key == "parsers_file" {
+			main.Sections[0].Entries = append(main.Sections[0].Entries[:i], main.Sections[0].Entries[i+1:]...)
+			break
+		}
+	}
+	deployed["fluent-bit.conf"], err = main.Generate()
+	require.NoError(t, err)
+	delete(deployed, "parsers.conf")
+
+	require.NoError(t, c.Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "audit-fluent-bit-config", Namespace: "shoot--a"},
+		Data:       deployed,
+	}))
+
+	t.Run("changes of a new version", func(t *testing.T) {
+		diffs, err := DiffFluentBitConfig(ctx, logr.Discard(), c, config.ControllerConfiguration{}, ex)
+		require.NoError(t, err)
+
+		var got []string
+		for _, d := range diffs {
+			got = append(got, d.String())
+		}
+
+		assert.Equal(t, []string{
+			"~ fluent-bit.conf\n    + service: parsers_file: \"/config/parsers.conf\"",
+			"+ parsers.conf",
+		}, got)
+	})
+
+	t.Run("changes of the format", func(t *testing.T) {
+		diffs, err := DiffFluentBitConfig(ctx, logr.Discard(), c, config.ControllerConfiguration{FluentBitConfigFormat: "yaml"}, ex)
+		require.NoError(t, err)
+
+		var got []string
+		for _, d := range diffs {
+			got = append(got, d.String())
+		}
+
+		assert.Equal(t, []string{
+			"- fluent-bit.conf",
+			"+ fluent-bit.yaml",
+			"- log.backend.conf",
+			"+ log.backend.yaml",
+			"- null.backend.conf",
+			"+ null.backend.yaml",
+			"+ parsers.conf",
+		}, got)
+	})
+}
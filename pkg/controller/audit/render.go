@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	gutil "github.com/gardener/gardener/pkg/utils/gardener"
+	secretsutils "github.com/gardener/gardener/pkg/utils/secrets"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+)
+
+// placeholderCertificate is the content of the certificates that are generated by the secrets manager during
+// reconciliation and can therefore not be rendered
+const placeholderCertificate = "<generated during reconciliation>"
+
+// Manifests are the serialized objects of the managed resources of the audit extension, keyed by their file name
+type Manifests struct {
+	// Shoot are the manifests of the managed resource deployed into the shoot
+	Shoot map[string][]byte
+	// Seed are the manifests of the managed resource deployed into the shoot namespace of the seed
+	Seed map[string][]byte
+}
+
+// Bytes returns the manifests of the seed and the shoot sorted by their file name as a multi document yaml. Every
+// document is preceded by a comment with the managed resource and the file name it belongs to.
+func (m *Manifests) Bytes() []byte {
+	var buf bytes.Buffer
+
+	for _, mr := range []struct {
+		name      string
+		manifests map[string][]byte
+	}{
+		{name: v1alpha1.SeedAuditResourceName, manifests: m.Seed},
+		{name: v1alpha1.ShootAuditResourceName, manifests: m.Shoot},
+	} {
+		var names []string
+		for name := range mr.manifests {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Fprintf(&buf, "---\n# Source: %s/%s\n", mr.name, name)
+			buf.Write(mr.manifests[name])
+			if !bytes.HasSuffix(mr.manifests[name], []byte("\n")) {
+				buf.WriteString("\n")
+			}
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// Render returns the manifests that this version of the extension deploys for the given extension with the given
+// controller configuration. It only reads the cluster of the extension and the secrets referenced by its backends from
+// the client, such that the manifests can also be rendered from a fake client without access to a seed. The
+// certificates of the cluster forwarding and the addresses of the backend hosts are replaced by placeholders.
+func Render(ctx context.Context, log logr.Logger, c client.Client, config config.ControllerConfiguration, ex *extensionsv1alpha1.Extension) (*Manifests, error) {
+	a := newReadOnlyActuator(c, config)
+
+	auditConfig := &v1alpha1.AuditConfig{}
+
+	state, err := a.desiredState(ctx, log, ex, auditConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	shootAccessSecret := gutil.NewShootAccessSecret(clusterForwardingAccessSecretName, ex.Namespace)
+
+	manifests, _, err := a.renderManifests(ctx, auditConfig, state.cluster, state.splunkSecret, state.enforced, placeholderCertificates(), shootAccessSecret.Secret.Name, ex.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return manifests, nil
+}
+
+// newReadOnlyActuator returns an actuator that can be used to render the resources of an extension, it must not be
+// used for reconciliations as it has no event recorder. it has no resolver either, such that rendering does not depend
+// on the DNS records of the backends at the time of rendering.
+func newReadOnlyActuator(c client.Client, config config.ControllerConfiguration) *actuator {
+	return &actuator{
+		client:  c,
+		decoder: serializer.NewCodecFactory(c.Scheme(), serializer.EnableStrict).UniversalDecoder(),
+		config:  config,
+	}
+}
+
+// placeholderCertificates returns the secrets of the cluster forwarding with placeholders instead of the certificates
+// that are generated during reconciliation
+func placeholderCertificates() map[string]*corev1.Secret {
+	secrets := map[string]*corev1.Secret{}
+
+	for _, name := range []string{"audittailer-server", "audittailer-client"} {
+		secrets[name] = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Data: map[string][]byte{
+				secretsutils.DataKeyCertificateCA: []byte(placeholderCertificate),
+				secretsutils.DataKeyCertificate:   []byte(placeholderCertificate),
+				secretsutils.DataKeyPrivateKey:    []byte(placeholderCertificate),
+			},
+		}
+	}
+
+	return secrets
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+)
+
+func TestRender(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, extensionsv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	install.Install(scheme)
+
+	var (
+		ctx     = context.Background()
+		cluster = &extensionsv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "shoot--a"},
+			Spec: extensionsv1alpha1.ClusterSpec{
+				Shoot:        runtime.RawExtension{Raw: []byte("{}")},
+				Seed:         runtime.RawExtension{Raw: []byte("{}")},
+				CloudProfile: runtime.RawExtension{Raw: []byte("{}")},
+			},
+		}
+		extension = func(providerConfig string) *extensionsv1alpha1.Extension {
+			return &extensionsv1alpha1.Extension{
+				ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: "shoot--a"},
+				Spec: extensionsv1alpha1.ExtensionSpec{
+					DefaultSpec: extensionsv1alpha1.DefaultSpec{
+						ProviderConfig: &runtime.RawExtension{Raw: []byte(providerConfig)},
+					},
+				},
+			}
+		}
+	)
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+
+	t.Run("cluster forwarding", func(t *testing.T) {
+		manifests, err := Render(ctx, logr.Discard(), c, config.ControllerConfiguration{}, extension(`{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","backends":{"clusterForwarding":{"enabled":true}}}`))
+		require.NoError(t, err)
+
+		require.Contains(t, manifests.Seed, "configmap__shoot--a__audit-fluent-bit-config.yaml")
+		assert.Contains(t, string(manifests.Seed["configmap__shoot--a__audit-fluent-bit-config.yaml"]), "clusterforwarding.backend.conf")
+		assert.Contains(t, manifests.Seed, "deployment__shoot--a__audit-cluster-forwarding-vpn-gateway.yaml")
+
+		require.Contains(t, manifests.Shoot, "secret__audit__audittailer-server.yaml")
+		assert.NotContains(t, string(manifests.Shoot["secret__audit__audittailer-server.yaml"]), "BEGIN CERTIFICATE")
+	})
+
+	t.Run("without cluster forwarding", func(t *testing.T) {
+		manifests, err := Render(ctx, logr.Discard(), c, config.ControllerConfiguration{}, extension(`{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","backends":{"log":{"enabled":true}}}`))
+		require.NoError(t, err)
+
+		assert.Empty(t, manifests.Shoot)
+		assert.Contains(t, manifests.Seed, "statefulset__shoot--a__audit-webhook-backend.yaml")
+	})
+
+	t.Run("invalid audit config", func(t *testing.T) {
+		_, err := Render(ctx, logr.Discard(), c, config.ControllerConfiguration{}, extension(`{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","backends":{"splunk":{"enabled":true,"customData":{"a b":"c"}}}}`))
+		require.ErrorContains(t, err, `"a b" is not a valid customData key for splunk`)
+	})
+
+	t.Run("unknown persistence type", func(t *testing.T) {
+		// an unknown type must not fall back to any persistence type, as the volumes are deleted in ephemeral mode
+		_, err := Render(ctx, logr.Discard(), c, config.ControllerConfiguration{}, extension(`{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","persistence":{"type":"Ephemeral"}}`))
+		require.ErrorContains(t, err, `persistence.type: Unsupported value: "Ephemeral"`)
+	})
+
+	t.Run("backend policy without name resolution", func(t *testing.T) {
+		shoot := &extensionsv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "shoot--b"},
+			Spec: extensionsv1alpha1.ClusterSpec{
+				Shoot:        runtime.RawExtension{Raw: []byte(`{"spec":{"resources":[{"name":"splunk","resourceRef":{"apiVersion":"v1","kind":"Secret","name":"splunk"}}]}}`)},
+				Seed:         runtime.RawExtension{Raw: []byte("{}")},
+				CloudProfile: runtime.RawExtension{Raw: []byte("{}")},
+			},
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ref-splunk", Namespace: "shoot--b"},
+			Data:       map[string][]byte{"token": []byte("token")},
+		}
+		ex := extension(`{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","backends":{"splunk":{"enabled":true,"host":"splunk.invalid","port":"8088","secretResourceName":"splunk"}}}`)
+		ex.Namespace = "shoot--b"
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(shoot, secret).Build()
+
+		// the .invalid top level domain never resolves, rendering must therefore not look up the hosts
+		manifests, err := Render(ctx, logr.Discard(), c, config.ControllerConfiguration{
+			BackendPolicy: &config.BackendPolicy{
+				AllowedHosts: []string{"*.invalid"},
+				AllowedCIDRs: []string{"203.0.113.0/24"},
+			},
+		}, ex)
+		require.NoError(t, err)
+
+		policy := manifests.Seed["networkpolicy__shoot--b__egress-from-audit-webhook-backend-to-backends.yaml"]
+		require.NotEmpty(t, policy)
+		assert.Contains(t, string(policy), "cidr: <resolved during reconciliation>")
+	})
+
+	t.Run("token exfiltration", func(t *testing.T) {
+		// fluent-bit expands environment variables in values, so the index would contain the token of the enforced backend
+		_, err := Render(ctx, logr.Discard(), c, config.ControllerConfiguration{}, extension(`{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","backends":{"splunk":{"enabled":true,"host":"splunk.example.com","index":"${ENFORCED_SPLUNK_HEC_TOKEN}"}}}`))
+		require.ErrorContains(t, err, `backends.splunk.index: Invalid value: "${ENFORCED_SPLUNK_HEC_TOKEN}": must not reference environment variables`)
+	})
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/utils"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+)
+
+// effectiveConfiguration describes the configuration applied to the shoot. backends that are enabled but not configured
+// by the user are defaults of the operator. the settings of the enforced backends are not revealed to the user.
+func effectiveConfiguration(auditConfig *v1alpha1.AuditConfig, userBackends, enforced *v1alpha1.AuditBackends, profiles []string, configChecksum string) *v1alpha1.EffectiveConfiguration {
+	var (
+		backends = pointer.SafeDeref(auditConfig.Backends)
+		user     = pointer.SafeDeref(userBackends)
+
+		configuration = &v1alpha1.EffectiveConfiguration{
+			WebhookMode:     v1alpha1.EffectiveWebhookMode(auditConfig.WebhookMode),
+			DefaultProfiles: profiles,
+			ConfigChecksum:  configChecksum,
+		}
+
+		origin = func(userConfigured bool) v1alpha1.BackendOrigin {
+			if userConfigured {
+				return v1alpha1.BackendOriginUser
+			}
+			return v1alpha1.BackendOriginDefault
+		}
+	)
+
+	if pointer.SafeDeref(backends.Log).Enabled {
+		configuration.Backends = append(configuration.Backends, v1alpha1.EffectiveBackend{
+			Name:   backendLog,
+			Type:   v1alpha1.BackendTypeLog,
+			Origin: origin(user.Log != nil),
+		})
+	}
+	if pointer.SafeDeref(backends.ClusterForwarding).Enabled {
+		configuration.Backends = append(configuration.Backends, v1alpha1.EffectiveBackend{
+			Name:   backendClusterForwarding,
+			Type:   v1alpha1.BackendTypeClusterForwarding,
+			Origin: origin(user.ClusterForwarding != nil),
+		})
+	}
+	if splunk := backends.Splunk; pointer.SafeDeref(splunk).Enabled {
+		backend := v1alpha1.EffectiveBackend{
+			Name:   backendSplunk,
+			Type:   v1alpha1.BackendTypeSplunk,
+			Origin: origin(user.Splunk != nil),
+			Splunk: &v1alpha1.EffectiveSplunkBackend{
+				Host:       splunk.Host,
+				Port:       splunk.Port,
+				Index:      splunk.Index,
+				TlsEnabled: splunk.TlsEnabled,
+			},
+		}
+		if backend.Origin == v1alpha1.BackendOriginUser {
+			// the secret names of the default backends refer to the backend secrets of the operator
+			backend.Splunk.SecretResourceName = splunk.SecretResourceName
+		}
+
+		configuration.Backends = append(configuration.Backends, backend)
+	}
+
+	if pointer.SafeDeref(pointer.SafeDeref(enforced).Log).Enabled {
+		configuration.Backends = append(configuration.Backends, v1alpha1.EffectiveBackend{
+			Name:   enforcedBackendPrefix + backendLog,
+			Type:   v1alpha1.BackendTypeLog,
+			Origin: v1alpha1.BackendOriginEnforced,
+		})
+	}
+	if pointer.SafeDeref(pointer.SafeDeref(enforced).Splunk).Enabled {
+		configuration.Backends = append(configuration.Backends, v1alpha1.EffectiveBackend{
+			Name:   enforcedBackendPrefix + backendSplunk,
+			Type:   v1alpha1.BackendTypeSplunk,
+			Origin: v1alpha1.BackendOriginEnforced,
+		})
+	}
+
+	return configuration
+}
+
+// configChecksum returns the checksum of the fluent-bit configuration contained in the seed objects
+func configChecksum(objects []client.Object) string {
+	if cm := findFluentbitConfigMap(objects); cm != nil {
+		return utils.ComputeConfigMapChecksum(cm.Data)
+	}
+
+	return ""
+}
+
+// PatchProviderStatus replaces the given field of the audit status in the extension's provider status. the provider
+// status is shared by the actuator, which owns the configuration, and the health check, which owns the health, so the
+// other fields are left untouched. the patch is computed against the given extension, such that nested fields that are
+// not set anymore are removed, which a merge patch of the new value alone would keep.
+func PatchProviderStatus(ctx context.Context, c client.Client, ex *extensionsv1alpha1.Extension, field string, value any) error {
+	status := map[string]any{}
+	if ex.Status.ProviderStatus != nil && len(ex.Status.ProviderStatus.Raw) > 0 {
+		if err := json.Unmarshal(ex.Status.ProviderStatus.Raw, &status); err != nil {
+			// a provider status that can not be read is replaced
+			status = map[string]any{}
+		}
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("unable to marshal %s of provider status: %w", field, err)
+	}
+
+	if string(raw) == "null" {
+		delete(status, field)
+	} else {
+		status[field] = json.RawMessage(raw)
+	}
+	status["apiVersion"] = v1alpha1.SchemeGroupVersion.String()
+	status["kind"] = "AuditStatus"
+
+	providerStatus, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("unable to marshal provider status: %w", err)
+	}
+
+	patch := client.MergeFrom(ex.DeepCopy())
+	ex.Status.ProviderStatus = &runtime.RawExtension{Raw: providerStatus}
+
+	return c.Status().Patch(ctx, ex, patch)
+}
+
+// updateProviderStatus writes the effective configuration to the extension's provider status, the health in the
+// provider status is owned by the health check.
+func (a *actuator) updateProviderStatus(ctx context.Context, ex *extensionsv1alpha1.Extension, configuration *v1alpha1.EffectiveConfiguration) error {
+	return PatchProviderStatus(ctx, a.client, ex, "configuration", configuration)
+}
@@ -0,0 +1,244 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/extensions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/fluentbitconfig"
+)
+
+func TestEffectiveConfiguration(t *testing.T) {
+	var (
+		userSplunk = &v1alpha1.AuditBackendSplunk{
+			Enabled:            true,
+			Host:               "splunk.example.com",
+			Port:               "8088",
+			Index:              "user",
+			TlsEnabled:         true,
+			SecretResourceName: "my-splunk",
+			CustomData:         map[string]string{"tenant": "a"},
+		}
+		defaultSplunk = &v1alpha1.AuditBackendSplunk{
+			Enabled:            true,
+			Host:               "default.example.com",
+			Port:               "443",
+			Index:              "default",
+			SecretResourceName: "operator-splunk",
+		}
+		enforced = &v1alpha1.AuditBackends{
+			Log:    &v1alpha1.AuditBackendLog{Enabled: true},
+			Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, Host: "enforced.example.com", Port: "443", SecretResourceName: "enforced"},
+		}
+	)
+
+	tests := []struct {
+		name         string
+		auditConfig  *v1alpha1.AuditConfig
+		userBackends *v1alpha1.AuditBackends
+		enforced     *v1alpha1.AuditBackends
+		want         *v1alpha1.EffectiveConfiguration
+	}{
+		{
+			name:        "no backends",
+			auditConfig: &v1alpha1.AuditConfig{},
+			want: &v1alpha1.EffectiveConfiguration{
+				WebhookMode:    v1alpha1.AuditWebhookModeBlockingStrict,
+				ConfigChecksum: "abc",
+			},
+		},
+		{
+			name: "user backends",
+			auditConfig: &v1alpha1.AuditConfig{
+				WebhookMode: v1alpha1.AuditWebhookModeBatch,
+				Backends: &v1alpha1.AuditBackends{
+					Log:               &v1alpha1.AuditBackendLog{Enabled: false},
+					ClusterForwarding: &v1alpha1.AuditBackendClusterForwarding{Enabled: true},
+					Splunk:            userSplunk,
+				},
+			},
+			userBackends: &v1alpha1.AuditBackends{
+				Log:               &v1alpha1.AuditBackendLog{Enabled: false},
+				ClusterForwarding: &v1alpha1.AuditBackendClusterForwarding{Enabled: true},
+				Splunk:            userSplunk,
+			},
+			want: &v1alpha1.EffectiveConfiguration{
+				WebhookMode: v1alpha1.AuditWebhookModeBatch,
+				Backends: []v1alpha1.EffectiveBackend{
+					{Name: "clusterforwarding", Type: v1alpha1.BackendTypeClusterForwarding, Origin: v1alpha1.BackendOriginUser},
+					{
+						Name:   "splunk",
+						Type:   v1alpha1.BackendTypeSplunk,
+						Origin: v1alpha1.BackendOriginUser,
+						Splunk: &v1alpha1.EffectiveSplunkBackend{
+							Host:               "splunk.example.com",
+							Port:               "8088",
+							Index:              "user",
+							TlsEnabled:         true,
+							SecretResourceName: "my-splunk",
+						},
+					},
+				},
+				ConfigChecksum: "abc",
+			},
+		},
+		{
+			name: "default and enforced backends",
+			auditConfig: &v1alpha1.AuditConfig{
+				WebhookMode: "unknown",
+				Backends: &v1alpha1.AuditBackends{
+					Log:    &v1alpha1.AuditBackendLog{Enabled: true},
+					Splunk: defaultSplunk,
+				},
+			},
+			userBackends: &v1alpha1.AuditBackends{
+				Log: &v1alpha1.AuditBackendLog{Enabled: true},
+			},
+			enforced: enforced,
+			want: &v1alpha1.EffectiveConfiguration{
+				WebhookMode:     v1alpha1.AuditWebhookModeBlockingStrict,
+				DefaultProfiles: []string{"production"},
+				Backends: []v1alpha1.EffectiveBackend{
+					{Name: "log", Type: v1alpha1.BackendTypeLog, Origin: v1alpha1.BackendOriginUser},
+					{
+						Name:   "splunk",
+						Type:   v1alpha1.BackendTypeSplunk,
+						Origin: v1alpha1.BackendOriginDefault,
+						Splunk: &v1alpha1.EffectiveSplunkBackend{
+							Host:  "default.example.com",
+							Port:  "443",
+							Index: "default",
+						},
+					},
+					{Name: "enforced-log", Type: v1alpha1.BackendTypeLog, Origin: v1alpha1.BackendOriginEnforced},
+					{Name: "enforced-splunk", Type: v1alpha1.BackendTypeSplunk, Origin: v1alpha1.BackendOriginEnforced},
+				},
+				ConfigChecksum: "abc",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := effectiveConfiguration(tt.auditConfig, tt.userBackends, tt.enforced, tt.want.DefaultProfiles, "abc")
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfigChecksum(t *testing.T) {
+	checksum := func(backends *v1alpha1.AuditBackends) string {
+		auditConfig := &v1alpha1.AuditConfig{
+			Backends: backends,
+			Persistence: v1alpha1.AuditPersistence{
+				Size: &resource.Quantity{},
+			},
+		}
+
+		objects, err := seedObjects(auditConfig, map[string]*corev1.Secret{}, &extensions.Cluster{Shoot: &v1beta1.Shoot{}}, &corev1.Secret{}, enforcedBackends{}, fluentbitconfig.FormatClassic, defaultInputSilencePeriod, "", "shoot--project--name")
+		require.NoError(t, err)
+
+		return configChecksum(objects)
+	}
+
+	withoutLog := checksum(&v1alpha1.AuditBackends{})
+	withLog := checksum(&v1alpha1.AuditBackends{Log: &v1alpha1.AuditBackendLog{Enabled: true}})
+
+	assert.NotEmpty(t, withoutLog)
+	assert.NotEqual(t, withoutLog, withLog)
+	assert.Equal(t, withLog, checksum(&v1alpha1.AuditBackends{Log: &v1alpha1.AuditBackendLog{Enabled: true}}))
+}
+
+func TestUpdateProviderStatus_KeepsHealth(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, extensionsv1alpha1.AddToScheme(scheme))
+	install.Install(scheme)
+
+	ex := &extensionsv1alpha1.Extension{
+		ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: "shoot--a"},
+		Status: extensionsv1alpha1.ExtensionStatus{
+			DefaultStatus: extensionsv1alpha1.DefaultStatus{
+				ProviderStatus: &runtime.RawExtension{
+					Raw: []byte(`{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditStatus","health":{"lastCheckTime":null,"fluentBitVersions":["3.0.4"]}}`),
+				},
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ex).WithStatusSubresource(ex).Build()
+	a := &actuator{client: c}
+
+	err := a.updateProviderStatus(context.Background(), ex, &v1alpha1.EffectiveConfiguration{
+		WebhookMode:    v1alpha1.AuditWebhookModeBlocking,
+		ConfigChecksum: "abc",
+	})
+	require.NoError(t, err)
+
+	got := &extensionsv1alpha1.Extension{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(ex), got))
+	require.NotNil(t, got.Status.ProviderStatus)
+
+	status := &v1alpha1.AuditStatus{}
+	_, _, err = serializer.NewCodecFactory(scheme).UniversalDecoder().Decode(got.Status.ProviderStatus.Raw, nil, status)
+	require.NoError(t, err)
+
+	require.NotNil(t, status.Configuration)
+	assert.Equal(t, "abc", status.Configuration.ConfigChecksum)
+	assert.Equal(t, v1alpha1.AuditWebhookModeBlocking, status.Configuration.WebhookMode)
+	require.NotNil(t, status.Health, "health is owned by the health check")
+	assert.Equal(t, []string{"3.0.4"}, status.Health.FluentBitVersions)
+}
+
+func TestUpdateProviderStatus_ClearsRemovedFields(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, extensionsv1alpha1.AddToScheme(scheme))
+	install.Install(scheme)
+
+	ex := &extensionsv1alpha1.Extension{
+		ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: "shoot--a"},
+		Status: extensionsv1alpha1.ExtensionStatus{
+			DefaultStatus: extensionsv1alpha1.DefaultStatus{
+				ProviderStatus: &runtime.RawExtension{
+					Raw: []byte(`{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditStatus",
+						"configuration":{"webhookMode":"batch","defaultProfiles":["production"],"backends":[{"name":"log","type":"log","origin":"default"}],"configChecksum":"abc"}}`),
+				},
+			},
+		},
+	}
+
+	// the health check writes the health in the meantime, which the actuator does not know about
+	stored := ex.DeepCopy()
+	stored.Status.ProviderStatus.Raw = []byte(`{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditStatus",
+		"configuration":{"webhookMode":"batch","defaultProfiles":["production"],"backends":[{"name":"log","type":"log","origin":"default"}],"configChecksum":"abc"},
+		"health":{"lastCheckTime":"2024-06-01T00:00:00Z","fluentBitVersions":["3.0.4"]}}`)
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(stored).WithStatusSubresource(stored).Build()
+	a := &actuator{client: c}
+
+	err := a.updateProviderStatus(context.Background(), ex, &v1alpha1.EffectiveConfiguration{
+		WebhookMode:    v1alpha1.AuditWebhookModeBlocking,
+		ConfigChecksum: "def",
+	})
+	require.NoError(t, err)
+
+	got := &extensionsv1alpha1.Extension{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(ex), got))
+	require.NotNil(t, got.Status.ProviderStatus)
+
+	assert.JSONEq(t, `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditStatus",
+		"configuration":{"webhookMode":"blocking","configChecksum":"def"},
+		"health":{"lastCheckTime":"2024-06-01T00:00:00Z","fluentBitVersions":["3.0.4"]}}`, string(got.Status.ProviderStatus.Raw))
+}
@@ -3,44 +3,86 @@ package healthcheck
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
+	"math"
 	"net/http"
-	"sync"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	extensionscontroller "github.com/gardener/gardener/extensions/pkg/controller"
 	"github.com/gardener/gardener/extensions/pkg/controller/healthcheck"
 	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-audit/pkg/controller/audit"
+	"github.com/metal-stack/gardener-extension-audit/pkg/metrics"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	appsv1 "k8s.io/api/apps/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/types"
 )
 
-// retryMap contains outputRetries using the namespace as a key
-type retryMap map[string]outputRetries
+const (
+	// warningProgressingThreshold is the duration for which warnings like retries are tolerated before the condition turns false
+	warningProgressingThreshold = 5 * time.Minute
+)
 
-// outputRetries contains retries per output plugin
-type outputRetries map[string]int
+// outputObservation contains the counters of an output summed up over all fluent-bit pods
+type outputObservation struct {
+	total    outputCounters
+	increase outputCounters
+	// observed is false if there is no baseline to calculate the increase of the counters from
+	observed bool
+	// availableCapacity is the lowest available capacity of the output's buffer of all pods
+	availableCapacity *float64
+}
 
 type BackendHealthChecker struct {
-	logger     logr.Logger
-	httpClient *http.Client
-	retries    retryMap
-	mutex      sync.Mutex
-	backoff    map[string]time.Time
-	syncPeriod time.Duration
-	seedClient client.Client
+	logger           logr.Logger
+	httpClient       *http.Client
+	scraper          *scraper
+	store            *stateStore
+	syncPeriod       time.Duration
+	seedClient       client.Client
+	decoder          runtime.Decoder
+	controllerConfig config.ControllerConfiguration
+	enforcedBackends *v1alpha1.AuditBackends
+	thresholds       bufferThresholds
+	silencePeriod    time.Duration
 }
 
-func backendHealth(syncPeriod time.Duration) healthcheck.HealthCheck {
+func backendHealth(syncPeriod time.Duration, config config.ControllerConfiguration) healthcheck.HealthCheck {
+	scheme := runtime.NewScheme()
+	install.Install(scheme)
+
+	bufferHealth := pointer.SafeDeref(config.BufferHealth)
+
 	return &BackendHealthChecker{
-		httpClient: http.DefaultClient,
-		retries:    retryMap{},
-		backoff:    map[string]time.Time{},
-		syncPeriod: syncPeriod,
+		httpClient:       &http.Client{Timeout: requestTimeout},
+		scraper:          newScraper(maxConcurrentScrapes, requestTimeout),
+		store:            newStateStore(),
+		syncPeriod:       syncPeriod,
+		decoder:          serializer.NewCodecFactory(scheme).UniversalDecoder(),
+		controllerConfig: config,
+		enforcedBackends: enforcedBackends(config.EnforcedBackends),
+		thresholds: bufferThresholds{
+			warningPercent: pointer.SafeDerefOrDefault(bufferHealth.WarningThresholdPercent, defaultBufferWarningThresholdPercent),
+			failurePercent: pointer.SafeDerefOrDefault(bufferHealth.FailureThresholdPercent, defaultBufferFailureThresholdPercent),
+			timeToFull:     pointer.SafeDerefOrDefault(bufferHealth.TimeToFullWarningThreshold, metav1.Duration{Duration: defaultTimeToFullWarningThreshold}).Duration,
+		},
+		silencePeriod: audit.InputSilencePeriod(config),
 	}
 }
 
@@ -55,18 +97,26 @@ func (h *BackendHealthChecker) SetLoggerSuffix(provider, extension string) {
 
 func (h *BackendHealthChecker) DeepCopy() healthcheck.HealthCheck {
 	return &BackendHealthChecker{
-		logger:     h.logger,
-		httpClient: h.httpClient,
-		retries:    h.retries,
-		mutex:      sync.Mutex{},
-		backoff:    h.backoff,
-		syncPeriod: h.syncPeriod,
-		seedClient: h.seedClient,
+		logger:           h.logger,
+		httpClient:       h.httpClient,
+		scraper:          h.scraper,
+		store:            h.store,
+		syncPeriod:       h.syncPeriod,
+		seedClient:       h.seedClient,
+		decoder:          h.decoder,
+		controllerConfig: h.controllerConfig,
+		enforcedBackends: h.enforcedBackends,
+		thresholds:       h.thresholds,
+		silencePeriod:    h.silencePeriod,
 	}
 }
 
 func (h *BackendHealthChecker) Check(ctx context.Context, request types.NamespacedName) (*healthcheck.SingleCheckResult, error) {
-	err := h.check(ctx, request.Namespace)
+	if err := h.store.gc(ctx, h.seedClient, h.syncPeriod); err != nil {
+		h.logger.Error(err, "unable to garbage collect health check states")
+	}
+
+	health, err := h.check(ctx, request)
 	if err != nil {
 		return &healthcheck.SingleCheckResult{ // nolint:nilerr
 			Status: gardencorev1beta1.ConditionFalse,
@@ -74,34 +124,106 @@ func (h *BackendHealthChecker) Check(ctx context.Context, request types.Namespac
 		}, nil
 	}
 
-	return &healthcheck.SingleCheckResult{
-		Status: gardencorev1beta1.ConditionTrue,
-	}, nil
+	if err := h.updateProviderStatus(ctx, request, health); err != nil {
+		h.logger.Error(err, "unable to update provider status of extension", "namespace", request.Namespace, "name", request.Name)
+	}
+
+	return checkResult(health), nil
 }
 
-func (h *BackendHealthChecker) check(ctx context.Context, namespace string) error {
-	if err := h.checkHealthEndpoint(ctx, namespace); err != nil {
-		return err
+func (h *BackendHealthChecker) check(ctx context.Context, request types.NamespacedName) (*v1alpha1.AuditHealth, error) {
+	if err := h.checkHealthEndpoint(ctx, request.Namespace); err != nil {
+		return nil, err
 	}
 
-	if err := h.checkRetries(ctx, namespace); err != nil {
-		return err
+	ex := &extensionsv1alpha1.Extension{}
+	if err := h.seedClient.Get(ctx, request, ex); err != nil {
+		return nil, fmt.Errorf("unable to get extension: %w", err)
 	}
 
-	return nil
+	cluster, err := extensionscontroller.GetCluster(ctx, h.seedClient, request.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get cluster: %w", err)
+	}
+
+	auditConfig, err := h.auditConfig(ex, cluster.Shoot)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity, err := bufferCapacity(auditConfig, h.enforcedBackends)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeAPIServer := &appsv1.Deployment{}
+	if err := h.seedClient.Get(ctx, client.ObjectKey{Namespace: request.Namespace, Name: v1beta1constants.DeploymentNameKubeAPIServer}, kubeAPIServer); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("unable to get kube-apiserver deployment: %w", err)
+		}
+		kubeAPIServer = nil
+	}
+
+	return h.checkEndpoints(ctx, request.Namespace, capacity, kubeAPIServer, persistedHealth(ex))
 }
 
-func (h *BackendHealthChecker) checkHealthEndpoint(ctx context.Context, namespace string) error {
-	url := fmt.Sprintf("http://audit-webhook-backend.%s.svc.cluster.local:2020/api/v1/health", namespace)
+// auditConfig returns the audit config of the extension including the default backends of the operator,
+// which is required to determine the capacity of the buffer.
+func (h *BackendHealthChecker) auditConfig(ex *extensionsv1alpha1.Extension, shoot *gardencorev1beta1.Shoot) (*v1alpha1.AuditConfig, error) {
+	auditConfig := &v1alpha1.AuditConfig{}
+	if ex.Spec.ProviderConfig != nil {
+		if _, _, err := h.decoder.Decode(ex.Spec.ProviderConfig.Raw, nil, auditConfig); err != nil {
+			return nil, fmt.Errorf("failed to decode provider config: %w", err)
+		}
+	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	defaults, err := audit.ResolveDefaults(h.controllerConfig, shoot)
 	if err != nil {
-		return fmt.Errorf("unable to create http request: %w", err)
+		return nil, err
 	}
 
-	resp, err := h.httpClient.Do(req)
+	auditConfig.Backends = audit.WithDefaultBackends(auditConfig.Backends, defaults.Backends)
+	v1alpha1.DefaultBackends(auditConfig.Backends)
+
+	return auditConfig, nil
+}
+
+// enforcedBackends returns the backends enforced by the operator with defaults applied
+func enforcedBackends(backends *v1alpha1.AuditBackends) *v1alpha1.AuditBackends {
+	if backends == nil {
+		return nil
+	}
+
+	backends = backends.DeepCopy()
+	v1alpha1.DefaultBackends(backends)
+
+	return backends
+}
+
+// persistedHealth returns the health that was written to the provider status of the extension by a previous health check
+func persistedHealth(ex *extensionsv1alpha1.Extension) *v1alpha1.AuditHealth {
+	if ex.Status.ProviderStatus == nil {
+		return nil
+	}
+
+	status := &v1alpha1.AuditStatus{}
+	if err := json.Unmarshal(ex.Status.ProviderStatus.Raw, status); err != nil {
+		// the counters are only an optimization, the state is rebuilt with the next health check
+		return nil
+	}
+
+	return status.Health
+}
+
+func (h *BackendHealthChecker) checkHealthEndpoint(ctx context.Context, namespace string) error {
+	url := fmt.Sprintf("http://audit-webhook-backend.%s.svc.cluster.local:2020/api/v1/health", namespace)
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	resp, err := get(ctx, h.httpClient, url)
 	if err != nil {
-		return fmt.Errorf("unable to do http request: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
@@ -112,7 +234,7 @@ func (h *BackendHealthChecker) checkHealthEndpoint(ctx context.Context, namespac
 	return fmt.Errorf("backend is unhealthy since errors or failures have occurred in the last minute time frame")
 }
 
-func (h *BackendHealthChecker) checkRetries(ctx context.Context, namespace string) error {
+func (h *BackendHealthChecker) checkEndpoints(ctx context.Context, namespace string, bufferCapacity int64, kubeAPIServer *appsv1.Deployment, persisted *v1alpha1.AuditHealth) (*v1alpha1.AuditHealth, error) {
 	// as retries are set to no_limits, fluent-bit does not count unreachable backends as errors or retry_errors
 	// therefore, we need to check if there were any retries during the last health check
 
@@ -121,116 +243,252 @@ func (h *BackendHealthChecker) checkRetries(ctx context.Context, namespace strin
 		"kubernetes.io/service-name": "audit-webhook-backend",
 	}, client.InNamespace(namespace))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(endpointSliceList.Items) == 0 {
-		return fmt.Errorf("no endpoints found for audit backend service")
+		return nil, fmt.Errorf("no endpoints found for audit backend service")
 	}
 
-	h.mutex.Lock()
-	defer h.mutex.Unlock()
+	state := h.store.get(namespace)
 
-	defer func() {
-		h.backoff[namespace] = time.Now()
-	}()
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
 
-	lastCheck, ok := h.backoff[namespace]
-	if ok {
-		if time.Since(lastCheck) < h.syncPeriod-1*time.Second {
-			// we only need a check once every sync period, otherwise retries might be too low such that health flickers
-			return nil
-		}
+	state.restore(persisted, 2*h.syncPeriod, time.Now())
+
+	if state.health != nil && time.Since(state.lastCheck) < h.syncPeriod-1*time.Second {
+		// we only need a check once every sync period, otherwise retries might be too low such that health flickers
+		return state.health, nil
+	}
+
+	if !state.lastCheck.IsZero() && time.Since(state.lastCheck) > 2*h.syncPeriod {
+		// health checks do not run while the shoot is hibernated, so the silence period starts again
+		state.lastInput = nil
 	}
 
-	type metrics struct {
-		Output map[string]struct {
-			Retries        int `json:"retries"`
-			RetriedRecords int `json:"retried_records"`
-		} `json:"output"`
+	results, err := h.scraper.scrapeAll(ctx, h.httpClient, endpointsOf(endpointSliceList))
+	if err != nil {
+		return nil, err
 	}
 
 	var (
-		ms        []metrics
-		addresses []string
+		outputs       = map[string]*outputObservation{}
+		pods          = map[string]podState{}
+		usedBytes     = map[string]int64{}
+		versions      []string
+		inputIncrease float64
 	)
 
-	for _, endpoints := range endpointSliceList.Items {
-		for _, endpoint := range endpoints.Endpoints {
-			addresses = append(addresses, endpoint.Addresses...)
+	// counters are tracked per pod because fluent-bit resets them on restart, which would hide an increase in the sum
+	for _, result := range results {
+		pod := podState{
+			uid:          result.uid,
+			inputRecords: result.metrics.inputRecords,
+			outputs:      map[string]outputCounters{},
 		}
-	}
 
-	for _, address := range addresses {
-		url := fmt.Sprintf("http://%s:2020/api/v1/metrics", address)
+		if last, ok := state.previous(result.pod, result.uid); ok {
+			inputIncrease += counterIncrease(pod.inputRecords, last.inputRecords)
+		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-		if err != nil {
-			return fmt.Errorf("unable to create http request: %w", err)
+		for name, current := range result.metrics.outputs {
+			pod.outputs[name] = current.outputCounters
+
+			o, ok := outputs[name]
+			if !ok {
+				o = &outputObservation{}
+				outputs[name] = o
+			}
+
+			o.total = o.total.add(current.outputCounters)
+
+			if last, ok := state.baseline(result.pod, result.uid, name); ok {
+				o.observed = true
+				o.increase = o.increase.add(current.increase(last))
+			}
+
+			if c := current.availableCapacity; c != nil && (o.availableCapacity == nil || *c < *o.availableCapacity) {
+				o.availableCapacity = c
+			}
 		}
 
-		resp, err := h.httpClient.Do(req)
+		pods[result.pod] = pod
+		used, err := result.storage.usedBytes()
 		if err != nil {
-			return fmt.Errorf("unable to do http request: %w", err)
+			return nil, err
 		}
-		defer resp.Body.Close()
+		usedBytes[result.pod] = used
 
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("metrics endpoint return code was %d", resp.StatusCode)
+		if v := result.metrics.version; v != "" && !slices.Contains(versions, v) {
+			versions = append(versions, v)
 		}
+	}
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("unable to read http body: %w", err)
-		}
+	now := metav1.Now()
 
-		var m metrics
+	// the counters of a failed scrape are unknown, so only a successful scrape counts as a check. otherwise the next check
+	// would be skipped and compare its counters with ones that are older than the sync period.
+	state.pods = pods
+	state.lastCheck = now.Time
 
-		err = json.Unmarshal(body, &m)
-		if err != nil {
-			return fmt.Errorf("unable to unmarshal metrics: %w", err)
+	slices.Sort(versions)
+
+	health := &v1alpha1.AuditHealth{
+		LastCheckTime:     now,
+		FluentBitVersions: versions,
+	}
+
+	for name, o := range outputs {
+		if name == "null" {
+			// the null output is only used for fluent-bit to start up without backends
+			continue
 		}
 
-		ms = append(ms, m)
+		outputHealth, lastFlush := evaluateOutput(name, o, state.lastFlush[name], h.thresholds, now)
+
+		state.lastFlush[name] = lastFlush
+		health.Outputs = append(health.Outputs, outputHealth)
+
+		metrics.SetOutputHealth(namespace, name, outputHealth.Status)
+		metrics.OutputRetries.WithLabelValues(namespace, name).Add(float64(outputHealth.Retries))
+		metrics.OutputDroppedRecords.WithLabelValues(namespace, name).Add(float64(outputHealth.DroppedRecords))
 	}
 
-	plugins, ok := h.retries[namespace]
-	if !ok {
-		plugins = outputRetries{}
+	sort.Slice(health.Outputs, func(i, j int) bool {
+		return health.Outputs[i].Name < health.Outputs[j].Name
+	})
+
+	health.Buffer, state.buffers = evaluateBuffer(usedBytes, state.buffers, bufferCapacity, h.thresholds, now.Time)
+
+	if kubeAPIServerRunning(kubeAPIServer) {
+		health.Input, state.lastInput = evaluateInput(inputIncrease, state.lastInput, h.silencePeriod, checkKubeAPIServer(kubeAPIServer), now)
+	} else {
+		// the kube-apiserver does not send audit events while it is not running, so the silence period starts again
+		state.lastInput = nil
+	}
+
+	health.Pods = state.podCounters()
+
+	state.health = health
+
+	return health, nil
+}
+
+// evaluateOutput compares the current counters of an output with the ones from the previous health check.
+// retries only lead to a warning because the backend might just be unavailable for a short period of time,
+// dropped records and an almost full buffer mean that audit events are or are about to be lost.
+func evaluateOutput(name string, current *outputObservation, lastFlush *metav1.Time, thresholds bufferThresholds, now metav1.Time) (v1alpha1.OutputHealth, *metav1.Time) {
+	health := v1alpha1.OutputHealth{
+		Name:   name,
+		Status: v1alpha1.OutputHealthStatusHealthy,
+	}
+
+	if current.observed {
+		health.Retries = int64(current.increase.retries)
+		health.DroppedRecords = int64(current.increase.droppedRecords)
+
+		if current.increase.procRecords > 0 {
+			lastFlush = &now
+		}
+	}
+
+	health.LastSuccessfulFlushTime = lastFlush
+
+	if current.availableCapacity != nil {
+		usage := int32(math.Round(100 - *current.availableCapacity))
+		health.BufferUsagePercent = &usage
 	}
 
 	var (
-		errs []error
-		sums = outputRetries{}
+		failures []string
+		warnings []string
 	)
 
-	for _, m := range ms {
-		m := m
+	if health.DroppedRecords > 0 {
+		failures = append(failures, fmt.Sprintf("%d records were dropped in the last minute time frame", health.DroppedRecords))
+	}
+	if health.BufferUsagePercent != nil {
+		switch {
+		case *health.BufferUsagePercent >= thresholds.failurePercent:
+			failures = append(failures, fmt.Sprintf("filesystem buffer is %d%% full", *health.BufferUsagePercent))
+		case *health.BufferUsagePercent >= thresholds.warningPercent:
+			warnings = append(warnings, fmt.Sprintf("filesystem buffer is %d%% full", *health.BufferUsagePercent))
+		}
+	}
+	if health.Retries > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d retries (%d in total) have occurred in the last minute time frame", health.Retries, int64(current.total.retries)))
+	}
+
+	switch {
+	case len(failures) > 0:
+		health.Status = v1alpha1.OutputHealthStatusFailed
+	case len(warnings) > 0:
+		health.Status = v1alpha1.OutputHealthStatusWarning
+	}
+
+	health.Message = strings.Join(append(failures, warnings...), ", ")
 
-		for name, output := range m.Output {
-			output := output
+	return health, lastFlush
+}
+
+// checkResult turns the output and buffer health into the result of the health check. failures turn the condition false,
+// warnings make it progressing, such that it only turns false if the warnings persist.
+func checkResult(health *v1alpha1.AuditHealth) *healthcheck.SingleCheckResult {
+	var (
+		failed  []string
+		warning []string
+	)
 
-			sums[name] += output.Retries
+	for _, output := range health.Outputs {
+		switch output.Status {
+		case v1alpha1.OutputHealthStatusFailed:
+			failed = append(failed, fmt.Sprintf("output %q: %s", output.Name, output.Message))
+		case v1alpha1.OutputHealthStatusWarning:
+			warning = append(warning, fmt.Sprintf("output %q: %s", output.Name, output.Message))
 		}
 	}
 
-	for name, sum := range sums {
-		lastCount, ok := plugins[name]
-		if !ok {
-			plugins[name] = sum
-			continue
+	if health.Buffer != nil {
+		switch health.Buffer.Status {
+		case v1alpha1.OutputHealthStatusFailed:
+			failed = append(failed, health.Buffer.Message)
+		case v1alpha1.OutputHealthStatusWarning:
+			warning = append(warning, health.Buffer.Message)
 		}
+	}
 
-		diff := sum - lastCount
-
-		plugins[name] = sum
+	if health.Input != nil && health.Input.Status == v1alpha1.OutputHealthStatusFailed {
+		failed = append(failed, health.Input.Message)
+	}
 
-		if diff > 0 {
-			errs = append(errs, fmt.Errorf("%d retries (%d in total) have occurred in the last minute time frame for output %q", diff, sum, name))
+	switch {
+	case len(failed) > 0:
+		return &healthcheck.SingleCheckResult{
+			Status: gardencorev1beta1.ConditionFalse,
+			Detail: strings.Join(append(failed, warning...), "; "),
+		}
+	case len(warning) > 0:
+		threshold := warningProgressingThreshold
+		return &healthcheck.SingleCheckResult{
+			Status:               gardencorev1beta1.ConditionProgressing,
+			Detail:               strings.Join(warning, "; "),
+			ProgressingThreshold: &threshold,
+		}
+	default:
+		return &healthcheck.SingleCheckResult{
+			Status: gardencorev1beta1.ConditionTrue,
 		}
 	}
+}
 
-	h.retries[namespace] = plugins
+// updateProviderStatus writes the health to the extension's provider status, other parts of the provider status are
+// not owned by the health check
+func (h *BackendHealthChecker) updateProviderStatus(ctx context.Context, request types.NamespacedName, health *v1alpha1.AuditHealth) error {
+	ex := &extensionsv1alpha1.Extension{}
+	if err := h.seedClient.Get(ctx, request, ex); err != nil {
+		return fmt.Errorf("unable to get extension: %w", err)
+	}
 
-	return errors.Join(errs...)
+	return audit.PatchProviderStatus(ctx, h.seedClient, ex, "health", health)
 }
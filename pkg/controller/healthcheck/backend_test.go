@@ -8,33 +8,64 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gardener/gardener/extensions/pkg/controller/healthcheck"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var (
-	body1 = `{
-		"output": {
-		  "splunk.0": {
-			"retries": 10
-		  },
-		  "null.1": {
-			"retries": 0
-		  }
-		}
-	  } `
-	body2 = `{
-		"output": {
-		  "splunk.0": {
-			"retries": 20
-		  },
-		  "null.1": {
-			"retries": 0
-		  }
-		}
-	  } `
+	body1 = `# HELP fluentbit_output_retries_total Number of output retries.
+# TYPE fluentbit_output_retries_total counter
+fluentbit_output_retries_total{name="splunk"} 10
+fluentbit_output_retries_total{name="null"} 0
+# HELP fluentbit_output_dropped_records_total Number of dropped records.
+# TYPE fluentbit_output_dropped_records_total counter
+fluentbit_output_dropped_records_total{name="splunk"} 0
+fluentbit_output_dropped_records_total{name="null"} 0
+# HELP fluentbit_output_proc_records_total Number of processed output records.
+# TYPE fluentbit_output_proc_records_total counter
+fluentbit_output_proc_records_total{name="splunk"} 100
+fluentbit_output_proc_records_total{name="null"} 100
+# HELP fluentbit_output_chunk_available_capacity_percent Available chunk capacity (percent)
+# TYPE fluentbit_output_chunk_available_capacity_percent gauge
+fluentbit_output_chunk_available_capacity_percent{name="splunk"} 80
+fluentbit_output_chunk_available_capacity_percent{name="null"} 100
+# HELP fluentbit_build_info Build version information.
+# TYPE fluentbit_build_info gauge
+fluentbit_build_info{hostname="audit-webhook-backend-0",version="3.0.4",os="linux"} 1718000000
+`
+	body2 = `# TYPE fluentbit_output_retries_total counter
+fluentbit_output_retries_total{name="splunk"} 20
+fluentbit_output_retries_total{name="null"} 0
+# TYPE fluentbit_output_dropped_records_total counter
+fluentbit_output_dropped_records_total{name="splunk"} 0
+fluentbit_output_dropped_records_total{name="null"} 0
+# TYPE fluentbit_output_proc_records_total counter
+fluentbit_output_proc_records_total{name="splunk"} 100
+fluentbit_output_proc_records_total{name="null"} 200
+`
+	body3 = `# TYPE fluentbit_output_retries_total counter
+fluentbit_output_retries_total{name="splunk"} 20
+# TYPE fluentbit_output_dropped_records_total counter
+fluentbit_output_dropped_records_total{name="splunk"} 5
+# TYPE fluentbit_output_proc_records_total counter
+fluentbit_output_proc_records_total{name="splunk"} 150
+# TYPE fluentbit_output_chunk_available_capacity_percent gauge
+fluentbit_output_chunk_available_capacity_percent{name="splunk"} 5
+`
+	storageBody = `{"storage_layer":{"chunks":{"total_chunks":10,"mem_chunks":0,"fs_chunks":10,"fs_chunks_up":2,"fs_chunks_down":8}},"input_chunks":{"http.0":{"status":{"overlimit":false,"mem_size":"1.0M","mem_limit":"0b"},"chunks":{"total":10,"up":2,"down":8,"busy":0,"busy_size":"0b"}}}}`
 )
 
 type RoundTripFunc func(req *http.Request) *http.Response
@@ -43,11 +74,16 @@ func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return f(req), nil
 }
 
-func TestBackendHealthChecker_checkRetries(t *testing.T) {
+func TestBackendHealthChecker_checkOutputs(t *testing.T) {
 	h := &BackendHealthChecker{
-		httpClient: newFakeClient(http.StatusOK, body1),
-		retries:    retryMap{},
-		backoff:    map[string]time.Time{},
+		httpClient: newFakeClient(http.StatusOK, body1, storageBody),
+		scraper:    newScraper(maxConcurrentScrapes, requestTimeout),
+		store:      newStateStore(),
+		thresholds: bufferThresholds{
+			warningPercent: 70,
+			failurePercent: 90,
+			timeToFull:     time.Hour,
+		},
 		seedClient: fake.NewClientBuilder().WithLists(&discoveryv1.EndpointSliceList{
 			Items: []discoveryv1.EndpointSlice{
 				{
@@ -70,41 +106,311 @@ func TestBackendHealthChecker_checkRetries(t *testing.T) {
 		}).Build(),
 	}
 
-	err := h.checkRetries(context.Background(), "shoot-a")
+	health, err := h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, nil, nil)
+	require.NoError(t, err)
+
+	require.Len(t, health.Outputs, 1, "null output must not be reported")
+	assert.Equal(t, v1alpha1.OutputHealth{
+		Name:               "splunk",
+		Status:             v1alpha1.OutputHealthStatusHealthy,
+		BufferUsagePercent: pointer.Pointer(int32(20)),
+	}, health.Outputs[0])
+	assert.Equal(t, []string{"3.0.4"}, health.FluentBitVersions)
+
+	require.Equal(t, outputCounters{
+		retries:     10,
+		procRecords: 100,
+	}, h.store.states["shoot-a"].pods["1.2.3.4"].outputs["splunk"])
+	require.Len(t, health.Pods, 2)
+
+	health, err = h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, nil, nil)
+	require.NoError(t, err)
+
+	require.Len(t, health.Outputs, 1)
+	assert.Equal(t, v1alpha1.OutputHealthStatusHealthy, health.Outputs[0].Status, "no changes in retries")
+	assert.Nil(t, health.Outputs[0].LastSuccessfulFlushTime, "no records were processed")
+
+	h.httpClient = newFakeClient(http.StatusOK, body2, storageBody)
+
+	health, err = h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, nil, nil)
+	require.NoError(t, err)
+
+	require.Len(t, health.Outputs, 1)
+	assert.Equal(t, v1alpha1.OutputHealthStatusWarning, health.Outputs[0].Status)
+	assert.Equal(t, int64(20), health.Outputs[0].Retries)
+	assert.Equal(t, `20 retries (40 in total) have occurred in the last minute time frame`, health.Outputs[0].Message)
+	assert.Nil(t, health.Outputs[0].BufferUsagePercent)
+
+	result := checkResult(health)
+	assert.Equal(t, gardencorev1beta1.ConditionProgressing, result.Status)
+	assert.Equal(t, `output "splunk": 20 retries (40 in total) have occurred in the last minute time frame`, result.Detail)
+
+	h.httpClient = newFakeClient(http.StatusOK, body3, storageBody)
+
+	health, err = h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, nil, nil)
+	require.NoError(t, err)
+
+	require.Len(t, health.Outputs, 1)
+	assert.Equal(t, v1alpha1.OutputHealthStatusFailed, health.Outputs[0].Status)
+	assert.Equal(t, int64(10), health.Outputs[0].DroppedRecords)
+	assert.Equal(t, int64(0), health.Outputs[0].Retries)
+	assert.NotNil(t, health.Outputs[0].LastSuccessfulFlushTime)
+	assert.Equal(t, "10 records were dropped in the last minute time frame, filesystem buffer is 95% full", health.Outputs[0].Message)
+
+	result = checkResult(health)
+	assert.Equal(t, gardencorev1beta1.ConditionFalse, result.Status)
+
+	// fluent-bit restarts and starts counting from zero, which must not be mistaken for no retries
+	h.httpClient = newFakeClient(http.StatusOK, body1, storageBody)
+
+	health, err = h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, nil, nil)
+	require.NoError(t, err)
+
+	require.Len(t, health.Outputs, 1)
+	assert.Equal(t, v1alpha1.OutputHealthStatusWarning, health.Outputs[0].Status)
+	assert.Equal(t, int64(20), health.Outputs[0].Retries)
+	assert.Equal(t, int64(0), health.Outputs[0].DroppedRecords)
+}
+
+func TestBackendHealthChecker_checkEndpointsAfterFailedScrape(t *testing.T) {
+	h := &BackendHealthChecker{
+		httpClient: newFakeClient(http.StatusOK, body1, storageBody),
+		scraper:    newScraper(maxConcurrentScrapes, requestTimeout),
+		store:      newStateStore(),
+		syncPeriod: time.Minute,
+		thresholds: bufferThresholds{
+			warningPercent: 70,
+			failurePercent: 90,
+			timeToFull:     time.Hour,
+		},
+		seedClient: fake.NewClientBuilder().WithLists(&discoveryv1.EndpointSliceList{
+			Items: []discoveryv1.EndpointSlice{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "shoot-a",
+						Labels: map[string]string{
+							"kubernetes.io/service-name": "audit-webhook-backend",
+						},
+					},
+					Endpoints: []discoveryv1.Endpoint{
+						{
+							Addresses: []string{"1.2.3.4"},
+							TargetRef: &corev1.ObjectReference{Name: "audit-webhook-backend-0", UID: "uid-0"},
+						},
+					},
+				},
+			},
+		}).Build(),
+	}
+
+	health, err := h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, nil, nil)
 	require.NoError(t, err)
+	require.Len(t, health.Outputs, 1)
+	assert.Equal(t, v1alpha1.OutputHealthStatusHealthy, health.Outputs[0].Status)
+
+	state := h.store.get("shoot-a")
+	lastCheck := time.Now().Add(-h.syncPeriod)
+	state.lastCheck = lastCheck
+
+	h.httpClient = newFakeClient(http.StatusInternalServerError, "", "")
+
+	_, err = h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, nil, nil)
+	require.Error(t, err)
+	assert.Equal(t, lastCheck, state.lastCheck, "a failed scrape must not count as a check")
+
+	h.httpClient = newFakeClient(http.StatusOK, body2, storageBody)
+
+	health, err = h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, health.LastCheckTime.Time, state.lastCheck)
+
+	require.Len(t, health.Outputs, 1)
+	assert.Equal(t, v1alpha1.OutputHealthStatusWarning, health.Outputs[0].Status, "the check after the failed scrape must not be skipped")
+	assert.Equal(t, int64(10), health.Outputs[0].Retries)
+}
 
-	require.Equal(t, retryMap{
-		"shoot-a": {
-			"splunk.0": 2 * 10, // two backends with 10 retries
-			"null.1":   0,
+func TestBackendHealthChecker_checkEndpointsRestoresPersistedCounters(t *testing.T) {
+	h := &BackendHealthChecker{
+		httpClient: newFakeClient(http.StatusOK, body2, storageBody),
+		scraper:    newScraper(maxConcurrentScrapes, requestTimeout),
+		store:      newStateStore(),
+		syncPeriod: time.Minute,
+		thresholds: bufferThresholds{
+			warningPercent: 70,
+			failurePercent: 90,
+			timeToFull:     time.Hour,
 		},
-	}, h.retries)
+		seedClient: fake.NewClientBuilder().WithLists(&discoveryv1.EndpointSliceList{
+			Items: []discoveryv1.EndpointSlice{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "shoot-a",
+						Labels: map[string]string{
+							"kubernetes.io/service-name": "audit-webhook-backend",
+						},
+					},
+					Endpoints: []discoveryv1.Endpoint{
+						{
+							Addresses: []string{"1.2.3.4"},
+							TargetRef: &corev1.ObjectReference{Name: "audit-webhook-backend-0", UID: "uid-0"},
+						},
+					},
+				},
+			},
+		}).Build(),
+	}
 
-	err = h.checkRetries(context.Background(), "shoot-a")
+	persisted := &v1alpha1.AuditHealth{
+		LastCheckTime: metav1.NewTime(time.Now().Add(-1 * time.Minute)),
+		Pods: []v1alpha1.PodCounters{
+			{
+				Name: "audit-webhook-backend-0",
+				UID:  "uid-0",
+				Outputs: []v1alpha1.OutputCounters{
+					{Name: "splunk", Retries: 10, ProcessedRecords: 100},
+				},
+			},
+		},
+	}
+
+	health, err := h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, nil, persisted)
 	require.NoError(t, err)
 
-	require.Equal(t, retryMap{
-		"shoot-a": {
-			"splunk.0": 2 * 10, // no changes in retries
-			"null.1":   0,
+	require.Len(t, health.Outputs, 1)
+	assert.Equal(t, v1alpha1.OutputHealthStatusWarning, health.Outputs[0].Status, "retries since the check before the restart must be detected")
+	assert.Equal(t, int64(10), health.Outputs[0].Retries)
+	assert.Equal(t, []v1alpha1.PodCounters{
+		{
+			Name: "audit-webhook-backend-0",
+			UID:  "uid-0",
+			Outputs: []v1alpha1.OutputCounters{
+				{Name: "null", ProcessedRecords: 200},
+				{Name: "splunk", Retries: 20, ProcessedRecords: 100},
+			},
 		},
-	}, h.retries)
+	}, health.Pods)
+}
 
-	h.httpClient = newFakeClient(http.StatusOK, body2)
+func TestCheckResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs []v1alpha1.OutputHealth
+		buffer  *v1alpha1.BufferHealth
+		input   *v1alpha1.InputHealth
+		want    *healthcheck.SingleCheckResult
+	}{
+		{
+			name: "all healthy",
+			outputs: []v1alpha1.OutputHealth{
+				{Name: "splunk", Status: v1alpha1.OutputHealthStatusHealthy},
+				{Name: "log", Status: v1alpha1.OutputHealthStatusHealthy},
+			},
+			want: &healthcheck.SingleCheckResult{
+				Status: gardencorev1beta1.ConditionTrue,
+			},
+		},
+		{
+			name: "failure wins over warning",
+			outputs: []v1alpha1.OutputHealth{
+				{Name: "splunk", Status: v1alpha1.OutputHealthStatusWarning, Message: "retries"},
+				{Name: "clusterforwarding", Status: v1alpha1.OutputHealthStatusFailed, Message: "dropped"},
+			},
+			want: &healthcheck.SingleCheckResult{
+				Status: gardencorev1beta1.ConditionFalse,
+				Detail: `output "clusterforwarding": dropped; output "splunk": retries`,
+			},
+		},
+		{
+			name: "buffer warning",
+			outputs: []v1alpha1.OutputHealth{
+				{Name: "splunk", Status: v1alpha1.OutputHealthStatusHealthy},
+			},
+			buffer: &v1alpha1.BufferHealth{Status: v1alpha1.OutputHealthStatusWarning, Message: "buffer of pod a is 75% full"},
+			want: &healthcheck.SingleCheckResult{
+				Status:               gardencorev1beta1.ConditionProgressing,
+				Detail:               "buffer of pod a is 75% full",
+				ProgressingThreshold: pointer.Pointer(warningProgressingThreshold),
+			},
+		},
+		{
+			name: "buffer failure",
+			outputs: []v1alpha1.OutputHealth{
+				{Name: "splunk", Status: v1alpha1.OutputHealthStatusWarning, Message: "retries"},
+			},
+			buffer: &v1alpha1.BufferHealth{Status: v1alpha1.OutputHealthStatusFailed, Message: "buffer of pod a is 95% full"},
+			want: &healthcheck.SingleCheckResult{
+				Status: gardencorev1beta1.ConditionFalse,
+				Detail: `buffer of pod a is 95% full; output "splunk": retries`,
+			},
+		},
+		{
+			name: "input silence",
+			outputs: []v1alpha1.OutputHealth{
+				{Name: "splunk", Status: v1alpha1.OutputHealthStatusHealthy},
+			},
+			input: &v1alpha1.InputHealth{Status: v1alpha1.OutputHealthStatusFailed, Message: "no audit events were received for 15m0s"},
+			want: &healthcheck.SingleCheckResult{
+				Status: gardencorev1beta1.ConditionFalse,
+				Detail: "no audit events were received for 15m0s",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkResult(&v1alpha1.AuditHealth{Outputs: tt.outputs, Buffer: tt.buffer, Input: tt.input})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
 
-	err = h.checkRetries(context.Background(), "shoot-a")
-	require.ErrorContains(t, err, `20 retries (40 in total) have occurred in the last minute time frame for output "splunk.0"`)
+func TestBackendHealthChecker_updateProviderStatusClearsFields(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, extensionsv1alpha1.AddToScheme(scheme))
+	install.Install(scheme)
 
-	require.Equal(t, retryMap{
-		"shoot-a": {
-			"splunk.0": 2 * 20,
-			"null.1":   0,
+	ex := &extensionsv1alpha1.Extension{
+		ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: "shoot--a"},
+		Status: extensionsv1alpha1.ExtensionStatus{
+			DefaultStatus: extensionsv1alpha1.DefaultStatus{
+				ProviderStatus: &runtime.RawExtension{
+					Raw: []byte(`{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditStatus",` +
+						`"configuration":{"webhookMode":"blocking","configChecksum":"abc"},` +
+						`"health":{"lastCheckTime":"2024-06-01T00:00:00Z",` +
+						`"buffer":{"status":"Failed","message":"buffer of pod a is 95% full","usagePercent":95,"estimatedTimeToFull":"10m0s"},` +
+						`"input":{"status":"Failed","message":"no audit events were received for 15m0s"},` +
+						`"outputs":[{"name":"splunk","status":"Failed","message":"dropped","retries":0,"droppedRecords":5}],` +
+						`"pods":[{"name":"audit-webhook-backend-0","inputRecords":10}]}}`),
+				},
+			},
 		},
-	}, h.retries)
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ex).WithStatusSubresource(ex).Build()
+	h := &BackendHealthChecker{seedClient: c}
+
+	// the backend recovered and its pods are gone, e.g. because the shoot is hibernated
+	err := h.updateProviderStatus(context.Background(), types.NamespacedName{Namespace: ex.Namespace, Name: ex.Name}, &v1alpha1.AuditHealth{
+		LastCheckTime: metav1.NewTime(time.Date(2024, 6, 1, 0, 1, 0, 0, time.UTC)),
+		Buffer:        &v1alpha1.BufferHealth{Status: v1alpha1.OutputHealthStatusHealthy, UsagePercent: 10},
+	})
+	require.NoError(t, err)
+
+	got := &extensionsv1alpha1.Extension{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(ex), got))
+	require.NotNil(t, got.Status.ProviderStatus)
+
+	assert.JSONEq(t, `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditStatus",
+		"configuration":{"webhookMode":"blocking","configChecksum":"abc"},
+		"health":{"lastCheckTime":"2024-06-01T00:01:00Z","buffer":{"status":"Healthy","usagePercent":10}}}`, string(got.Status.ProviderStatus.Raw))
 }
 
-func newFakeClient(code int, respBody string) *http.Client {
+func newFakeClient(code int, metricsBody, storageBody string) *http.Client {
 	return &http.Client{Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+		respBody := metricsBody
+		if req.URL.Path == "/api/v1/storage" {
+			respBody = storageBody
+		}
+
 		return &http.Response{
 			StatusCode: code,
 			Body:       io.NopCloser(strings.NewReader(respBody)),
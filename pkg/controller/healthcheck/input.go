@@ -0,0 +1,70 @@
+package healthcheck
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	extensionswebhook "github.com/gardener/gardener/extensions/pkg/webhook"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// kubeAPIServerWebhookFlag and kubeAPIServerNetworkPolicyLabel are ensured by the kube-apiserver webhook of this extension
+	kubeAPIServerWebhookFlag        = "--audit-webhook-config-file="
+	kubeAPIServerNetworkPolicyLabel = "networking.resources.gardener.cloud/to-audit-webhook-backend-tcp-9880"
+)
+
+// kubeAPIServerRunning returns true if the kube-apiserver is running and therefore expected to send audit events,
+// which is not the case while the shoot is hibernated, waking up or being created
+func kubeAPIServerRunning(deployment *appsv1.Deployment) bool {
+	return deployment != nil && deployment.DeletionTimestamp == nil && deployment.Status.ReadyReplicas > 0
+}
+
+// checkKubeAPIServer verifies that the kube-apiserver is configured to send audit events to the audit webhook backend,
+// the configuration could for example be dropped by another mutating webhook
+func checkKubeAPIServer(deployment *appsv1.Deployment) []string {
+	var problems []string
+
+	c := extensionswebhook.ContainerWithName(deployment.Spec.Template.Spec.Containers, v1beta1constants.DeploymentNameKubeAPIServer)
+	if c == nil {
+		return []string{"kube-apiserver container not found in kube-apiserver deployment"}
+	}
+
+	if extensionswebhook.StringWithPrefixIndex(c.Command, kubeAPIServerWebhookFlag) < 0 && extensionswebhook.StringWithPrefixIndex(c.Args, kubeAPIServerWebhookFlag) < 0 {
+		problems = append(problems, fmt.Sprintf("kube-apiserver is missing the flag %s", strings.TrimSuffix(kubeAPIServerWebhookFlag, "=")))
+	}
+	if deployment.Spec.Template.Labels[kubeAPIServerNetworkPolicyLabel] != "allowed" {
+		problems = append(problems, fmt.Sprintf("kube-apiserver is missing the label %s", kubeAPIServerNetworkPolicyLabel))
+	}
+
+	return problems
+}
+
+// evaluateInput checks whether the audit webhook backend receives audit events. the kube-apiserver sends audit events
+// for its own requests, so if no events arrive for the silence period, they are not sent to the backend anymore.
+// the silence period starts with the observation if nothing is known about the last received events.
+func evaluateInput(received float64, lastReceived *metav1.Time, silencePeriod time.Duration, problems []string, now metav1.Time) (*v1alpha1.InputHealth, *metav1.Time) {
+	if received > 0 || lastReceived == nil {
+		lastReceived = &now
+	}
+
+	health := &v1alpha1.InputHealth{
+		Status:           v1alpha1.OutputHealthStatusHealthy,
+		LastReceivedTime: lastReceived,
+	}
+
+	if silence := now.Sub(lastReceived.Time); silencePeriod > 0 && silence >= silencePeriod {
+		problems = append(problems, fmt.Sprintf("no audit events were received for %s", silence.Round(time.Second)))
+	}
+
+	if len(problems) > 0 {
+		health.Status = v1alpha1.OutputHealthStatusFailed
+		health.Message = strings.Join(problems, ", ")
+	}
+
+	return health, lastReceived
+}
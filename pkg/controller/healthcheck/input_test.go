@@ -0,0 +1,234 @@
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func kubeAPIServerDeployment(command []string, labels map[string]string, readyReplicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver", Namespace: "shoot-a"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "kube-apiserver", Command: command},
+					},
+				},
+			},
+		},
+		Status: appsv1.DeploymentStatus{ReadyReplicas: readyReplicas},
+	}
+}
+
+func TestCheckKubeAPIServer(t *testing.T) {
+	var (
+		command = []string{"/usr/local/bin/kube-apiserver", "--audit-webhook-config-file=/etc/audit-webhook/config/audit-webhook-config.yaml"}
+		labels  = map[string]string{"networking.resources.gardener.cloud/to-audit-webhook-backend-tcp-9880": "allowed"}
+	)
+
+	tests := []struct {
+		name       string
+		deployment *appsv1.Deployment
+		want       []string
+	}{
+		{
+			name:       "configured",
+			deployment: kubeAPIServerDeployment(command, labels, 1),
+		},
+		{
+			name:       "flag missing",
+			deployment: kubeAPIServerDeployment([]string{"/usr/local/bin/kube-apiserver"}, labels, 1),
+			want:       []string{"kube-apiserver is missing the flag --audit-webhook-config-file"},
+		},
+		{
+			name:       "label missing",
+			deployment: kubeAPIServerDeployment(command, nil, 1),
+			want:       []string{"kube-apiserver is missing the label networking.resources.gardener.cloud/to-audit-webhook-backend-tcp-9880"},
+		},
+		{
+			name: "container missing",
+			deployment: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "other"}}}}},
+			},
+			want: []string{"kube-apiserver container not found in kube-apiserver deployment"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, checkKubeAPIServer(tt.deployment))
+		})
+	}
+}
+
+func TestKubeAPIServerRunning(t *testing.T) {
+	assert.False(t, kubeAPIServerRunning(nil), "not created yet")
+	assert.False(t, kubeAPIServerRunning(kubeAPIServerDeployment(nil, nil, 0)), "hibernated or waking up")
+	assert.True(t, kubeAPIServerRunning(kubeAPIServerDeployment(nil, nil, 2)))
+}
+
+func TestEvaluateInput(t *testing.T) {
+	var (
+		now     = metav1.Now()
+		recent  = metav1.NewTime(now.Add(-5 * time.Minute))
+		silence = metav1.NewTime(now.Add(-20 * time.Minute))
+	)
+
+	tests := []struct {
+		name             string
+		received         float64
+		lastReceived     *metav1.Time
+		silencePeriod    time.Duration
+		problems         []string
+		want             *v1alpha1.InputHealth
+		wantLastReceived *metav1.Time
+	}{
+		{
+			name:             "observation starts",
+			lastReceived:     nil,
+			silencePeriod:    15 * time.Minute,
+			want:             &v1alpha1.InputHealth{Status: v1alpha1.OutputHealthStatusHealthy, LastReceivedTime: &now},
+			wantLastReceived: &now,
+		},
+		{
+			name:             "events received",
+			received:         10,
+			lastReceived:     &silence,
+			silencePeriod:    15 * time.Minute,
+			want:             &v1alpha1.InputHealth{Status: v1alpha1.OutputHealthStatusHealthy, LastReceivedTime: &now},
+			wantLastReceived: &now,
+		},
+		{
+			name:             "silent within the period",
+			lastReceived:     &recent,
+			silencePeriod:    15 * time.Minute,
+			want:             &v1alpha1.InputHealth{Status: v1alpha1.OutputHealthStatusHealthy, LastReceivedTime: &recent},
+			wantLastReceived: &recent,
+		},
+		{
+			name:          "silent for longer than the period",
+			lastReceived:  &silence,
+			silencePeriod: 15 * time.Minute,
+			want: &v1alpha1.InputHealth{
+				Status:           v1alpha1.OutputHealthStatusFailed,
+				Message:          "no audit events were received for 20m0s",
+				LastReceivedTime: &silence,
+			},
+			wantLastReceived: &silence,
+		},
+		{
+			name:             "silence detection disabled",
+			lastReceived:     &silence,
+			silencePeriod:    0,
+			want:             &v1alpha1.InputHealth{Status: v1alpha1.OutputHealthStatusHealthy, LastReceivedTime: &silence},
+			wantLastReceived: &silence,
+		},
+		{
+			name:          "kube-apiserver misconfigured",
+			received:      10,
+			lastReceived:  &recent,
+			silencePeriod: 15 * time.Minute,
+			problems:      []string{"kube-apiserver is missing the flag --audit-webhook-config-file"},
+			want: &v1alpha1.InputHealth{
+				Status:           v1alpha1.OutputHealthStatusFailed,
+				Message:          "kube-apiserver is missing the flag --audit-webhook-config-file",
+				LastReceivedTime: &now,
+			},
+			wantLastReceived: &now,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, lastReceived := evaluateInput(tt.received, tt.lastReceived, tt.silencePeriod, tt.problems, now)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantLastReceived, lastReceived)
+		})
+	}
+}
+
+func TestBackendHealthChecker_checkInput(t *testing.T) {
+	var (
+		received = `# TYPE fluentbit_input_records_total counter
+fluentbit_input_records_total{name="http.0"} 100
+`
+		kubeAPIServer = kubeAPIServerDeployment(
+			[]string{"--audit-webhook-config-file=/etc/audit-webhook/config/audit-webhook-config.yaml"},
+			map[string]string{"networking.resources.gardener.cloud/to-audit-webhook-backend-tcp-9880": "allowed"},
+			1,
+		)
+	)
+
+	h := &BackendHealthChecker{
+		httpClient:    newFakeClient(http.StatusOK, received, storageBody),
+		scraper:       newScraper(maxConcurrentScrapes, requestTimeout),
+		store:         newStateStore(),
+		syncPeriod:    time.Minute,
+		silencePeriod: 15 * time.Minute,
+		seedClient: fake.NewClientBuilder().WithLists(&discoveryv1.EndpointSliceList{
+			Items: []discoveryv1.EndpointSlice{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "shoot-a",
+						Labels: map[string]string{
+							"kubernetes.io/service-name": "audit-webhook-backend",
+						},
+					},
+					Endpoints: []discoveryv1.Endpoint{
+						{
+							Addresses: []string{"1.2.3.4"},
+							TargetRef: &corev1.ObjectReference{Name: "audit-webhook-backend-0", UID: "uid-0"},
+						},
+					},
+				},
+			},
+		}).Build(),
+	}
+
+	state := h.store.get("shoot-a")
+	nextCheck := func() {
+		state.lastCheck = time.Now().Add(-h.syncPeriod)
+	}
+
+	health, err := h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, kubeAPIServer, nil)
+	require.NoError(t, err)
+	require.NotNil(t, health.Input)
+	assert.Equal(t, v1alpha1.OutputHealthStatusHealthy, health.Input.Status)
+	assert.Equal(t, int64(100), health.Pods[0].InputRecords)
+
+	// the counter does not grow anymore, which is tolerated for the silence period
+	nextCheck()
+	health, err = h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, kubeAPIServer, nil)
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.OutputHealthStatusHealthy, health.Input.Status)
+
+	nextCheck()
+	state.lastInput = &metav1.Time{Time: time.Now().Add(-20 * time.Minute)}
+	health, err = h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, kubeAPIServer, nil)
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.OutputHealthStatusFailed, health.Input.Status)
+	assert.Contains(t, health.Input.Message, "no audit events were received for 20m")
+
+	// the silence period starts again when the kube-apiserver is scaled down, e.g. during hibernation
+	nextCheck()
+	health, err = h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, kubeAPIServerDeployment(nil, nil, 0), nil)
+	require.NoError(t, err)
+	assert.Nil(t, health.Input)
+	assert.Nil(t, state.lastInput)
+
+	nextCheck()
+	health, err = h.checkEndpoints(context.Background(), "shoot-a", 1024*1024*1024, kubeAPIServer, nil)
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.OutputHealthStatusHealthy, health.Input.Status)
+}
@@ -0,0 +1,104 @@
+package healthcheck
+
+import (
+	"fmt"
+	"io"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	metricInputRecords            = "fluentbit_input_records_total"
+	metricOutputRetries           = "fluentbit_output_retries_total"
+	metricOutputDroppedRecords    = "fluentbit_output_dropped_records_total"
+	metricOutputProcRecords       = "fluentbit_output_proc_records_total"
+	metricOutputAvailableCapacity = "fluentbit_output_chunk_available_capacity_percent"
+	metricBuildInfo               = "fluentbit_build_info"
+)
+
+// fluentbitMetrics contains the metrics scraped from fluent-bit's prometheus endpoint that are relevant for the health checks
+type fluentbitMetrics struct {
+	// inputRecords is the sum of the records received by all inputs
+	inputRecords float64
+	outputs      map[string]*outputMetrics
+	// version is the version of fluent-bit, empty if fluent-bit does not expose it
+	version string
+}
+
+// outputMetrics contains the metrics of a single fluent-bit output
+type outputMetrics struct {
+	outputCounters
+	// availableCapacity is the available capacity of the output's filesystem buffer in percent, nil if fluent-bit does not expose it
+	availableCapacity *float64
+}
+
+func parseMetrics(r io.Reader) (*fluentbitMetrics, error) {
+	var parser expfmt.TextParser
+
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse metrics: %w", err)
+	}
+
+	m := &fluentbitMetrics{
+		outputs: map[string]*outputMetrics{},
+	}
+
+	output := func(metric *dto.Metric) *outputMetrics {
+		name := labelValue(metric, "name")
+
+		o, ok := m.outputs[name]
+		if !ok {
+			o = &outputMetrics{}
+			m.outputs[name] = o
+		}
+
+		return o
+	}
+
+	for name, family := range families {
+		for _, metric := range family.GetMetric() {
+			switch name {
+			case metricInputRecords:
+				m.inputRecords += metricValue(metric)
+			case metricOutputRetries:
+				output(metric).retries = metricValue(metric)
+			case metricOutputDroppedRecords:
+				output(metric).droppedRecords = metricValue(metric)
+			case metricOutputProcRecords:
+				output(metric).procRecords = metricValue(metric)
+			case metricOutputAvailableCapacity:
+				v := metricValue(metric)
+				output(metric).availableCapacity = &v
+			case metricBuildInfo:
+				m.version = labelValue(metric, "version")
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func labelValue(metric *dto.Metric, name string) string {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+
+	return ""
+}
+
+func metricValue(metric *dto.Metric) float64 {
+	switch {
+	case metric.Counter != nil:
+		return metric.Counter.GetValue()
+	case metric.Gauge != nil:
+		return metric.Gauge.GetValue()
+	case metric.Untyped != nil:
+		return metric.Untyped.GetValue()
+	default:
+		return 0
+	}
+}
@@ -10,6 +10,7 @@ import (
 	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
 	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
 	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
 	"github.com/metal-stack/gardener-extension-audit/pkg/controller/audit"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -20,14 +21,23 @@ import (
 var (
 	defaultSyncPeriod = 60 * time.Second
 	// DefaultAddOptions contains configuration for the health check controller.
-	DefaultAddOptions = healthcheck.DefaultAddArgs{
-		HealthCheckConfig: extensionsconfig.HealthCheckConfig{SyncPeriod: metav1.Duration{Duration: defaultSyncPeriod}},
+	DefaultAddOptions = AddOptions{
+		DefaultAddArgs: healthcheck.DefaultAddArgs{
+			HealthCheckConfig: extensionsconfig.HealthCheckConfig{SyncPeriod: metav1.Duration{Duration: defaultSyncPeriod}},
+		},
 	}
 )
 
+// AddOptions are options to apply when adding the health check controller to the manager.
+type AddOptions struct {
+	healthcheck.DefaultAddArgs
+	// Config contains configuration for the audit controller, which is also used by the health checks.
+	Config config.ControllerConfiguration
+}
+
 // RegisterHealthChecks registers health checks for each extension resource
 // HealthChecks are grouped by extension (e.g worker), extension.type (e.g aws) and  Health Check Type (e.g SystemComponentsHealthy)
-func RegisterHealthChecks(ctx context.Context, mgr manager.Manager, opts healthcheck.DefaultAddArgs) error {
+func RegisterHealthChecks(ctx context.Context, mgr manager.Manager, opts AddOptions) error {
 	return healthcheck.DefaultRegistration(
 		ctx,
 		audit.Type,
@@ -35,7 +45,7 @@ func RegisterHealthChecks(ctx context.Context, mgr manager.Manager, opts healthc
 		func() client.ObjectList { return &extensionsv1alpha1.ExtensionList{} },
 		func() extensionsv1alpha1.Object { return &extensionsv1alpha1.Extension{} },
 		mgr,
-		opts,
+		opts.DefaultAddArgs,
 		nil,
 		[]healthcheck.ConditionTypeToHealthCheck{
 			{
@@ -48,7 +58,7 @@ func RegisterHealthChecks(ctx context.Context, mgr manager.Manager, opts healthc
 			},
 			{
 				ConditionType: string(gardencorev1beta1.ShootSystemComponentsHealthy),
-				HealthCheck:   backendHealth(opts.HealthCheckConfig.SyncPeriod.Duration),
+				HealthCheck:   backendHealth(opts.HealthCheckConfig.SyncPeriod.Duration, opts.Config),
 			},
 		},
 		sets.Set[gardencorev1beta1.ConditionType]{},
@@ -0,0 +1,195 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// requestTimeout is the timeout of a single request to fluent-bit, such that a hanging pod does not stall the health check
+	requestTimeout = 10 * time.Second
+	// maxConcurrentScrapes limits the amount of requests that are sent to fluent-bit pods at the same time across all health checks
+	maxConcurrentScrapes = 20
+	// apiPortName is the name of the port of fluent-bit's http server in the audit webhook backend service
+	apiPortName = "api"
+	// defaultAPIPort is the port of fluent-bit's http server, used in case the endpoint slice does not contain it
+	defaultAPIPort = 2020
+)
+
+// endpoint is a fluent-bit pod of the audit webhook backend
+type endpoint struct {
+	pod     string
+	uid     types.UID
+	address string
+	port    int32
+}
+
+// scrapeResult contains the metrics scraped from a single fluent-bit pod
+type scrapeResult struct {
+	pod     string
+	uid     types.UID
+	metrics *fluentbitMetrics
+	storage *storageMetrics
+}
+
+// scraper scrapes the fluent-bit pods concurrently. the amount of concurrent requests is bounded by a pool of workers
+// that is shared between all health checks of the controller.
+type scraper struct {
+	workers chan struct{}
+	timeout time.Duration
+}
+
+func newScraper(workers int, timeout time.Duration) *scraper {
+	return &scraper{
+		workers: make(chan struct{}, workers),
+		timeout: timeout,
+	}
+}
+
+func endpointsOf(endpointSliceList *discoveryv1.EndpointSliceList) []endpoint {
+	var endpoints []endpoint
+
+	for _, endpointSlice := range endpointSliceList.Items {
+		port := int32(defaultAPIPort)
+		for _, p := range endpointSlice.Ports {
+			if pointer.SafeDeref(p.Name) == apiPortName && p.Port != nil {
+				port = *p.Port
+			}
+		}
+
+		for _, e := range endpointSlice.Endpoints {
+			for _, address := range e.Addresses {
+				ep := endpoint{pod: address, address: address, port: port}
+				if e.TargetRef != nil {
+					ep.pod = e.TargetRef.Name
+					ep.uid = e.TargetRef.UID
+				}
+
+				endpoints = append(endpoints, ep)
+			}
+		}
+	}
+
+	return endpoints
+}
+
+// scrapeAll scrapes all endpoints concurrently and returns the results in the order of the endpoints.
+// if any of the endpoints cannot be scraped, the errors of all failing endpoints are returned.
+func (s *scraper) scrapeAll(ctx context.Context, httpClient *http.Client, endpoints []endpoint) ([]scrapeResult, error) {
+	var (
+		wg      sync.WaitGroup
+		results = make([]scrapeResult, len(endpoints))
+		errs    = make([]error, len(endpoints))
+	)
+
+	for i, e := range endpoints {
+		wg.Add(1)
+
+		go func(i int, e endpoint) {
+			defer wg.Done()
+
+			select {
+			case s.workers <- struct{}{}:
+				defer func() { <-s.workers }()
+			case <-ctx.Done():
+				errs[i] = fmt.Errorf("unable to scrape pod %s: %w", e.pod, ctx.Err())
+				return
+			}
+
+			result, err := s.scrape(ctx, httpClient, e)
+			if err != nil {
+				errs[i] = fmt.Errorf("unable to scrape pod %s: %w", e.pod, err)
+				return
+			}
+
+			results[i] = *result
+		}(i, e)
+	}
+
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (s *scraper) scrape(ctx context.Context, httpClient *http.Client, e endpoint) (*scrapeResult, error) {
+	host := net.JoinHostPort(e.address, strconv.Itoa(int(e.port)))
+
+	metrics, err := s.scrapeMetrics(ctx, httpClient, host)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := s.scrapeStorage(ctx, httpClient, host)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scrapeResult{
+		pod:     e.pod,
+		uid:     e.uid,
+		metrics: metrics,
+		storage: storage,
+	}, nil
+}
+
+func (s *scraper) scrapeMetrics(ctx context.Context, httpClient *http.Client, host string) (*fluentbitMetrics, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	resp, err := get(ctx, httpClient, fmt.Sprintf("http://%s/api/v2/metrics/prometheus", host))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metrics endpoint return code was %d", resp.StatusCode)
+	}
+
+	return parseMetrics(resp.Body)
+}
+
+func (s *scraper) scrapeStorage(ctx context.Context, httpClient *http.Client, host string) (*storageMetrics, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	resp, err := get(ctx, httpClient, fmt.Sprintf("http://%s/api/v1/storage", host))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("storage endpoint return code was %d", resp.StatusCode)
+	}
+
+	return parseStorage(resp.Body)
+}
+
+func get(ctx context.Context, httpClient *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create http request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to do http request: %w", err)
+	}
+
+	return resp, nil
+}
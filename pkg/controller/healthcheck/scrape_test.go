@@ -0,0 +1,241 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fluentbitPod simulates the http server of a fluent-bit pod
+type fluentbitPod struct {
+	delay      time.Duration
+	statusCode int
+	inFlight   *atomic.Int32
+	maxFlight  *atomic.Int32
+}
+
+func (p fluentbitPod) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if p.inFlight != nil {
+		current := p.inFlight.Add(1)
+		defer p.inFlight.Add(-1)
+
+		for {
+			highest := p.maxFlight.Load()
+			if current <= highest || p.maxFlight.CompareAndSwap(highest, current) {
+				break
+			}
+		}
+	}
+
+	select {
+	case <-time.After(p.delay):
+	case <-r.Context().Done():
+		return
+	}
+
+	if p.statusCode != 0 && p.statusCode != http.StatusOK {
+		w.WriteHeader(p.statusCode)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/api/v2/metrics/prometheus":
+		fmt.Fprint(w, body1)
+	case "/api/v1/storage":
+		fmt.Fprint(w, storageBody)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func startPods(t *testing.T, pods map[string]fluentbitPod) []endpoint {
+	var endpoints []endpoint
+
+	for name, pod := range pods {
+		server := httptest.NewServer(pod)
+		t.Cleanup(server.Close)
+
+		host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+		require.NoError(t, err)
+
+		p, err := strconv.Atoi(port)
+		require.NoError(t, err)
+
+		endpoints = append(endpoints, endpoint{pod: name, address: host, port: int32(p)})
+	}
+
+	return endpoints
+}
+
+func TestScraper_scrapeAll(t *testing.T) {
+	tests := []struct {
+		name        string
+		pods        map[string]fluentbitPod
+		timeout     time.Duration
+		wantErr     []string
+		maxDuration time.Duration
+	}{
+		{
+			name: "healthy pods",
+			pods: map[string]fluentbitPod{
+				"pod-0": {},
+				"pod-1": {},
+			},
+			timeout:     time.Second,
+			maxDuration: time.Second,
+		},
+		{
+			name: "slow pods are scraped in parallel",
+			pods: map[string]fluentbitPod{
+				"pod-0": {delay: 200 * time.Millisecond},
+				"pod-1": {delay: 200 * time.Millisecond},
+				"pod-2": {delay: 200 * time.Millisecond},
+			},
+			timeout:     time.Second,
+			maxDuration: 1 * time.Second,
+		},
+		{
+			name: "hanging pod runs into the timeout",
+			pods: map[string]fluentbitPod{
+				"pod-0": {},
+				"pod-1": {delay: time.Minute},
+			},
+			timeout:     100 * time.Millisecond,
+			wantErr:     []string{"unable to scrape pod pod-1", "context deadline exceeded"},
+			maxDuration: 5 * time.Second,
+		},
+		{
+			name: "failing pod",
+			pods: map[string]fluentbitPod{
+				"pod-0": {},
+				"pod-1": {statusCode: http.StatusInternalServerError},
+			},
+			timeout:     time.Second,
+			wantErr:     []string{"unable to scrape pod pod-1: metrics endpoint return code was 500"},
+			maxDuration: time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints := startPods(t, tt.pods)
+
+			s := newScraper(maxConcurrentScrapes, tt.timeout)
+
+			start := time.Now()
+			results, err := s.scrapeAll(context.Background(), &http.Client{}, endpoints)
+			assert.Less(t, time.Since(start), tt.maxDuration)
+
+			if len(tt.wantErr) > 0 {
+				require.Error(t, err)
+				for _, want := range tt.wantErr {
+					assert.ErrorContains(t, err, want)
+				}
+				assert.NotContains(t, err.Error(), "pod-0", "healthy pods must not be reported")
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, results, len(endpoints))
+			for i, result := range results {
+				assert.Equal(t, endpoints[i].pod, result.pod)
+				assert.NotNil(t, result.metrics)
+				assert.NotNil(t, result.storage)
+			}
+		})
+	}
+}
+
+func TestScraper_scrapeAllIsBounded(t *testing.T) {
+	var (
+		inFlight  atomic.Int32
+		maxFlight atomic.Int32
+		pods      = map[string]fluentbitPod{}
+	)
+
+	for i := 0; i < 10; i++ {
+		pods[fmt.Sprintf("pod-%d", i)] = fluentbitPod{delay: 20 * time.Millisecond, inFlight: &inFlight, maxFlight: &maxFlight}
+	}
+
+	s := newScraper(3, time.Second)
+
+	_, err := s.scrapeAll(context.Background(), &http.Client{}, startPods(t, pods))
+	require.NoError(t, err)
+
+	assert.LessOrEqual(t, maxFlight.Load(), int32(3))
+	assert.Greater(t, maxFlight.Load(), int32(1))
+}
+
+func TestBackendHealthChecker_namespacesDoNotBlockEachOther(t *testing.T) {
+	var (
+		slow = startPods(t, map[string]fluentbitPod{"slow": {delay: time.Minute}})[0]
+		fast = startPods(t, map[string]fluentbitPod{"fast": {}})[0]
+	)
+
+	endpointSlice := func(namespace string, e endpoint) discoveryv1.EndpointSlice {
+		return discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "audit-webhook-backend",
+				Namespace: namespace,
+				Labels: map[string]string{
+					"kubernetes.io/service-name": "audit-webhook-backend",
+				},
+			},
+			Ports: []discoveryv1.EndpointPort{
+				{Name: pointer.Pointer("http"), Port: pointer.Pointer(int32(9880))},
+				{Name: pointer.Pointer(apiPortName), Port: pointer.Pointer(e.port)},
+			},
+			Endpoints: []discoveryv1.Endpoint{
+				{
+					Addresses: []string{e.address},
+					TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: e.pod},
+				},
+			},
+		}
+	}
+
+	h := &BackendHealthChecker{
+		httpClient: &http.Client{},
+		scraper:    newScraper(maxConcurrentScrapes, 2*time.Second),
+		store:      newStateStore(),
+		thresholds: bufferThresholds{warningPercent: 70, failurePercent: 90, timeToFull: time.Hour},
+		seedClient: fake.NewClientBuilder().WithLists(&discoveryv1.EndpointSliceList{
+			Items: []discoveryv1.EndpointSlice{
+				endpointSlice("shoot-slow", slow),
+				endpointSlice("shoot-fast", fast),
+			},
+		}).Build(),
+	}
+
+	slowDone := make(chan error)
+	go func() {
+		_, err := h.checkEndpoints(context.Background(), "shoot-slow", 1024*1024*1024, nil, nil)
+		slowDone <- err
+	}()
+
+	// give the slow check the chance to acquire its lock first
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	health, err := h.checkEndpoints(context.Background(), "shoot-fast", 1024*1024*1024, nil, nil)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second, "check of another namespace must not wait for the slow namespace")
+	require.Len(t, health.Outputs, 1)
+	assert.Contains(t, h.store.states["shoot-fast"].buffers, "fast", "pods are identified by the target ref")
+
+	err = <-slowDone
+	require.ErrorContains(t, err, "unable to scrape pod slow")
+}
@@ -0,0 +1,233 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/controller/audit"
+	"github.com/metal-stack/gardener-extension-audit/pkg/metrics"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// outputCounters contains the counters of an output of a single fluent-bit pod
+type outputCounters struct {
+	retries        float64
+	droppedRecords float64
+	procRecords    float64
+}
+
+// podState contains the counters of a fluent-bit pod as seen in the last health check
+type podState struct {
+	uid          types.UID
+	inputRecords float64
+	outputs      map[string]outputCounters
+}
+
+// stateStore contains the health check states of all shoot namespaces, it is shared between all copies of the health checker
+type stateStore struct {
+	mutex  sync.Mutex
+	states map[string]*namespaceState
+	lastGC time.Time
+}
+
+// namespaceState contains the state of the health checks for a shoot namespace, the mutex is held during a check
+// such that health checks of different namespaces do not block each other
+type namespaceState struct {
+	mutex     sync.Mutex
+	lastCheck time.Time
+	pods      map[string]podState
+	lastFlush map[string]*metav1.Time
+	buffers   map[string]bufferSample
+	// lastInput is the last time audit events were received, nil if it is unknown because the observation just started
+	lastInput *metav1.Time
+	health    *v1alpha1.AuditHealth
+}
+
+func newStateStore() *stateStore {
+	return &stateStore{
+		states: map[string]*namespaceState{},
+	}
+}
+
+// get returns the state of the given namespace, the store's mutex is only held for the lookup
+func (s *stateStore) get(namespace string) *namespaceState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.states[namespace]
+	if !ok {
+		state = &namespaceState{
+			pods:      map[string]podState{},
+			lastFlush: map[string]*metav1.Time{},
+			buffers:   map[string]bufferSample{},
+		}
+		s.states[namespace] = state
+	}
+
+	return state
+}
+
+// gc removes the states of namespaces that do not contain an audit extension anymore, it runs at most once per interval
+func (s *stateStore) gc(ctx context.Context, c client.Client, interval time.Duration) error {
+	s.mutex.Lock()
+	if time.Since(s.lastGC) < interval {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.lastGC = time.Now()
+	s.mutex.Unlock()
+
+	extensions := &extensionsv1alpha1.ExtensionList{}
+	if err := c.List(ctx, extensions); err != nil {
+		return fmt.Errorf("unable to list extensions: %w", err)
+	}
+
+	namespaces := sets.New[string]()
+	for _, ex := range extensions.Items {
+		if ex.Spec.Type == audit.Type && ex.DeletionTimestamp == nil {
+			namespaces.Insert(ex.Namespace)
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for namespace := range s.states {
+		if !namespaces.Has(namespace) {
+			delete(s.states, namespace)
+			metrics.DeleteShoot(namespace)
+		}
+	}
+
+	return nil
+}
+
+// restore initializes the state from the health that was persisted in the extension status, such that the counters
+// of the last health check survive restarts of the extension and leader changes. outdated counters are not restored
+// as the increase of the counters would not refer to the last health check anymore.
+func (s *namespaceState) restore(health *v1alpha1.AuditHealth, maxAge time.Duration, now time.Time) {
+	if health == nil || !s.lastCheck.IsZero() || now.Sub(health.LastCheckTime.Time) > maxAge {
+		return
+	}
+
+	s.lastCheck = health.LastCheckTime.Time
+
+	for _, pod := range health.Pods {
+		state := podState{
+			uid:          pod.UID,
+			inputRecords: float64(pod.InputRecords),
+			outputs:      map[string]outputCounters{},
+		}
+
+		for _, output := range pod.Outputs {
+			state.outputs[output.Name] = outputCounters{
+				retries:        float64(output.Retries),
+				droppedRecords: float64(output.DroppedRecords),
+				procRecords:    float64(output.ProcessedRecords),
+			}
+		}
+
+		s.pods[pod.Name] = state
+	}
+
+	for _, output := range health.Outputs {
+		if output.LastSuccessfulFlushTime != nil {
+			s.lastFlush[output.Name] = output.LastSuccessfulFlushTime
+		}
+	}
+
+	if health.Input != nil {
+		s.lastInput = health.Input.LastReceivedTime
+	}
+}
+
+// previous returns the state of a pod from the last health check, which is compared to the current counters.
+// if there is no previous state because nothing is known about the namespace yet, false is returned.
+func (s *namespaceState) previous(pod string, uid types.UID) (podState, bool) {
+	last, known := s.pods[pod]
+
+	switch {
+	case known && last.uid == uid:
+		return last, true
+	case known || !s.lastCheck.IsZero():
+		// the pod was recreated or added since the last health check, so its counters started from zero
+		return podState{}, true
+	default:
+		return podState{}, false
+	}
+}
+
+// baseline returns the counters of an output of a pod from the last health check, see previous
+func (s *namespaceState) baseline(pod string, uid types.UID, output string) (outputCounters, bool) {
+	last, ok := s.previous(pod, uid)
+	// an output that did not exist before was added with a restart of fluent-bit, so it started from zero
+	return last.outputs[output], ok
+}
+
+// podCounters returns the counters of all pods in order to persist them in the extension status
+func (s *namespaceState) podCounters() []v1alpha1.PodCounters {
+	var result []v1alpha1.PodCounters
+
+	for name, pod := range s.pods {
+		counters := v1alpha1.PodCounters{
+			Name:         name,
+			UID:          pod.uid,
+			InputRecords: int64(pod.inputRecords),
+		}
+
+		for output, c := range pod.outputs {
+			counters.Outputs = append(counters.Outputs, v1alpha1.OutputCounters{
+				Name:             output,
+				Retries:          int64(c.retries),
+				DroppedRecords:   int64(c.droppedRecords),
+				ProcessedRecords: int64(c.procRecords),
+			})
+		}
+
+		sort.Slice(counters.Outputs, func(i, j int) bool {
+			return counters.Outputs[i].Name < counters.Outputs[j].Name
+		})
+
+		result = append(result, counters)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// increase returns the increase of the counters since the last health check
+func (c outputCounters) increase(last outputCounters) outputCounters {
+	return outputCounters{
+		retries:        counterIncrease(c.retries, last.retries),
+		droppedRecords: counterIncrease(c.droppedRecords, last.droppedRecords),
+		procRecords:    counterIncrease(c.procRecords, last.procRecords),
+	}
+}
+
+func (c outputCounters) add(other outputCounters) outputCounters {
+	return outputCounters{
+		retries:        c.retries + other.retries,
+		droppedRecords: c.droppedRecords + other.droppedRecords,
+		procRecords:    c.procRecords + other.procRecords,
+	}
+}
+
+// counterIncrease returns the increase of a counter since the last health check. fluent-bit's counters start from zero
+// when it restarts, so a decreasing counter means that the whole current value was added since the restart.
+func counterIncrease(current, last float64) float64 {
+	if current < last {
+		return current
+	}
+	return current - last
+}
@@ -0,0 +1,166 @@
+package healthcheck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNamespaceState_baseline(t *testing.T) {
+	known := podState{
+		uid: "uid-0",
+		outputs: map[string]outputCounters{
+			"splunk": {retries: 10, procRecords: 100},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		state     *namespaceState
+		pod       string
+		uid       types.UID
+		output    string
+		want      outputCounters
+		wantFound bool
+	}{
+		{
+			name:   "nothing known about the namespace",
+			state:  &namespaceState{pods: map[string]podState{}},
+			pod:    "pod-0",
+			uid:    "uid-0",
+			output: "splunk",
+		},
+		{
+			name:      "same pod",
+			state:     &namespaceState{lastCheck: time.Now(), pods: map[string]podState{"pod-0": known}},
+			pod:       "pod-0",
+			uid:       "uid-0",
+			output:    "splunk",
+			want:      outputCounters{retries: 10, procRecords: 100},
+			wantFound: true,
+		},
+		{
+			name:      "recreated pod starts from zero",
+			state:     &namespaceState{lastCheck: time.Now(), pods: map[string]podState{"pod-0": known}},
+			pod:       "pod-0",
+			uid:       "uid-1",
+			output:    "splunk",
+			wantFound: true,
+		},
+		{
+			name:      "added pod starts from zero",
+			state:     &namespaceState{lastCheck: time.Now(), pods: map[string]podState{"pod-0": known}},
+			pod:       "pod-1",
+			uid:       "uid-1",
+			output:    "splunk",
+			wantFound: true,
+		},
+		{
+			name:      "added output starts from zero",
+			state:     &namespaceState{lastCheck: time.Now(), pods: map[string]podState{"pod-0": known}},
+			pod:       "pod-0",
+			uid:       "uid-0",
+			output:    "clusterforwarding",
+			wantFound: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := tt.state.baseline(tt.pod, tt.uid, tt.output)
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOutputCounters_increase(t *testing.T) {
+	last := outputCounters{retries: 10, droppedRecords: 5, procRecords: 100}
+
+	assert.Equal(t, outputCounters{retries: 5, procRecords: 50}, outputCounters{retries: 15, droppedRecords: 5, procRecords: 150}.increase(last))
+	assert.Equal(t, outputCounters{retries: 3, droppedRecords: 1, procRecords: 20}, outputCounters{retries: 3, droppedRecords: 1, procRecords: 20}.increase(last), "counters were reset by a restart")
+}
+
+func TestNamespaceState_restore(t *testing.T) {
+	var (
+		now       = time.Now()
+		lastFlush = metav1.NewTime(now.Add(-10 * time.Minute))
+		health    = &v1alpha1.AuditHealth{
+			LastCheckTime: metav1.NewTime(now.Add(-1 * time.Minute)),
+			Outputs: []v1alpha1.OutputHealth{
+				{Name: "splunk", LastSuccessfulFlushTime: &lastFlush},
+			},
+			Input: &v1alpha1.InputHealth{LastReceivedTime: &lastFlush},
+			Pods: []v1alpha1.PodCounters{
+				{
+					Name:         "pod-0",
+					UID:          "uid-0",
+					InputRecords: 4,
+					Outputs: []v1alpha1.OutputCounters{
+						{Name: "splunk", Retries: 1, DroppedRecords: 2, ProcessedRecords: 3},
+					},
+				},
+			},
+		}
+	)
+
+	state := newStateStore().get("shoot-a")
+	state.restore(health, 2*time.Minute, now)
+
+	assert.Equal(t, health.LastCheckTime.Time, state.lastCheck)
+	assert.Equal(t, map[string]podState{
+		"pod-0": {uid: "uid-0", inputRecords: 4, outputs: map[string]outputCounters{"splunk": {retries: 1, droppedRecords: 2, procRecords: 3}}},
+	}, state.pods)
+	assert.Equal(t, &lastFlush, state.lastFlush["splunk"])
+	assert.Equal(t, &lastFlush, state.lastInput)
+	assert.Nil(t, state.health, "the check must not be skipped")
+	assert.Equal(t, health.Pods, state.podCounters())
+
+	outdated := newStateStore().get("shoot-a")
+	outdated.restore(health, 30*time.Second, now)
+	assert.True(t, outdated.lastCheck.IsZero())
+	assert.Empty(t, outdated.pods)
+
+	checked := newStateStore().get("shoot-a")
+	checked.lastCheck = now
+	checked.restore(health, 2*time.Minute, now)
+	assert.Empty(t, checked.pods, "the state of the running extension takes precedence")
+}
+
+func TestStateStore_gc(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, extensionsv1alpha1.AddToScheme(scheme))
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&extensionsv1alpha1.Extension{
+			ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: "shoot-a"},
+			Spec:       extensionsv1alpha1.ExtensionSpec{DefaultSpec: extensionsv1alpha1.DefaultSpec{Type: "audit"}},
+		},
+		&extensionsv1alpha1.Extension{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "shoot-b"},
+			Spec:       extensionsv1alpha1.ExtensionSpec{DefaultSpec: extensionsv1alpha1.DefaultSpec{Type: "other"}},
+		},
+	).Build()
+
+	s := newStateStore()
+	s.get("shoot-a")
+	s.get("shoot-b")
+	s.get("shoot-c")
+
+	require.NoError(t, s.gc(context.Background(), c, time.Minute))
+	assert.Len(t, s.states, 1)
+	assert.Contains(t, s.states, "shoot-a")
+
+	s.get("shoot-c")
+
+	require.NoError(t, s.gc(context.Background(), c, time.Minute))
+	assert.Contains(t, s.states, "shoot-c", "gc only runs once per interval")
+}
@@ -0,0 +1,233 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// chunkSize is the maximum size of a fluent-bit filesystem chunk in bytes
+	chunkSize = 2048000
+
+	defaultBufferWarningThresholdPercent = 70
+	defaultBufferFailureThresholdPercent = 90
+	defaultTimeToFullWarningThreshold    = 1 * time.Hour
+)
+
+// storageMetrics is the response of fluent-bit's /api/v1/storage endpoint, which requires storage.metrics to be enabled
+type storageMetrics struct {
+	StorageLayer struct {
+		Chunks struct {
+			TotalChunks int64 `json:"total_chunks"`
+			MemChunks   int64 `json:"mem_chunks"`
+			FsChunks    int64 `json:"fs_chunks"`
+		} `json:"chunks"`
+	} `json:"storage_layer"`
+	InputChunks map[string]inputChunks `json:"input_chunks"`
+}
+
+// inputChunks are the chunks of a single input as reported by fluent-bit's /api/v1/storage endpoint
+type inputChunks struct {
+	Status struct {
+		MemSize string `json:"mem_size"`
+	} `json:"status"`
+	Chunks struct {
+		Total int64 `json:"total"`
+		Up    int64 `json:"up"`
+		Down  int64 `json:"down"`
+	} `json:"chunks"`
+}
+
+// bufferThresholds contain the thresholds at which the buffer health degrades
+type bufferThresholds struct {
+	warningPercent int32
+	failurePercent int32
+	timeToFull     time.Duration
+}
+
+// bufferSample is the buffer usage of a pod as seen in the last health check
+type bufferSample struct {
+	usedBytes int64
+	time      time.Time
+}
+
+func parseStorage(r io.Reader) (*storageMetrics, error) {
+	m := &storageMetrics{}
+
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, fmt.Errorf("unable to parse storage metrics: %w", err)
+	}
+
+	return m, nil
+}
+
+// usedBytes returns the amount of bytes buffered by the inputs. fluent-bit only reports the size of the chunks that are
+// up in memory, the chunks that are down on the filesystem are accounted with the maximum chunk size. chunks are only
+// moved out of memory while new records are written to other chunks, so they are usually full.
+func (m *storageMetrics) usedBytes() (int64, error) {
+	var used int64
+
+	for name, input := range m.InputChunks {
+		size, err := parseHumanReadableSize(input.Status.MemSize)
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse memory size of input %q: %w", name, err)
+		}
+
+		used += size + input.Chunks.Down*chunkSize
+	}
+
+	return used, nil
+}
+
+// parseHumanReadableSize parses the sizes printed by fluent-bit, which are either plain bytes like "512b" or
+// binary multiples with one decimal like "1.5K" or "2.0M"
+func parseHumanReadableSize(s string) (int64, error) {
+	const units = "bKMGTPE"
+
+	if s == "" {
+		return 0, nil
+	}
+
+	exponent := strings.IndexByte(units, s[len(s)-1])
+	if exponent < 0 {
+		return 0, fmt.Errorf("unknown unit in size %q", s)
+	}
+
+	value, err := strconv.ParseFloat(s[:len(s)-1], 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	return int64(value * math.Pow(1024, float64(exponent))), nil
+}
+
+// bufferCapacity returns the amount of bytes that can be buffered by a single audit webhook backend pod.
+// it is the size of the volume unless the filesystem buffer limits of the backends, including the ones enforced
+// by the operator, are smaller in total.
+func bufferCapacity(auditConfig *v1alpha1.AuditConfig, enforced *v1alpha1.AuditBackends) (int64, error) {
+	var capacity int64
+	if auditConfig.Persistence.Size != nil {
+		capacity = auditConfig.Persistence.Size.Value()
+	}
+
+	var limits []string
+	if backends := auditConfig.Backends; backends != nil {
+		if pointer.SafeDeref(backends.ClusterForwarding).Enabled {
+			limits = append(limits, pointer.SafeDeref(backends.ClusterForwarding.FilesystemBufferSize))
+		}
+		if pointer.SafeDeref(backends.Splunk).Enabled {
+			limits = append(limits, pointer.SafeDeref(backends.Splunk.FilesystemBufferSize))
+		}
+	}
+	if enforced != nil && pointer.SafeDeref(enforced.Splunk).Enabled {
+		limits = append(limits, pointer.SafeDeref(enforced.Splunk.FilesystemBufferSize))
+	}
+
+	var total int64
+	for _, limit := range limits {
+		if limit == "" {
+			continue
+		}
+
+		q, err := resource.ParseQuantity(limit)
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse filesystem buffer size %q: %w", limit, err)
+		}
+
+		total += q.Value()
+	}
+
+	if total > 0 && (capacity == 0 || total < capacity) {
+		capacity = total
+	}
+
+	if capacity <= 0 {
+		return 0, fmt.Errorf("unable to determine the capacity of the buffer")
+	}
+
+	return capacity, nil
+}
+
+// evaluateBuffer reports the buffer of the fullest pod and estimates the time until the first pod's buffer runs full
+// from the growth since the last health check.
+func evaluateBuffer(used map[string]int64, last map[string]bufferSample, capacity int64, thresholds bufferThresholds, now time.Time) (*v1alpha1.BufferHealth, map[string]bufferSample) {
+	var (
+		next       = map[string]bufferSample{}
+		health     = &v1alpha1.BufferHealth{Status: v1alpha1.OutputHealthStatusHealthy}
+		fullestPod string
+		growingPod string
+		timeToFull *time.Duration
+	)
+
+	pods := make([]string, 0, len(used))
+	for pod := range used {
+		pods = append(pods, pod)
+	}
+	sort.Strings(pods)
+
+	for _, pod := range pods {
+		bytes := used[pod]
+
+		next[pod] = bufferSample{usedBytes: bytes, time: now}
+
+		usage := int32(math.Min(math.Round(float64(bytes)*100/float64(capacity)), 100))
+		if fullestPod == "" || usage > health.UsagePercent {
+			fullestPod = pod
+			health.UsagePercent = usage
+		}
+
+		previous, ok := last[pod]
+		if !ok || bytes <= previous.usedBytes || !now.After(previous.time) {
+			continue
+		}
+
+		rate := float64(bytes-previous.usedBytes) / now.Sub(previous.time).Seconds()
+		remaining := time.Duration(math.Max(float64(capacity-bytes), 0)/rate) * time.Second
+
+		if timeToFull == nil || remaining < *timeToFull {
+			growingPod = pod
+			timeToFull = &remaining
+		}
+	}
+
+	if timeToFull != nil {
+		health.EstimatedTimeToFull = &metav1.Duration{Duration: *timeToFull}
+	}
+
+	var (
+		failures []string
+		warnings []string
+	)
+
+	switch {
+	case health.UsagePercent >= thresholds.failurePercent:
+		failures = append(failures, fmt.Sprintf("buffer of pod %s is %d%% full", fullestPod, health.UsagePercent))
+	case health.UsagePercent >= thresholds.warningPercent:
+		warnings = append(warnings, fmt.Sprintf("buffer of pod %s is %d%% full", fullestPod, health.UsagePercent))
+	}
+	if timeToFull != nil && *timeToFull < thresholds.timeToFull {
+		warnings = append(warnings, fmt.Sprintf("buffer of pod %s is estimated to be full in %s", growingPod, timeToFull.Round(time.Minute)))
+	}
+
+	switch {
+	case len(failures) > 0:
+		health.Status = v1alpha1.OutputHealthStatusFailed
+	case len(warnings) > 0:
+		health.Status = v1alpha1.OutputHealthStatusWarning
+	}
+
+	health.Message = strings.Join(append(failures, warnings...), ", ")
+
+	return health, next
+}
@@ -0,0 +1,262 @@
+package healthcheck
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseStorage(t *testing.T) {
+	m, err := parseStorage(strings.NewReader(storageBody))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(10), m.StorageLayer.Chunks.FsChunks)
+
+	// 1M for the two chunks that are up and full chunks for the eight chunks that are down
+	used, err := m.usedBytes()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1024*1024+8*chunkSize), used)
+
+	// all chunks are moved out of memory while the backend is unavailable
+	m, err = parseStorage(strings.NewReader(`{"input_chunks":{"http.0":{"status":{"mem_size":"0b"},"chunks":{"total":5,"up":0,"down":5}},"emitter_for_rewrite_tag.0":{"status":{"mem_size":"0b"},"chunks":{"total":3,"up":0,"down":3}}}}`))
+	require.NoError(t, err)
+	used, err = m.usedBytes()
+	require.NoError(t, err)
+	assert.Equal(t, int64(8*chunkSize), used)
+
+	_, err = parseStorage(strings.NewReader("not json"))
+	require.Error(t, err)
+
+	m, err = parseStorage(strings.NewReader(`{"input_chunks":{"http.0":{"status":{"mem_size":"a lot"}}}}`))
+	require.NoError(t, err)
+	_, err = m.usedBytes()
+	require.Error(t, err)
+}
+
+func TestParseHumanReadableSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    int64
+		wantErr bool
+	}{
+		{size: "", want: 0},
+		{size: "0b", want: 0},
+		{size: "512b", want: 512},
+		{size: "1.5K", want: 1536},
+		{size: "2.0M", want: 2 * 1024 * 1024},
+		{size: "1.0G", want: 1024 * 1024 * 1024},
+		{size: "12", wantErr: true},
+		{size: "M", wantErr: true},
+		{size: "-1.0M", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			got, err := parseHumanReadableSize(tt.size)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBufferCapacity(t *testing.T) {
+	tests := []struct {
+		name        string
+		auditConfig *v1alpha1.AuditConfig
+		enforced    *v1alpha1.AuditBackends
+		want        int64
+		wantErr     bool
+	}{
+		{
+			name: "volume size without backends",
+			auditConfig: &v1alpha1.AuditConfig{
+				Persistence: v1alpha1.AuditPersistence{Size: pointer.Pointer(resource.MustParse("1Gi"))},
+			},
+			want: 1024 * 1024 * 1024,
+		},
+		{
+			name: "backend limits are smaller than the volume",
+			auditConfig: &v1alpha1.AuditConfig{
+				Persistence: v1alpha1.AuditPersistence{Size: pointer.Pointer(resource.MustParse("1Gi"))},
+				Backends: &v1alpha1.AuditBackends{
+					Splunk:            &v1alpha1.AuditBackendSplunk{Enabled: true, FilesystemBufferSize: pointer.Pointer("300M")},
+					ClusterForwarding: &v1alpha1.AuditBackendClusterForwarding{Enabled: true, FilesystemBufferSize: pointer.Pointer("200M")},
+				},
+			},
+			want: 500 * 1000 * 1000,
+		},
+		{
+			name: "disabled backends are ignored",
+			auditConfig: &v1alpha1.AuditConfig{
+				Persistence: v1alpha1.AuditPersistence{Size: pointer.Pointer(resource.MustParse("1Gi"))},
+				Backends: &v1alpha1.AuditBackends{
+					Splunk:            &v1alpha1.AuditBackendSplunk{Enabled: false, FilesystemBufferSize: pointer.Pointer("300M")},
+					ClusterForwarding: &v1alpha1.AuditBackendClusterForwarding{Enabled: true, FilesystemBufferSize: pointer.Pointer("200M")},
+				},
+			},
+			want: 200 * 1000 * 1000,
+		},
+		{
+			name: "enforced backends are added to the backend limits",
+			auditConfig: &v1alpha1.AuditConfig{
+				Persistence: v1alpha1.AuditPersistence{Size: pointer.Pointer(resource.MustParse("1Gi"))},
+				Backends: &v1alpha1.AuditBackends{
+					Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, FilesystemBufferSize: pointer.Pointer("300M")},
+				},
+			},
+			enforced: &v1alpha1.AuditBackends{
+				Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, FilesystemBufferSize: pointer.Pointer("400M")},
+			},
+			want: 700 * 1000 * 1000,
+		},
+		{
+			name: "volume is smaller than the backend limits",
+			auditConfig: &v1alpha1.AuditConfig{
+				Persistence: v1alpha1.AuditPersistence{Size: pointer.Pointer(resource.MustParse("100M"))},
+				Backends: &v1alpha1.AuditBackends{
+					Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, FilesystemBufferSize: pointer.Pointer("900M")},
+				},
+			},
+			want: 100 * 1000 * 1000,
+		},
+		{
+			name: "invalid buffer size",
+			auditConfig: &v1alpha1.AuditConfig{
+				Persistence: v1alpha1.AuditPersistence{Size: pointer.Pointer(resource.MustParse("1Gi"))},
+				Backends: &v1alpha1.AuditBackends{
+					Splunk: &v1alpha1.AuditBackendSplunk{Enabled: true, FilesystemBufferSize: pointer.Pointer("a lot")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:        "no size at all",
+			auditConfig: &v1alpha1.AuditConfig{},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bufferCapacity(tt.auditConfig, tt.enforced)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEvaluateBuffer(t *testing.T) {
+	var (
+		now        = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		before     = now.Add(-1 * time.Minute)
+		thresholds = bufferThresholds{
+			warningPercent: 70,
+			failurePercent: 90,
+			timeToFull:     time.Hour,
+		}
+	)
+
+	tests := []struct {
+		name     string
+		used     map[string]int64
+		last     map[string]bufferSample
+		capacity int64
+		want     *v1alpha1.BufferHealth
+	}{
+		{
+			name:     "empty buffers",
+			used:     map[string]int64{"pod-0": 0, "pod-1": 0},
+			capacity: 1000,
+			want: &v1alpha1.BufferHealth{
+				Status: v1alpha1.OutputHealthStatusHealthy,
+			},
+		},
+		{
+			name:     "fullest pod exceeds the warning threshold",
+			used:     map[string]int64{"pod-0": 100, "pod-1": 750},
+			capacity: 1000,
+			want: &v1alpha1.BufferHealth{
+				Status:       v1alpha1.OutputHealthStatusWarning,
+				Message:      "buffer of pod pod-1 is 75% full",
+				UsagePercent: 75,
+			},
+		},
+		{
+			name:     "fullest pod exceeds the failure threshold",
+			used:     map[string]int64{"pod-0": 950, "pod-1": 750},
+			capacity: 1000,
+			want: &v1alpha1.BufferHealth{
+				Status:       v1alpha1.OutputHealthStatusFailed,
+				Message:      "buffer of pod pod-0 is 95% full",
+				UsagePercent: 95,
+			},
+		},
+		{
+			name:     "usage is capped",
+			used:     map[string]int64{"pod-0": 2000},
+			capacity: 1000,
+			want: &v1alpha1.BufferHealth{
+				Status:       v1alpha1.OutputHealthStatusFailed,
+				Message:      "buffer of pod pod-0 is 100% full",
+				UsagePercent: 100,
+			},
+		},
+		{
+			name:     "slowly growing buffer",
+			used:     map[string]int64{"pod-0": 110, "pod-1": 0},
+			last:     map[string]bufferSample{"pod-0": {usedBytes: 100, time: before}},
+			capacity: 1000,
+			want: &v1alpha1.BufferHealth{
+				Status:              v1alpha1.OutputHealthStatusHealthy,
+				UsagePercent:        11,
+				EstimatedTimeToFull: &metav1.Duration{Duration: 89 * time.Minute},
+			},
+		},
+		{
+			name:     "fast growing buffer",
+			used:     map[string]int64{"pod-0": 200, "pod-1": 300},
+			last:     map[string]bufferSample{"pod-0": {usedBytes: 100, time: before}, "pod-1": {usedBytes: 290, time: before}},
+			capacity: 1000,
+			want: &v1alpha1.BufferHealth{
+				Status:              v1alpha1.OutputHealthStatusWarning,
+				Message:             "buffer of pod pod-0 is estimated to be full in 8m0s",
+				UsagePercent:        30,
+				EstimatedTimeToFull: &metav1.Duration{Duration: 8 * time.Minute},
+			},
+		},
+		{
+			name:     "shrinking buffer",
+			used:     map[string]int64{"pod-0": 100},
+			last:     map[string]bufferSample{"pod-0": {usedBytes: 500, time: before}},
+			capacity: 1000,
+			want: &v1alpha1.BufferHealth{
+				Status:       v1alpha1.OutputHealthStatusHealthy,
+				UsagePercent: 10,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, next := evaluateBuffer(tt.used, tt.last, tt.capacity, thresholds, now)
+			assert.Equal(t, tt.want, got)
+
+			require.Len(t, next, len(tt.used))
+			for pod, used := range tt.used {
+				assert.Equal(t, bufferSample{usedBytes: used, time: now}, next[pod])
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+package fluentbitconfig
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change is a semantic difference between two documents
+type Change struct {
+	// Path identifies the changed part of the document, e.g. "service", "output splunk" or "filter modify#0"
+	Path string
+	// Key is the key of the changed entries, it is empty if the whole part was added or removed
+	Key string
+	// Old are the values before the change, they are empty if the key or part was added
+	Old []string
+	// New are the values after the change, they are empty if the key or part was removed
+	New []string
+}
+
+// String returns the change in a human readable form, prefixed with + for additions, - for removals and ~ for
+// modifications
+func (c Change) String() string {
+	prefix := "~"
+	switch {
+	case len(c.Old) == 0:
+		prefix = "+"
+	case len(c.New) == 0:
+		prefix = "-"
+	}
+
+	if c.Key == "" {
+		values := c.New
+		if prefix == "-" {
+			values = c.Old
+		}
+
+		var b strings.Builder
+		b.WriteString(prefix + " " + c.Path)
+		for _, v := range values {
+			b.WriteString("\n    " + v)
+		}
+
+		return b.String()
+	}
+
+	switch prefix {
+	case "+":
+		return fmt.Sprintf("+ %s: %s: %s", c.Path, c.Key, formatValues(c.New))
+	case "-":
+		return fmt.Sprintf("- %s: %s: %s", c.Path, c.Key, formatValues(c.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s: %s -> %s", c.Path, c.Key, formatValues(c.Old), formatValues(c.New))
+	}
+}
+
+func formatValues(values []string) string {
+	if len(values) == 1 {
+		return strconv.Quote(values[0])
+	}
+
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, strconv.Quote(v))
+	}
+
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// Changes are the semantic differences between two documents
+type Changes []Change
+
+// String returns the changes in a human readable form, one change per line
+func (c Changes) String() string {
+	lines := make([]string, 0, len(c))
+	for _, change := range c {
+		lines = append(lines, change.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Diff returns the semantic differences between the documents. The order of the keys of a section, their case and
+// whitespace around keys and values are ignored, the order of the values of the same key is not, as it matters e.g.
+// for the rules of a modify filter. Sections are matched by their name and alias, sections without an alias by their
+// position among the sections with the same name and plugin. The order of the includes is ignored.
+func Diff(from, to Document) Changes {
+	var (
+		changes  Changes
+		oldParts = normalize(from)
+		newParts = normalize(to)
+		paths    []string
+		seen     = map[string]bool{}
+	)
+
+	for _, parts := range []normalized{oldParts, newParts} {
+		for _, p := range parts.paths {
+			if !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+
+	for _, p := range paths {
+		o, inOld := oldParts.parts[p]
+		n, inNew := newParts.parts[p]
+
+		switch {
+		case !inOld:
+			changes = append(changes, Change{Path: p, New: n.lines()})
+		case !inNew:
+			changes = append(changes, Change{Path: p, Old: o.lines()})
+		default:
+			var keys []string
+			keys = append(keys, o.keys...)
+			for _, key := range n.keys {
+				if _, ok := o.values[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+
+			for _, key := range keys {
+				if !equalValues(o.values[key], n.values[key]) {
+					changes = append(changes, Change{Path: p, Key: key, Old: o.values[key], New: n.values[key]})
+				}
+			}
+		}
+	}
+
+	oldIncludes, newIncludes := sortedIncludes(from.Includes), sortedIncludes(to.Includes)
+	if !equalValues(oldIncludes, newIncludes) {
+		changes = append(changes, Change{Path: "includes", Key: "@INCLUDE", Old: oldIncludes, New: newIncludes})
+	}
+
+	return changes
+}
+
+// part is a normalized section with the values grouped by their lower case key
+type part struct {
+	keys   []string
+	values map[string][]string
+}
+
+func (p *part) add(s Section) {
+	for _, e := range s {
+		key := strings.ToLower(strings.TrimSpace(e.Key))
+		if _, ok := p.values[key]; !ok {
+			p.keys = append(p.keys, key)
+		}
+
+		p.values[key] = append(p.values[key], strings.TrimSpace(e.Value))
+	}
+}
+
+func (p part) lines() []string {
+	var lines []string
+	for _, key := range p.keys {
+		for _, v := range p.values[key] {
+			lines = append(lines, strings.TrimSpace(key+" "+v))
+		}
+	}
+
+	return lines
+}
+
+type normalized struct {
+	paths []string
+	parts map[string]*part
+}
+
+func (n *normalized) part(p string) *part {
+	if existing, ok := n.parts[p]; ok {
+		return existing
+	}
+
+	n.paths = append(n.paths, p)
+	n.parts[p] = &part{values: map[string][]string{}}
+
+	return n.parts[p]
+}
+
+// normalize returns the parts of the document keyed by their path. service and plugins sections are merged as they
+// occur only once in the configuration of fluent-bit.
+func normalize(d Document) normalized {
+	var (
+		n     = normalized{parts: map[string]*part{}}
+		index = map[string]int{}
+	)
+
+	if len(d.Variables) > 0 {
+		n.part("variables").add(d.Variables)
+	}
+
+	for _, s := range d.Sections {
+		name := strings.ToLower(s.Name)
+
+		var p string
+		switch {
+		case s.Name == "SERVICE" || s.Name == "PLUGINS":
+			p = name
+		default:
+			if alias, ok := s.Entries.Get("alias"); ok {
+				p = name + " " + strings.TrimSpace(alias)
+				break
+			}
+
+			plugin, _ := s.Entries.Get("name")
+			prefix := strings.TrimSpace(name + " " + strings.TrimSpace(plugin))
+			p = prefix + "#" + strconv.Itoa(index[prefix])
+			index[prefix]++
+		}
+
+		n.part(p).add(s.Entries)
+
+		for i, processor := range s.Processors {
+			n.part(p + " processor " + strconv.Itoa(i)).add(processor)
+		}
+	}
+
+	return n
+}
+
+func sortedIncludes(includes []Include) []string {
+	var result []string
+	for _, include := range includes {
+		result = append(result, strings.TrimSpace(string(include)))
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FileDiff contains the changes of a file
+type FileDiff struct {
+	Name    string
+	Added   bool
+	Removed bool
+	// Changes are the semantic changes of a fluent-bit configuration, parsers or plugins file. Other files like lua
+	// scripts are compared by their content.
+	Changes Changes
+}
+
+// String returns the changes of the file in a human readable form
+func (f FileDiff) String() string {
+	switch {
+	case f.Added:
+		return "+ " + f.Name
+	case f.Removed:
+		return "- " + f.Name
+	}
+
+	lines := []string{"~ " + f.Name}
+	for _, line := range strings.Split(f.Changes.String(), "\n") {
+		lines = append(lines, "    "+line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Diff returns the differences between the files and the given files, sorted by the file names. Files ending with
+// .conf are parsed in the classic format, files ending with .yaml or .yml in the yaml format.
+func (f Files) Diff(to Files) ([]FileDiff, error) {
+	var names []string
+	for name := range f {
+		names = append(names, name)
+	}
+	for name := range to {
+		if _, ok := f[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diffs []FileDiff
+
+	for _, name := range names {
+		o, inOld := f[name]
+		n, inNew := to[name]
+
+		switch {
+		case !inOld:
+			diffs = append(diffs, FileDiff{Name: name, Added: true})
+			continue
+		case !inNew:
+			diffs = append(diffs, FileDiff{Name: name, Removed: true})
+			continue
+		}
+
+		parse := parserFor(name)
+		if parse == nil {
+			if o != n {
+				diffs = append(diffs, FileDiff{Name: name, Changes: Changes{{Path: "content", Key: "content", Old: []string{o}, New: []string{n}}}})
+			}
+			continue
+		}
+
+		oldDocument, err := parse(o)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse old %q: %w", name, err)
+		}
+		newDocument, err := parse(n)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse new %q: %w", name, err)
+		}
+
+		if changes := Diff(oldDocument, newDocument); len(changes) > 0 {
+			diffs = append(diffs, FileDiff{Name: name, Changes: changes})
+		}
+	}
+
+	return diffs, nil
+}
+
+// ParseFile parses the content of a file in the format indicated by its extension
+func ParseFile(name, content string) (Document, error) {
+	parse := parserFor(name)
+	if parse == nil {
+		return Document{}, fmt.Errorf("file %q is not a fluent-bit configuration", name)
+	}
+
+	return parse(content)
+}
+
+func parserFor(name string) func(string) (Document, error) {
+	switch path.Ext(name) {
+	case ".conf":
+		return Parse
+	case ".yaml", ".yml":
+		return ParseYAML
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,260 @@
+package fluentbitconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{
+			name: "order of keys, case and whitespace are ignored",
+			from: `
+@SET cluster=shoot
+[SERVICE]
+    flush 1
+    log_level info
+
+[OUTPUT]
+    name  stdout
+    alias log
+    match audit
+
+@INCLUDE a.conf
+@INCLUDE b.conf`,
+			to: `@set cluster = shoot
+[service]
+	Log_Level   info
+	flush 1
+[OUTPUT]
+  Match audit
+  name stdout
+  alias log
+@INCLUDE b.conf
+@INCLUDE a.conf
+`,
+		},
+		{
+			name: "changed, added and removed keys",
+			from: `
+[SERVICE]
+    flush 1
+    log_level info
+
+[OUTPUT]
+    name splunk
+    alias splunk
+    host a.example.com
+    tls on`,
+			to: `
+[SERVICE]
+    flush 5
+
+[OUTPUT]
+    name splunk
+    alias splunk
+    host b.example.com
+    tls on
+    tls.ca_file /backends/splunk/certs/ca.crt`,
+			want: `~ service: flush: "1" -> "5"
+- service: log_level: "info"
+~ output splunk: host: "a.example.com" -> "b.example.com"
++ output splunk: tls.ca_file: "/backends/splunk/certs/ca.crt"`,
+		},
+		{
+			name: "order of values of the same key matters",
+			from: `
+[FILTER]
+    name modify
+    add a 1
+    add b 2`,
+			to: `
+[FILTER]
+    name modify
+    add b 2
+    add a 1`,
+			want: `~ filter modify#0: add: ["a 1", "b 2"] -> ["b 2", "a 1"]`,
+		},
+		{
+			name: "added and removed sections, variables and includes",
+			from: `
+[OUTPUT]
+    name stdout
+    alias log
+
+[FILTER]
+    name modify
+    add a 1
+
+@INCLUDE *.backend.conf`,
+			to: `
+@SET cluster=shoot
+
+[OUTPUT]
+    name null
+    alias null`,
+			want: `- output log
+    name stdout
+    alias log
+- filter modify#0
+    name modify
+    add a 1
++ variables
+    cluster shoot
++ output null
+    name null
+    alias null
+- includes: @INCLUDE: "*.backend.conf"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, err := Parse(tt.from)
+			require.NoError(t, err)
+			to, err := Parse(tt.to)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, Diff(from, to).String())
+		})
+	}
+}
+
+func TestDiff_Processors(t *testing.T) {
+	from, err := ParseYAML(`
+pipeline:
+  outputs:
+    - name: splunk
+      alias: splunk
+      processors:
+        logs:
+          - name: modify
+            add: cluster a`)
+	require.NoError(t, err)
+
+	to, err := ParseYAML(`
+pipeline:
+  outputs:
+    - name: splunk
+      alias: splunk
+      processors:
+        logs:
+          - name: modify
+            add: cluster b`)
+	require.NoError(t, err)
+
+	assert.Equal(t, `~ output splunk processor 0: add: "cluster a" -> "cluster b"`, Diff(from, to).String())
+}
+
+func TestFiles_Diff(t *testing.T) {
+	from := Files{
+		"fluent-bit.conf":   "[SERVICE]\n    flush 1",
+		"null.backend.conf": "[OUTPUT]\n    name null",
+		"redact.lua":        "function redact() end",
+		"log.backend.yaml":  "pipeline:\n  outputs:\n    - name: stdout",
+	}
+	to := Files{
+		"fluent-bit.conf":     "[SERVICE]\n  flush   1",
+		"redact.lua":          "function redact(tag) end",
+		"log.backend.yaml":    "pipeline:\n  outputs:\n    - name: stdout\n      match: audit",
+		"splunk.backend.conf": "[OUTPUT]\n    name splunk",
+	}
+
+	diffs, err := from.Diff(to)
+	require.NoError(t, err)
+
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.String())
+	}
+
+	assert.Equal(t, []string{
+		"~ log.backend.yaml\n    + output stdout#0: match: \"audit\"",
+		"- null.backend.conf",
+		"~ redact.lua\n    ~ content: content: \"function redact() end\" -> \"function redact(tag) end\"",
+		"+ splunk.backend.conf",
+	}, got)
+
+	_, err = Files{"a.conf": "flush 1"}.Diff(Files{"a.conf": "[SERVICE]"})
+	require.EqualError(t, err, `unable to parse old "a.conf": line 1: entry "flush 1" is not part of a section`)
+}
+
+func TestParseConfig(t *testing.T) {
+	got, err := ParseConfig(`
+@SET cluster=shoot
+[SERVICE]
+    flush 1
+[INPUT]
+    name http
+[FILTER]
+    name modify
+    add cluster ${cluster}
+[OUTPUT]
+    name stdout
+@INCLUDE *.backend.conf`)
+	require.NoError(t, err)
+
+	assert.Equal(t, Config{
+		Variables: Section{}.Add("cluster", "shoot"),
+		Service:   Section{}.Add("flush", "1"),
+		Input:     []Section{Section{}.Add("name", "http")},
+		Filter:    []Section{Section{}.Add("name", "modify").Add("add", "cluster ${cluster}")},
+		Output:    []Section{Section{}.Add("name", "stdout")},
+		Includes:  []Include{"*.backend.conf"},
+	}, got)
+
+	rendered, err := got.Generate()
+	require.NoError(t, err)
+	assert.Equal(t, `@SET cluster=shoot
+
+[SERVICE]
+    flush 1
+
+[INPUT]
+    name http
+
+[FILTER]
+    name modify
+    add cluster ${cluster}
+
+[OUTPUT]
+    name stdout
+
+@INCLUDE *.backend.conf`, rendered)
+
+	_, err = ParseConfig("[PARSER]\n    name json")
+	require.EqualError(t, err, `section 0: "PARSER" is not part of a configuration`)
+
+	_, err = ParseConfig("@SET cluster")
+	require.EqualError(t, err, `line 1: invalid variable "cluster", expected name=value`)
+
+	_, err = Config{Variables: Section{}.Add("a-b", "c")}.Generate()
+	require.ErrorContains(t, err, `variable 0: name "a-b" must only contain letters, digits and underscores`)
+}
+
+func TestDocument_Config_Processors(t *testing.T) {
+	config := Config{
+		Output:     Sections(StdoutOutput{OutputOptions: OutputOptions{Alias: "log", Match: "audit"}}),
+		Processors: map[string][]Section{"log": Sections(ModifyFilter{}.Add("a", "b"))},
+		Variables:  Section{}.Add("cluster", "shoot"),
+	}
+
+	rendered, err := config.GenerateYAML()
+	require.NoError(t, err)
+
+	parsed, err := ParseYAML(rendered)
+	require.NoError(t, err)
+
+	got, err := parsed.Config()
+	require.NoError(t, err)
+	assert.Equal(t, config, got)
+
+	_, err = Document{Sections: []NamedSection{{Name: "OUTPUT", Entries: Section{}.Add("name", "stdout"), Processors: []Section{{}}}}}.Config()
+	require.EqualError(t, err, "section 0: processors of output without alias are not supported")
+}
@@ -0,0 +1,246 @@
+package fluentbitconfig
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Document is a file in the classic fluent-bit configuration format, e.g. the main configuration, a parsers file or
+// a plugins file
+type Document struct {
+	// Variables are set by @SET commands, they can be referenced by ${name} in the values of the entries
+	Variables Section
+	// Environment contains the names of the environment variables of fluent-bit that can be referenced by ${name}
+	// in the values of the entries, they are not rendered
+	Environment []string
+	Sections    []NamedSection
+	Includes    []Include
+}
+
+// NamedSection is a section with its name, e.g. SERVICE, INPUT or PARSER
+type NamedSection struct {
+	Name    string
+	Entries Section
+	// Processors are applied to the logs of an input or output before they are passed on, they are only supported
+	// by the yaml format
+	Processors []Section
+}
+
+// Validate returns an error if the document can not be rendered safely
+func (d Document) Validate() error {
+	var (
+		errs       []error
+		index      = map[string]int{}
+		references = slices.Clone(d.Environment)
+	)
+
+	for _, v := range d.Variables {
+		references = append(references, v.Key)
+	}
+
+	for i, v := range d.Variables {
+		if v.Key == "" || strings.IndexFunc(v.Key, func(r rune) bool { return !isVariableRune(r) }) >= 0 {
+			errs = append(errs, fmt.Errorf("variable %d: name %q must only contain letters, digits and underscores", i, v.Key))
+		}
+		if strings.IndexFunc(v.Value, unicode.IsControl) >= 0 {
+			errs = append(errs, fmt.Errorf("variable %d: value of %q must not contain control characters", i, v.Key))
+		}
+		if err := validateValue(v.Value, references); err != nil {
+			errs = append(errs, fmt.Errorf("variable %d: value of %q %w", i, v.Key, err))
+		}
+	}
+
+	for _, s := range d.Sections {
+		i := index[s.Name]
+		index[s.Name]++
+
+		if s.Name == "" || strings.IndexFunc(s.Name, func(r rune) bool { return !unicode.IsUpper(r) && r != '_' }) >= 0 {
+			errs = append(errs, fmt.Errorf("section %q must only contain upper case letters and underscores", s.Name))
+			continue
+		}
+
+		if err := s.Entries.Validate(references...); err != nil {
+			errs = append(errs, fmt.Errorf("%s %d: %w", strings.ToLower(s.Name), i, err))
+		}
+
+		if len(s.Processors) > 0 && s.Name != "INPUT" && s.Name != "OUTPUT" {
+			errs = append(errs, fmt.Errorf("%s %d: processors are only supported for inputs and outputs", strings.ToLower(s.Name), i))
+		}
+		for j, p := range s.Processors {
+			if err := p.Validate(references...); err != nil {
+				errs = append(errs, fmt.Errorf("%s %d: processor %d: %w", strings.ToLower(s.Name), i, j, err))
+			}
+		}
+	}
+
+	for i, include := range d.Includes {
+		if strings.TrimSpace(string(include)) == "" || strings.IndexFunc(string(include), unicode.IsControl) >= 0 {
+			errs = append(errs, fmt.Errorf("include %d: path %q must not be empty or contain control characters", i, include))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Generate renders the document in the classic fluent-bit configuration format. The entries of the sections are
+// rendered in their order, keys and values are trimmed.
+func (d Document) Generate() (string, error) {
+	if err := d.Validate(); err != nil {
+		return "", fmt.Errorf("invalid fluent-bit configuration: %w", err)
+	}
+
+	var b strings.Builder
+
+	for _, v := range d.Variables {
+		b.WriteString("@SET " + v.Key + "=" + strings.TrimSpace(v.Value) + "\n")
+	}
+
+	for _, s := range d.Sections {
+		if len(s.Processors) > 0 {
+			return "", fmt.Errorf("processors of %s are only supported by the %s format", strings.ToLower(s.Name), FormatYAML)
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+
+		b.WriteString("[" + s.Name + "]\n")
+		for _, e := range s.Entries {
+			b.WriteString("    " + strings.TrimSpace(e.Key))
+			if value := strings.TrimSpace(e.Value); value != "" {
+				b.WriteString(" " + value)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if len(d.Includes) > 0 {
+		b.WriteString("\n")
+		for _, include := range d.Includes {
+			b.WriteString("@INCLUDE " + strings.TrimSpace(string(include)) + "\n")
+		}
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+// Parse parses a document in the classic fluent-bit configuration format. Empty lines and comments are skipped, keys
+// and values are trimmed.
+func Parse(content string) (Document, error) {
+	var (
+		d       Document
+		current = -1
+	)
+
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		lineNumber := i + 1
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return d, fmt.Errorf("line %d: invalid section header %q", lineNumber, line)
+			}
+
+			d.Sections = append(d.Sections, NamedSection{Name: strings.ToUpper(strings.TrimSpace(line[1 : len(line)-1]))})
+			current = len(d.Sections) - 1
+
+		case strings.HasPrefix(line, "@"):
+			command, argument := splitKeyValue(line)
+
+			switch strings.ToUpper(command) {
+			case "@INCLUDE":
+				d.Includes = append(d.Includes, Include(argument))
+			case "@SET":
+				key, value, ok := strings.Cut(argument, "=")
+				if !ok {
+					return d, fmt.Errorf("line %d: invalid variable %q, expected name=value", lineNumber, argument)
+				}
+
+				d.Variables = d.Variables.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+			default:
+				return d, fmt.Errorf("line %d: unsupported command %q", lineNumber, command)
+			}
+
+		default:
+			if current < 0 {
+				return d, fmt.Errorf("line %d: entry %q is not part of a section", lineNumber, line)
+			}
+
+			key, value := splitKeyValue(line)
+			d.Sections[current].Entries = d.Sections[current].Entries.Add(key, value)
+		}
+	}
+
+	return d, nil
+}
+
+// Config returns the document as configuration. It fails for sections that are not part of the main configuration
+// like parsers and for processors of inputs and outputs without an alias, by which they could be referenced.
+func (d Document) Config() (Config, error) {
+	c := Config{
+		Variables: d.Variables,
+		Includes:  d.Includes,
+	}
+
+	for i, s := range d.Sections {
+		switch s.Name {
+		case "SERVICE":
+			c.Service = append(c.Service, s.Entries...)
+		case "INPUT":
+			c.Input = append(c.Input, s.Entries)
+		case "FILTER":
+			c.Filter = append(c.Filter, s.Entries)
+		case "OUTPUT":
+			c.Output = append(c.Output, s.Entries)
+		default:
+			return Config{}, fmt.Errorf("section %d: %q is not part of a configuration", i, s.Name)
+		}
+
+		if len(s.Processors) == 0 {
+			continue
+		}
+
+		alias, ok := s.Entries.Get("alias")
+		if !ok {
+			return Config{}, fmt.Errorf("section %d: processors of %s without alias are not supported", i, strings.ToLower(s.Name))
+		}
+
+		if c.Processors == nil {
+			c.Processors = map[string][]Section{}
+		}
+		c.Processors[alias] = s.Processors
+	}
+
+	return c, nil
+}
+
+// ParseConfig parses a configuration in the classic fluent-bit configuration format
+func ParseConfig(content string) (Config, error) {
+	d, err := Parse(content)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return d.Config()
+}
+
+func isVariableRune(r rune) bool {
+	return r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+}
+
+// splitKeyValue splits a trimmed line at the first whitespace into the key and the trimmed value
+func splitKeyValue(line string) (string, string) {
+	i := strings.IndexFunc(line, unicode.IsSpace)
+	if i < 0 {
+		return line, ""
+	}
+
+	return line[:i], strings.TrimSpace(line[i:])
+}
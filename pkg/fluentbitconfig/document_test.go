@@ -0,0 +1,194 @@
+package fluentbitconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		document Document
+		want     string
+	}{
+		{
+			name: "config",
+			document: Config{
+				Service: Section{}.Add("flush", "1").Add("parsers_file", "/config/parsers.conf"),
+				Input:   Sections(HTTPInput{StorageType: "filesystem"}),
+				Filter: Sections(
+					ModifyFilter{Match: "*"}.Add("a", "1").Add("b", "2"),
+					LuaFilter{Match: "audit", Script: "/config/redact.lua", Call: "redact"},
+				),
+				Output:   Sections(StdoutOutput{OutputOptions: OutputOptions{Match: "audit"}}),
+				Includes: []Include{"*.backend.conf", "extra.conf"},
+			}.Document(),
+			want: `[SERVICE]
+    flush 1
+    parsers_file /config/parsers.conf
+
+[INPUT]
+    name http
+    storage.type filesystem
+
+[FILTER]
+    name modify
+    match *
+    add a 1
+    add b 2
+
+[FILTER]
+    name lua
+    match audit
+    script /config/redact.lua
+    call redact
+
+[OUTPUT]
+    name stdout
+    match audit
+
+@INCLUDE *.backend.conf
+@INCLUDE extra.conf`,
+		},
+		{
+			name: "parsers",
+			document: ParsersFile{
+				Parser: Sections(
+					Parser{Name: "json", Format: "json", TimeKey: "time", TimeFormat: "%Y-%m-%dT%H:%M:%S.%LZ", TimeKeep: true},
+					Parser{Name: "user", Format: "regex", Regex: `^(?<user>[^ ]+) (?<verb>\w+)$`},
+				),
+				MultilineParser: Sections(MultilineParser{
+					Name:         "stacktrace",
+					Type:         "regex",
+					FlushTimeout: 1000,
+					Rules: []MultilineRule{
+						{State: "start_state", Regex: `^\w+Exception`, NextState: "cont"},
+						{State: "cont", Regex: `^\s+at`, NextState: "cont"},
+					},
+				}),
+			}.Document(),
+			want: `[PARSER]
+    name json
+    format json
+    time_key time
+    time_format %Y-%m-%dT%H:%M:%S.%LZ
+    time_keep on
+
+[PARSER]
+    name user
+    format regex
+    regex ^(?<user>[^ ]+) (?<verb>\w+)$
+
+[MULTILINE_PARSER]
+    name stacktrace
+    type regex
+    flush_timeout 1000
+    rule "start_state" "/^\w+Exception/" "cont"
+    rule "cont" "/^\s+at/" "cont"`,
+		},
+		{
+			name:     "plugins",
+			document: PluginsFile{Paths: []string{"/plugins/out_a.so", "/plugins/out_b.so"}}.Document(),
+			want: `[PLUGINS]
+    path /plugins/out_a.so
+    path /plugins/out_b.so`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.document.Generate()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			parsed, err := Parse(got)
+			require.NoError(t, err)
+			assert.Equal(t, tt.document, parsed)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	got, err := Parse(`
+# comment
+[service]
+	flush   1
+  log_level  info
+
+[OUTPUT]
+    name null
+    tls.verify
+@include other.conf
+`)
+	require.NoError(t, err)
+	assert.Equal(t, Document{
+		Sections: []NamedSection{
+			{Name: "SERVICE", Entries: Section{{Key: "flush", Value: "1"}, {Key: "log_level", Value: "info"}}},
+			{Name: "OUTPUT", Entries: Section{{Key: "name", Value: "null"}, {Key: "tls.verify", Value: ""}}},
+		},
+		Includes: []Include{"other.conf"},
+	}, got)
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "entry outside of section",
+			content: "flush 1",
+			wantErr: `line 1: entry "flush 1" is not part of a section`,
+		},
+		{
+			name:    "invalid section header",
+			content: "[SERVICE\n    flush 1",
+			wantErr: `line 1: invalid section header "[SERVICE"`,
+		},
+		{
+			name:    "unsupported command",
+			content: "[SERVICE]\n@UNKNOWN x",
+			wantErr: `line 2: unsupported command "@UNKNOWN"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.content)
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestDocument_InvalidSectionName(t *testing.T) {
+	_, err := Document{Sections: []NamedSection{{Name: "OUTPUT]\n[INPUT"}}}.Generate()
+	require.ErrorContains(t, err, "must only contain upper case letters and underscores")
+}
+
+// FuzzRoundTrip ensures that every document that can be rendered is parsed into the same document
+func FuzzRoundTrip(f *testing.F) {
+	f.Add("PARSER", "name", "json")
+	f.Add("MULTILINE_PARSER", "rule", `"start_state" "/^\w+/" "cont"`)
+	f.Add("OUTPUT", "match", "  *  ")
+	f.Add("FILTER", "add", "key\tvalue")
+	f.Add("SERVICE", "#flush", "1")
+
+	f.Fuzz(func(t *testing.T, name, key, value string) {
+		document := Document{
+			Sections: []NamedSection{{Name: name, Entries: Section{}.Add(key, value)}},
+		}
+
+		rendered, err := document.Generate()
+		if err != nil {
+			return
+		}
+
+		parsed, err := Parse(rendered)
+		require.NoError(t, err)
+		require.Len(t, parsed.Sections, 1)
+		assert.Equal(t, name, parsed.Sections[0].Name)
+		assert.Equal(t, Section{}.Add(strings.TrimSpace(key), strings.TrimSpace(value)), parsed.Sections[0].Entries)
+	})
+}
@@ -0,0 +1,47 @@
+package fluentbitconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Files are the files of a fluent-bit configuration keyed by their file name, e.g. the main configuration, included
+// configurations, parsers files and lua scripts. They are placed into the same directory.
+type Files map[string]string
+
+// Add adds a file, it returns an error if the name is not a plain file name or a file with the same name was already added
+func (f Files) Add(name, content string) error {
+	if name == "" || strings.ContainsAny(name, "/\\") || name == "." || name == ".." {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+	if _, ok := f[name]; ok {
+		return fmt.Errorf("file %q was already added", name)
+	}
+
+	f[name] = content
+
+	return nil
+}
+
+// AddDocument renders the document and adds it as file
+func (f Files) AddDocument(name string, d Document) error {
+	content, err := d.Generate()
+	if err != nil {
+		return fmt.Errorf("unable to render %q: %w", name, err)
+	}
+
+	return f.Add(name, content)
+}
+
+// Checksums returns the sha256 checksums of the files keyed by their file name
+func (f Files) Checksums() map[string]string {
+	checksums := make(map[string]string, len(f))
+	for name, content := range f {
+		sum := sha256.Sum256([]byte(content))
+		checksums[name] = hex.EncodeToString(sum[:])
+	}
+
+	return checksums
+}
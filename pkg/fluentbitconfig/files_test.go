@@ -0,0 +1,29 @@
+package fluentbitconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiles(t *testing.T) {
+	files := Files{}
+
+	require.NoError(t, files.AddDocument("fluent-bit.conf", Config{Service: Section{}.Add("parsers_file", "parsers.conf")}.Document()))
+	require.NoError(t, files.AddDocument("parsers.conf", ParsersFile{Parser: Sections(Parser{Name: "json", Format: "json"})}.Document()))
+	require.NoError(t, files.Add("redact.lua", "function redact(tag, timestamp, record)\n  return 0, timestamp, record\nend\n"))
+
+	require.EqualError(t, files.Add("parsers.conf", ""), `file "parsers.conf" was already added`)
+	require.EqualError(t, files.Add("../fluent-bit.conf", ""), `invalid file name "../fluent-bit.conf"`)
+	require.ErrorContains(t, files.AddDocument("invalid.conf", Config{Service: Section{}.Add("flush", "1\n[OUTPUT]")}.Document()), `unable to render "invalid.conf"`)
+
+	checksums := files.Checksums()
+	require.Len(t, checksums, 3)
+	assert.Len(t, checksums["redact.lua"], 64)
+
+	files["redact.lua"] = "function redact(tag, timestamp, record)\n  return 2, timestamp, record\nend\n"
+	changed := files.Checksums()
+	assert.NotEqual(t, checksums["redact.lua"], changed["redact.lua"])
+	assert.Equal(t, checksums["parsers.conf"], changed["parsers.conf"])
+}
@@ -1,64 +1,205 @@
 package fluentbitconfig
 
 import (
-	"bytes"
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
-	"text/template"
+	"unicode"
 )
 
 type (
 	Config struct {
-		Service  Service
-		Input    []Input
-		Filter   []Filter
-		Output   []Output
-		Includes []Include
+		// Variables are set by @SET commands, they can be referenced by ${name} in the values of the entries
+		Variables Section
+		// Environment contains the names of the environment variables of fluent-bit that can be referenced by ${name}
+		// in the values of the entries, they are not rendered
+		Environment []string
+		Service     Section
+		Input       []Section
+		Filter      []Section
+		Output      []Section
+		Includes    []Include
+		// Processors are the processors of the inputs and outputs keyed by their alias, they are only supported by the
+		// yaml format
+		Processors map[string][]Section
+	}
+
+	// Section contains the entries of a section in the order in which they are rendered. A key may occur several
+	// times, e.g. for the rules of a modify filter.
+	Section []Entry
+
+	Entry struct {
+		Key   string
+		Value string
 	}
 
-	Service map[string]string
-	Input   map[string]string
-	Filter  map[string]string
-	Output  map[string]string
 	Include string
 )
 
-var t = func() *template.Template {
-	t, err := template.New("").Funcs(template.FuncMap{
-		"trim": strings.TrimSpace,
-	}).Parse(`
-{{ if .Service }}
-[SERVICE]
-{{- range $key, $value := .Service }}
-    {{ $key | trim }} {{ $value | trim }}{{ end }}{{ end }}
-{{ range $input := .Input }}
-[INPUT]
-{{- range $key, $value := $input }}
-    {{ $key | trim }} {{ $value | trim }}{{ end }}{{ end }}
-{{ range $filter := .Filter }}
-[FILTER]
-{{- range $key, $value := $filter }}
-    {{ $key | trim }} {{ $value | trim }}{{ end }}{{ end }}
-{{ range $output := .Output }}
-[OUTPUT]
-{{- range $key, $value := $output }}
-    {{ $key | trim }} {{ $value | trim }}{{ end }}{{ end }}
-
-{{ range $include := .Includes }}@INCLUDE {{ $include }}{{ end }}
-`)
-	if err != nil {
-		panic(err)
-	}
-
-	return t
-}()
-
-func (c Config) Generate() string {
-	var buf bytes.Buffer
-	err := t.Execute(&buf, c)
-	if err != nil {
-		// as this function is tested, this should never happen...
-		panic(err)
-	}
-
-	return strings.TrimSpace(buf.String())
+// Add returns the section with an entry for the given key and value appended
+func (s Section) Add(key, value string) Section {
+	return append(s, Entry{Key: key, Value: value})
+}
+
+// addIfSet returns the section with an entry for the given key and value appended if the value is not empty
+func (s Section) addIfSet(key, value string) Section {
+	if value == "" {
+		return s
+	}
+
+	return s.Add(key, value)
+}
+
+// Get returns the value of the first entry with the given key, keys are compared case-insensitive like fluent-bit does
+func (s Section) Get(key string) (string, bool) {
+	for _, e := range s {
+		if strings.EqualFold(e.Key, key) {
+			return e.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// Values returns the values of all entries with the given key
+func (s Section) Values(key string) []string {
+	var values []string
+	for _, e := range s {
+		if strings.EqualFold(e.Key, key) {
+			values = append(values, e.Value)
+		}
+	}
+
+	return values
+}
+
+// Validate returns an error if an entry can not be rendered safely. keys must not be empty or contain whitespace and
+// neither keys nor values may contain control characters like newlines, which would allow to inject further entries
+// or sections into the configuration. as fluent-bit expands variables in any value, values may only reference the
+// given variables by ${name} and must not contain @INCLUDE or @SET commands.
+func (s Section) Validate(references ...string) error {
+	var errs []error
+
+	for i, e := range s {
+		key := strings.TrimSpace(e.Key)
+
+		switch {
+		case key == "":
+			errs = append(errs, fmt.Errorf("entry %d: key must not be empty", i))
+		case strings.IndexFunc(key, unicode.IsSpace) >= 0 || strings.IndexFunc(key, unicode.IsControl) >= 0:
+			errs = append(errs, fmt.Errorf("entry %d: key %q must not contain whitespace or control characters", i, key))
+		case strings.HasPrefix(key, "[") || strings.HasPrefix(key, "@") || strings.HasPrefix(key, "#"):
+			errs = append(errs, fmt.Errorf("entry %d: key %q must not start with %q", i, key, key[:1]))
+		}
+
+		if strings.IndexFunc(e.Value, unicode.IsControl) >= 0 {
+			errs = append(errs, fmt.Errorf("entry %d: value of key %q must not contain control characters", i, key))
+		}
+		if err := validateValue(e.Value, references); err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: value of key %q %w", i, key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateValue returns an error if the value contains a command or references a variable that is not one of the
+// given references
+func validateValue(value string, references []string) error {
+	for _, field := range strings.Fields(value) {
+		if strings.EqualFold(field, "@INCLUDE") || strings.EqualFold(field, "@SET") {
+			return fmt.Errorf("must not contain the command %q", field)
+		}
+	}
+
+	for rest := value; ; {
+		i := strings.Index(rest, "${")
+		if i < 0 {
+			return nil
+		}
+
+		name, after, ok := strings.Cut(rest[i+2:], "}")
+		if !ok {
+			return fmt.Errorf("must not contain an unterminated variable reference")
+		}
+		if !slices.Contains(references, name) {
+			return fmt.Errorf("must not reference the undeclared variable %q", name)
+		}
+
+		rest = after
+	}
+}
+
+// Document returns the configuration as document
+func (c Config) Document() Document {
+	d := Document{Variables: c.Variables, Environment: c.Environment}
+
+	if len(c.Service) > 0 {
+		d.Sections = append(d.Sections, NamedSection{Name: "SERVICE", Entries: c.Service})
+	}
+	for _, s := range c.Input {
+		d.Sections = append(d.Sections, NamedSection{Name: "INPUT", Entries: s, Processors: c.processors(s)})
+	}
+	for _, s := range c.Filter {
+		d.Sections = append(d.Sections, NamedSection{Name: "FILTER", Entries: s})
+	}
+	for _, s := range c.Output {
+		d.Sections = append(d.Sections, NamedSection{Name: "OUTPUT", Entries: s, Processors: c.processors(s)})
+	}
+	d.Includes = c.Includes
+
+	return d
+}
+
+// processors returns the processors of the input or output with the alias of the given section
+func (c Config) processors(s Section) []Section {
+	alias, ok := s.Get("alias")
+	if !ok {
+		return nil
+	}
+
+	return c.Processors[alias]
+}
+
+// Validate returns an error if the configuration can not be rendered safely
+func (c Config) Validate() error {
+	var errs []error
+
+	aliases := map[string]bool{}
+	for _, s := range append(append([]Section{}, c.Input...), c.Output...) {
+		if alias, ok := s.Get("alias"); ok {
+			aliases[alias] = true
+		}
+	}
+	for alias := range c.Processors {
+		if !aliases[alias] {
+			errs = append(errs, fmt.Errorf("processors reference unknown input or output %q", alias))
+		}
+	}
+
+	if err := c.Document().Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Generate renders the configuration in the classic fluent-bit configuration format
+func (c Config) Generate() (string, error) {
+	return c.Render(FormatClassic)
+}
+
+// GenerateYAML renders the configuration in the yaml fluent-bit configuration format
+func (c Config) GenerateYAML() (string, error) {
+	return c.Render(FormatYAML)
+}
+
+// Render renders the configuration in the given format, the classic format is used if the format is empty
+func (c Config) Render(format Format) (string, error) {
+	if err := c.Validate(); err != nil {
+		return "", fmt.Errorf("invalid fluent-bit configuration: %w", err)
+	}
+
+	return c.Document().Render(format)
 }
@@ -1,43 +1,45 @@
 package fluentbitconfig
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConfig_Generate(t *testing.T) {
 	tests := []struct {
-		name   string
-		config *Config
-		want   string
+		name    string
+		config  *Config
+		want    string
+		wantErr string
 	}{
 		{
 			name: "full config",
 			config: &Config{
-				Service: map[string]string{
-					"flush":     "1",
-					"log_level": "info",
+				Service: Section{
+					{Key: "flush", Value: "1"},
+					{Key: "log_level", Value: "info"},
 				},
-				Input: []Input{
-					map[string]string{
-						"name": "http",
-					},
+				Input: []Section{
+					{{Key: "name", Value: "http"}},
 				},
-				Filter: []Filter{
+				Filter: []Section{
 					{
-						"name":  "modify",
-						"match": "*",
-						"add":   "cluster devcluster",
+						{Key: "name", Value: "modify"},
+						{Key: "match", Value: "*"},
+						{Key: "add", Value: "cluster devcluster"},
+						{Key: "add", Value: "project dev"},
 					},
 				},
-				Output: []Output{
-					map[string]string{
-						"name":  "    stdout  ",
-						"match": "*",
+				Output: []Section{
+					{
+						{Key: "name", Value: "    stdout  "},
+						{Key: "match", Value: "*"},
 					},
-					map[string]string{
-						"name": "null",
+					{
+						{Key: "name", Value: "null"},
 					},
 				},
 				Includes: []Include{
@@ -52,13 +54,15 @@ func TestConfig_Generate(t *testing.T) {
     name http
 
 [FILTER]
-    add cluster devcluster
-    match *
     name modify
+    match *
+    add cluster devcluster
+    add project dev
 
 [OUTPUT]
-    match *
     name stdout
+    match *
+
 [OUTPUT]
     name null
 
@@ -67,22 +71,176 @@ func TestConfig_Generate(t *testing.T) {
 		{
 			name: "only output section",
 			config: &Config{
-				Output: []Output{
-					map[string]string{
-						"name":  "stdout",
-						"match": "*",
-					},
+				Output: []Section{
+					Section{}.Add("name", "stdout").Add("match", "*"),
 				},
 			},
 			want: `[OUTPUT]
-    match *
-    name stdout`,
+    name stdout
+    match *`,
+		},
+		{
+			name: "newline in value",
+			config: &Config{
+				Output: []Section{
+					Section{}.Add("name", "stdout").Add("match", "*\n[OUTPUT]\n    name http"),
+				},
+			},
+			wantErr: `invalid fluent-bit configuration: output 0: entry 1: value of key "match" must not contain control characters`,
+		},
+		{
+			name: "whitespace in key",
+			config: &Config{
+				Filter: []Section{
+					Section{}.Add("name", "modify").Add("add key", "value"),
+				},
+			},
+			wantErr: `invalid fluent-bit configuration: filter 0: entry 1: key "add key" must not contain whitespace or control characters`,
+		},
+		{
+			name: "section header as key",
+			config: &Config{
+				Service: Section{}.Add("[OUTPUT]", ""),
+			},
+			wantErr: `invalid fluent-bit configuration: service 0: entry 0: key "[OUTPUT]" must not start with "["`,
+		},
+		{
+			name: "empty key",
+			config: &Config{
+				Input: []Section{Section{}.Add(" ", "http")},
+			},
+			wantErr: `invalid fluent-bit configuration: input 0: entry 0: key must not be empty`,
+		},
+		{
+			name: "declared variables",
+			config: &Config{
+				Variables:   Section{}.Add("cluster", "shoot"),
+				Environment: []string{"SPLUNK_HEC_TOKEN"},
+				Filter: []Section{
+					Section{}.Add("name", "modify").Add("add", "cluster ${cluster}"),
+				},
+				Output: []Section{
+					Section{}.Add("name", "splunk").Add("splunk_token", "${SPLUNK_HEC_TOKEN}").Add("event_index", "$index"),
+				},
+			},
+			want: `@SET cluster=shoot
+
+[FILTER]
+    name modify
+    add cluster ${cluster}
+
+[OUTPUT]
+    name splunk
+    splunk_token ${SPLUNK_HEC_TOKEN}
+    event_index $index`,
+		},
+		{
+			name: "undeclared environment variable",
+			config: &Config{
+				Environment: []string{"SPLUNK_HEC_TOKEN"},
+				Output: []Section{
+					Section{}.Add("name", "splunk").Add("splunk_token", "${SPLUNK_HEC_TOKEN}").Add("event_index", "${ENFORCED_SPLUNK_HEC_TOKEN}"),
+				},
+			},
+			wantErr: `invalid fluent-bit configuration: output 0: entry 2: value of key "event_index" must not reference the undeclared variable "ENFORCED_SPLUNK_HEC_TOKEN"`,
+		},
+		{
+			name: "unterminated variable reference",
+			config: &Config{
+				Output: []Section{
+					Section{}.Add("name", "splunk").Add("host", "splunk.example.com${"),
+				},
+			},
+			wantErr: `invalid fluent-bit configuration: output 0: entry 1: value of key "host" must not contain an unterminated variable reference`,
+		},
+		{
+			name: "environment variable in variable",
+			config: &Config{
+				Variables: Section{}.Add("token", "${SPLUNK_HEC_TOKEN}"),
+			},
+			wantErr: `invalid fluent-bit configuration: variable 0: value of "token" must not reference the undeclared variable "SPLUNK_HEC_TOKEN"`,
+		},
+		{
+			name: "include command in value",
+			config: &Config{
+				Filter: []Section{
+					Section{}.Add("name", "modify").Add("add", "@INCLUDE /etc/passwd"),
+				},
+			},
+			wantErr: `invalid fluent-bit configuration: filter 0: entry 1: value of key "add" must not contain the command "@INCLUDE"`,
+		},
+		{
+			name: "set command in value",
+			config: &Config{
+				Filter: []Section{
+					Section{}.Add("name", "modify").Add("add", "a @set b=c"),
+				},
+			},
+			wantErr: `invalid fluent-bit configuration: filter 0: entry 1: value of key "add" must not contain the command "@set"`,
+		},
+		{
+			name: "newline in include",
+			config: &Config{
+				Includes: []Include{"*.conf\n@INCLUDE /etc/passwd"},
+			},
+			wantErr: `invalid fluent-bit configuration: include 0: path "*.conf\n@INCLUDE /etc/passwd" must not be empty or contain control characters`,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := tt.config.Generate()
+			got, err := tt.config.Generate()
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
 			assert.Equal(t, tt.want, got)
 		})
 	}
 }
+
+func TestSection_Get(t *testing.T) {
+	s := Section{}.Add("name", "modify").Add("Add", "a 1").Add("add", "b 2")
+
+	v, ok := s.Get("ADD")
+	assert.True(t, ok)
+	assert.Equal(t, "a 1", v)
+
+	_, ok = s.Get("rename")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"a 1", "b 2"}, s.Values("add"))
+}
+
+// FuzzConfig_Generate ensures that no key or value is able to add entries or sections to the configuration
+func FuzzConfig_Generate(f *testing.F) {
+	f.Add("match", "*")
+	f.Add("match", "*\n[OUTPUT]\n    name http")
+	f.Add("add", "key value\r\n@INCLUDE /etc/passwd")
+	f.Add("[OUTPUT]", "")
+	f.Add("@INCLUDE", "/etc/passwd")
+	f.Add("name\n", "stdout")
+	f.Add("key", "value [OUTPUT]")
+
+	f.Fuzz(func(t *testing.T, key, value string) {
+		config := Config{
+			Output: []Section{
+				Section{}.Add("name", "stdout").Add(key, value),
+			},
+		}
+
+		got, err := config.Generate()
+		if err != nil {
+			return
+		}
+
+		lines := strings.Split(got, "\n")
+		require.Len(t, lines, 3, "rendered configuration must consist of the section header and the two entries:\n%s", got)
+		assert.Equal(t, "[OUTPUT]", lines[0])
+		assert.Equal(t, "    name stdout", lines[1])
+
+		entry := strings.TrimPrefix(lines[2], "    ")
+		assert.NotEqual(t, lines[2], entry, "entry must be indented")
+		assert.Equal(t, strings.TrimSpace(key), strings.Fields(entry)[0])
+	})
+}
@@ -0,0 +1,139 @@
+package fluentbitconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParsersFile contains parsers and multiline parsers. It is a separate file that is referenced by the parsers_file
+// entry of the service section.
+type ParsersFile struct {
+	Parser          []Section
+	MultilineParser []Section
+}
+
+// Document returns the parsers file as document
+func (p ParsersFile) Document() Document {
+	var d Document
+
+	for _, s := range p.Parser {
+		d.Sections = append(d.Sections, NamedSection{Name: "PARSER", Entries: s})
+	}
+	for _, s := range p.MultilineParser {
+		d.Sections = append(d.Sections, NamedSection{Name: "MULTILINE_PARSER", Entries: s})
+	}
+
+	return d
+}
+
+// Generate renders the parsers file in the classic fluent-bit configuration format
+func (p ParsersFile) Generate() (string, error) {
+	return p.Document().Generate()
+}
+
+// PluginsFile contains the paths of external plugins. It is a separate file that is referenced by the plugins_file
+// entry of the service section.
+type PluginsFile struct {
+	Paths []string
+}
+
+// Document returns the plugins file as document
+func (p PluginsFile) Document() Document {
+	plugins := Section{}
+	for _, path := range p.Paths {
+		plugins = plugins.Add("path", path)
+	}
+
+	return Document{
+		Sections: []NamedSection{{Name: "PLUGINS", Entries: plugins}},
+	}
+}
+
+// Generate renders the plugins file in the classic fluent-bit configuration format
+func (p PluginsFile) Generate() (string, error) {
+	return p.Document().Generate()
+}
+
+// Parser parses the value of a record key, e.g. with the json or regex format
+type Parser struct {
+	Name       string
+	Format     string
+	Regex      string
+	TimeKey    string
+	TimeFormat string
+	TimeKeep   bool
+}
+
+func (p Parser) Section() Section {
+	s := Section{}.
+		Add("name", p.Name).
+		Add("format", p.Format).
+		addIfSet("regex", p.Regex).
+		addIfSet("time_key", p.TimeKey).
+		addIfSet("time_format", p.TimeFormat)
+
+	if p.TimeKeep {
+		s = s.Add("time_keep", "on")
+	}
+
+	return s
+}
+
+// MultilineParser concatenates records with the given rules, which form a state machine starting at start_state
+type MultilineParser struct {
+	Name         string
+	Type         string
+	FlushTimeout int
+	Rules        []MultilineRule
+}
+
+// MultilineRule switches to the next state if a line in the given state matches the regular expression
+type MultilineRule struct {
+	State     string
+	Regex     string
+	NextState string
+}
+
+func (p MultilineParser) Section() Section {
+	s := Section{}.
+		Add("name", p.Name).
+		Add("type", p.Type)
+
+	if p.FlushTimeout != 0 {
+		s = s.Add("flush_timeout", strconv.Itoa(p.FlushTimeout))
+	}
+
+	for _, rule := range p.Rules {
+		s = s.Add("rule", rule.String())
+	}
+
+	return s
+}
+
+// String returns the rule as it is written in the classic format, e.g. "start_state" "/^\w+/" "cont"
+func (r MultilineRule) String() string {
+	return `"` + r.State + `" "/` + r.Regex + `/" "` + r.NextState + `"`
+}
+
+// parseMultilineRule parses a rule in the classic format. the states must not contain quotes, the regular expression
+// is everything in between.
+func parseMultilineRule(s string) (MultilineRule, error) {
+	s = strings.TrimSpace(s)
+
+	state, rest, ok := strings.Cut(strings.TrimPrefix(s, `"`), `" "/`)
+	if !ok || !strings.HasPrefix(s, `"`) || !strings.HasSuffix(rest, `"`) {
+		return MultilineRule{}, fmt.Errorf("invalid multiline rule %q", s)
+	}
+
+	i := strings.LastIndex(rest, `/" "`)
+	if i < 0 {
+		return MultilineRule{}, fmt.Errorf("invalid multiline rule %q", s)
+	}
+
+	return MultilineRule{
+		State:     state,
+		Regex:     rest[:i],
+		NextState: strings.TrimSuffix(rest[i+len(`/" "`):], `"`),
+	}, nil
+}
@@ -0,0 +1,294 @@
+package fluentbitconfig
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Plugin is a typed configuration of a fluent-bit plugin
+type Plugin interface {
+	// Section returns the section that configures the plugin
+	Section() Section
+}
+
+// Section returns the section itself, such that sections can be used wherever a plugin is expected
+func (s Section) Section() Section {
+	return s
+}
+
+// Sections returns the sections of the given plugins
+func Sections(plugins ...Plugin) []Section {
+	sections := make([]Section, 0, len(plugins))
+	for _, p := range plugins {
+		sections = append(sections, p.Section())
+	}
+
+	return sections
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+
+	return "off"
+}
+
+// HTTPInput receives records via http
+type HTTPInput struct {
+	Alias       string
+	Tag         string
+	Listen      string
+	Port        int
+	StorageType string
+}
+
+func (i HTTPInput) Section() Section {
+	s := Section{}.
+		Add("name", "http").
+		addIfSet("alias", i.Alias).
+		addIfSet("tag", i.Tag).
+		addIfSet("listen", i.Listen)
+
+	if i.Port != 0 {
+		s = s.Add("port", strconv.Itoa(i.Port))
+	}
+
+	return s.addIfSet("storage.type", i.StorageType)
+}
+
+// OutputOptions contains the options that are common to all outputs
+type OutputOptions struct {
+	Alias string
+	Match string
+	// RetryLimit is the amount of retries before records are discarded, no_limits lets fluent-bit retry forever
+	RetryLimit            string
+	StorageTotalLimitSize string
+}
+
+func (o OutputOptions) section(name string) Section {
+	return Section{}.
+		Add("name", name).
+		addIfSet("match", o.Match).
+		addIfSet("alias", o.Alias).
+		addIfSet("retry_limit", o.RetryLimit).
+		addIfSet("storage.total_limit_size", o.StorageTotalLimitSize)
+}
+
+// TLS contains the tls options of an output
+type TLS struct {
+	Enabled bool
+	Verify  bool
+	Debug   string
+	CAFile  string
+	CrtFile string
+	KeyFile string
+	VHost   string
+}
+
+func (t TLS) add(s Section) Section {
+	if !t.Enabled {
+		return s
+	}
+
+	return s.
+		Add("tls", "on").
+		Add("tls.verify", onOff(t.Verify)).
+		addIfSet("tls.debug", t.Debug).
+		addIfSet("tls.ca_file", t.CAFile).
+		addIfSet("tls.crt_file", t.CrtFile).
+		addIfSet("tls.key_file", t.KeyFile).
+		addIfSet("tls.vhost", t.VHost)
+}
+
+// StdoutOutput writes the records to the standard output
+type StdoutOutput struct {
+	OutputOptions
+	Format string
+}
+
+func (o StdoutOutput) Section() Section {
+	return o.section("stdout").addIfSet("format", o.Format)
+}
+
+// ForwardOutput forwards the records with the forward protocol
+type ForwardOutput struct {
+	OutputOptions
+	TLS
+	Host               string
+	Port               string
+	RequireAckResponse bool
+	Compress           string
+}
+
+func (o ForwardOutput) Section() Section {
+	s := o.section("forward").
+		addIfSet("host", o.Host).
+		addIfSet("port", o.Port)
+
+	if o.RequireAckResponse {
+		s = s.Add("require_ack_response", "on")
+	}
+
+	return o.TLS.add(s.addIfSet("compress", o.Compress))
+}
+
+// SplunkOutput sends the records to a splunk http event collector
+type SplunkOutput struct {
+	OutputOptions
+	TLS
+	Host string
+	Port string
+	// Token is the hec token, it should reference an environment variable instead of containing the token itself
+	Token           string
+	SendRaw         bool
+	EventSource     string
+	EventSourceType string
+	EventIndex      string
+	EventHost       string
+}
+
+func (o SplunkOutput) Section() Section {
+	s := o.section("splunk").
+		addIfSet("host", o.Host).
+		addIfSet("port", o.Port).
+		addIfSet("splunk_token", o.Token).
+		Add("splunk_send_raw", onOff(o.SendRaw)).
+		addIfSet("event_source", o.EventSource).
+		addIfSet("event_sourcetype", o.EventSourceType).
+		addIfSet("event_index", o.EventIndex).
+		addIfSet("event_host", o.EventHost)
+
+	return o.TLS.add(s)
+}
+
+// ModifyFilter modifies the records with the given rules in their order
+type ModifyFilter struct {
+	Match string
+	Rules []ModifyRule
+}
+
+// ModifyRule is a rule of the modify filter, e.g. add, set, rename or remove
+type ModifyRule struct {
+	Operation string
+	Args      []string
+}
+
+// Add returns the filter with a rule that adds the key with the value if the key does not exist yet
+func (f ModifyFilter) Add(key, value string) ModifyFilter {
+	f.Rules = append(f.Rules, ModifyRule{Operation: "add", Args: []string{key, value}})
+	return f
+}
+
+// Set returns the filter with a rule that sets the key to the value
+func (f ModifyFilter) Set(key, value string) ModifyFilter {
+	f.Rules = append(f.Rules, ModifyRule{Operation: "set", Args: []string{key, value}})
+	return f
+}
+
+// Rename returns the filter with a rule that renames the key
+func (f ModifyFilter) Rename(key, newKey string) ModifyFilter {
+	f.Rules = append(f.Rules, ModifyRule{Operation: "rename", Args: []string{key, newKey}})
+	return f
+}
+
+// Remove returns the filter with a rule that removes the key
+func (f ModifyFilter) Remove(key string) ModifyFilter {
+	f.Rules = append(f.Rules, ModifyRule{Operation: "remove", Args: []string{key}})
+	return f
+}
+
+func (f ModifyFilter) Section() Section {
+	s := Section{}.
+		Add("name", "modify").
+		addIfSet("match", f.Match)
+
+	for _, rule := range f.Rules {
+		s = s.Add(rule.Operation, strings.Join(rule.Args, " "))
+	}
+
+	return s
+}
+
+// GrepFilter keeps records matching all regex rules and drops records matching any exclude rule
+type GrepFilter struct {
+	Match   string
+	Regex   []GrepRule
+	Exclude []GrepRule
+}
+
+// GrepRule matches the value of the key against the regular expression
+type GrepRule struct {
+	Key   string
+	Regex string
+}
+
+func (f GrepFilter) Section() Section {
+	s := Section{}.
+		Add("name", "grep").
+		addIfSet("match", f.Match)
+
+	for _, rule := range f.Regex {
+		s = s.Add("regex", rule.Key+" "+rule.Regex)
+	}
+	for _, rule := range f.Exclude {
+		s = s.Add("exclude", rule.Key+" "+rule.Regex)
+	}
+
+	return s
+}
+
+// LuaFilter modifies the records with the function of a lua script
+type LuaFilter struct {
+	Match string
+	// Script is the path to the lua script
+	Script string
+	// Call is the name of the lua function that is called for every record
+	Call        string
+	TimeAsTable bool
+}
+
+func (f LuaFilter) Section() Section {
+	s := Section{}.
+		Add("name", "lua").
+		addIfSet("match", f.Match).
+		addIfSet("script", f.Script).
+		addIfSet("call", f.Call)
+
+	if f.TimeAsTable {
+		s = s.Add("time_as_table", "on")
+	}
+
+	return s
+}
+
+// RewriteTagFilter re-emits records matching the rules with a new tag
+type RewriteTagFilter struct {
+	Match              string
+	Rules              []RewriteTagRule
+	EmitterName        string
+	EmitterStorageType string
+}
+
+// RewriteTagRule re-emits a record with the new tag if the value of the key matches the regular expression. If keep is
+// false, the original record is dropped.
+type RewriteTagRule struct {
+	Key    string
+	Regex  string
+	NewTag string
+	Keep   bool
+}
+
+func (f RewriteTagFilter) Section() Section {
+	s := Section{}.
+		Add("name", "rewrite_tag").
+		addIfSet("match", f.Match)
+
+	for _, rule := range f.Rules {
+		s = s.Add("rule", strings.Join([]string{rule.Key, rule.Regex, rule.NewTag, strconv.FormatBool(rule.Keep)}, " "))
+	}
+
+	return s.
+		addIfSet("emitter_name", f.EmitterName).
+		addIfSet("emitter_storage.type", f.EmitterStorageType)
+}
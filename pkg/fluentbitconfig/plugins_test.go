@@ -0,0 +1,152 @@
+package fluentbitconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlugins(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin Plugin
+		want   Section
+	}{
+		{
+			name:   "http input",
+			plugin: HTTPInput{Tag: "audit", Port: 9880, StorageType: "filesystem"},
+			want: Section{
+				{Key: "name", Value: "http"},
+				{Key: "tag", Value: "audit"},
+				{Key: "port", Value: "9880"},
+				{Key: "storage.type", Value: "filesystem"},
+			},
+		},
+		{
+			name: "stdout output",
+			plugin: StdoutOutput{
+				OutputOptions: OutputOptions{Alias: "log", Match: "audit", RetryLimit: "no_limits"},
+				Format:        "json_lines",
+			},
+			want: Section{
+				{Key: "name", Value: "stdout"},
+				{Key: "match", Value: "audit"},
+				{Key: "alias", Value: "log"},
+				{Key: "retry_limit", Value: "no_limits"},
+				{Key: "format", Value: "json_lines"},
+			},
+		},
+		{
+			name: "forward output",
+			plugin: ForwardOutput{
+				OutputOptions:      OutputOptions{Match: "audit", StorageTotalLimitSize: "900M"},
+				TLS:                TLS{Enabled: true, Verify: true, CAFile: "/certs/ca.crt", VHost: "audittailer"},
+				Host:               "audittailer",
+				Port:               "24224",
+				RequireAckResponse: true,
+				Compress:           "gzip",
+			},
+			want: Section{
+				{Key: "name", Value: "forward"},
+				{Key: "match", Value: "audit"},
+				{Key: "storage.total_limit_size", Value: "900M"},
+				{Key: "host", Value: "audittailer"},
+				{Key: "port", Value: "24224"},
+				{Key: "require_ack_response", Value: "on"},
+				{Key: "compress", Value: "gzip"},
+				{Key: "tls", Value: "on"},
+				{Key: "tls.verify", Value: "on"},
+				{Key: "tls.ca_file", Value: "/certs/ca.crt"},
+				{Key: "tls.vhost", Value: "audittailer"},
+			},
+		},
+		{
+			name: "splunk output without tls",
+			plugin: SplunkOutput{
+				OutputOptions: OutputOptions{Alias: "splunk", Match: "audit"},
+				TLS:           TLS{Verify: true, VHost: "ignored"},
+				Host:          "splunk.example.com",
+				Port:          "8088",
+				Token:         "${SPLUNK_HEC_TOKEN}",
+				EventIndex:    "audit",
+			},
+			want: Section{
+				{Key: "name", Value: "splunk"},
+				{Key: "match", Value: "audit"},
+				{Key: "alias", Value: "splunk"},
+				{Key: "host", Value: "splunk.example.com"},
+				{Key: "port", Value: "8088"},
+				{Key: "splunk_token", Value: "${SPLUNK_HEC_TOKEN}"},
+				{Key: "splunk_send_raw", Value: "off"},
+				{Key: "event_index", Value: "audit"},
+			},
+		},
+		{
+			name:   "modify filter",
+			plugin: ModifyFilter{Match: "*"}.Add("cluster", "shoot").Rename("user", "username").Set("source", "audit").Remove("log"),
+			want: Section{
+				{Key: "name", Value: "modify"},
+				{Key: "match", Value: "*"},
+				{Key: "add", Value: "cluster shoot"},
+				{Key: "rename", Value: "user username"},
+				{Key: "set", Value: "source audit"},
+				{Key: "remove", Value: "log"},
+			},
+		},
+		{
+			name: "grep filter",
+			plugin: GrepFilter{
+				Match:   "audit",
+				Regex:   []GrepRule{{Key: "stage", Regex: "^ResponseComplete$"}},
+				Exclude: []GrepRule{{Key: "verb", Regex: "^(get|list|watch)$"}, {Key: "user", Regex: "^system:"}},
+			},
+			want: Section{
+				{Key: "name", Value: "grep"},
+				{Key: "match", Value: "audit"},
+				{Key: "regex", Value: "stage ^ResponseComplete$"},
+				{Key: "exclude", Value: "verb ^(get|list|watch)$"},
+				{Key: "exclude", Value: "user ^system:"},
+			},
+		},
+		{
+			name:   "lua filter",
+			plugin: LuaFilter{Match: "audit", Script: "/fluent-bit/scripts/redact.lua", Call: "redact", TimeAsTable: true},
+			want: Section{
+				{Key: "name", Value: "lua"},
+				{Key: "match", Value: "audit"},
+				{Key: "script", Value: "/fluent-bit/scripts/redact.lua"},
+				{Key: "call", Value: "redact"},
+				{Key: "time_as_table", Value: "on"},
+			},
+		},
+		{
+			name: "rewrite tag filter",
+			plugin: RewriteTagFilter{
+				Match:       "audit",
+				Rules:       []RewriteTagRule{{Key: "$verb", Regex: "^delete$", NewTag: "audit.delete", Keep: true}},
+				EmitterName: "re_emitted",
+			},
+			want: Section{
+				{Key: "name", Value: "rewrite_tag"},
+				{Key: "match", Value: "audit"},
+				{Key: "rule", Value: "$verb ^delete$ audit.delete true"},
+				{Key: "emitter_name", Value: "re_emitted"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.plugin.Section()
+			assert.Equal(t, tt.want, got)
+			require.NoError(t, got.Validate("SPLUNK_HEC_TOKEN"))
+		})
+	}
+}
+
+func TestPlugins_RejectInjection(t *testing.T) {
+	_, err := Config{
+		Filter: Sections(ModifyFilter{Match: "*"}.Add("tenant", "a\n[OUTPUT]\n    name http")),
+	}.Generate()
+	require.ErrorContains(t, err, `value of key "add" must not contain control characters`)
+}
@@ -0,0 +1,509 @@
+package fluentbitconfig
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the format in which a fluent-bit configuration is rendered
+type Format string
+
+const (
+	// FormatClassic is the classic fluent-bit configuration format with sections in square brackets
+	FormatClassic Format = "classic"
+	// FormatYAML is the yaml configuration format, which is supported since fluent-bit 2.x
+	FormatYAML Format = "yaml"
+)
+
+// Extension returns the file extension of the format, the classic format is used if the format is empty
+func (f Format) Extension() string {
+	if f == FormatYAML {
+		return "yaml"
+	}
+
+	return "conf"
+}
+
+// Render renders the document in the given format, the classic format is used if the format is empty
+func (d Document) Render(format Format) (string, error) {
+	switch format {
+	case "", FormatClassic:
+		return d.Generate()
+	case FormatYAML:
+		return d.GenerateYAML()
+	default:
+		return "", fmt.Errorf("unsupported fluent-bit configuration format %q", format)
+	}
+}
+
+// yamlPipelineKeys are the keys of the pipeline in the yaml format for the sections of the classic format
+var yamlPipelineKeys = map[string]string{
+	"INPUT":  "inputs",
+	"FILTER": "filters",
+	"OUTPUT": "outputs",
+}
+
+// GenerateYAML renders the document in the yaml fluent-bit configuration format. The sections are grouped by their
+// kind, entries with the same key are rendered as a list at the position of the first entry with that key.
+func (d Document) GenerateYAML() (string, error) {
+	if err := d.Validate(); err != nil {
+		return "", fmt.Errorf("invalid fluent-bit configuration: %w", err)
+	}
+
+	var (
+		service  *yaml.Node
+		plugins  []string
+		lists    = map[string][]*yaml.Node{}
+		pipeline = map[string][]*yaml.Node{}
+	)
+
+	for _, s := range d.Sections {
+		switch s.Name {
+		case "SERVICE":
+			if service == nil {
+				service = mappingNode()
+			}
+			appendEntries(service, s.Entries)
+
+		case "PLUGINS":
+			plugins = append(plugins, s.Entries.Values("path")...)
+
+		case "INPUT", "FILTER", "OUTPUT":
+			n := mappingNode()
+			appendEntries(n, s.Entries)
+
+			if len(s.Processors) > 0 {
+				logs := sequenceNode()
+				for _, p := range s.Processors {
+					processor := mappingNode()
+					appendEntries(processor, p)
+					logs.Content = append(logs.Content, processor)
+				}
+
+				processors := mappingNode()
+				processors.Content = append(processors.Content, scalarNode("logs"), logs)
+				n.Content = append(n.Content, scalarNode("processors"), processors)
+			}
+
+			key := yamlPipelineKeys[s.Name]
+			pipeline[key] = append(pipeline[key], n)
+
+		case "PARSER":
+			n := mappingNode()
+			appendEntries(n, s.Entries)
+			lists["parsers"] = append(lists["parsers"], n)
+
+		case "MULTILINE_PARSER":
+			var (
+				n     = mappingNode()
+				rules = sequenceNode()
+				other Section
+			)
+
+			for _, e := range s.Entries {
+				if !strings.EqualFold(e.Key, "rule") {
+					other = append(other, e)
+					continue
+				}
+
+				rule, err := parseMultilineRule(e.Value)
+				if err != nil {
+					return "", err
+				}
+
+				r := mappingNode()
+				r.Content = append(r.Content,
+					scalarNode("state"), scalarNode(rule.State),
+					scalarNode("regex"), scalarNode(rule.Regex),
+					scalarNode("next_state"), scalarNode(rule.NextState),
+				)
+				rules.Content = append(rules.Content, r)
+			}
+
+			appendEntries(n, other)
+			if len(rules.Content) > 0 {
+				n.Content = append(n.Content, scalarNode("rules"), rules)
+			}
+
+			lists["multiline_parsers"] = append(lists["multiline_parsers"], n)
+
+		default:
+			return "", fmt.Errorf("section %q is not supported by the %s format", s.Name, FormatYAML)
+		}
+	}
+
+	root := mappingNode()
+
+	if len(d.Variables) > 0 {
+		env := mappingNode()
+		for _, v := range d.Variables {
+			env.Content = append(env.Content, scalarNode(v.Key), scalarNode(strings.TrimSpace(v.Value)))
+		}
+
+		root.Content = append(root.Content, scalarNode("env"), env)
+	}
+	if service != nil {
+		root.Content = append(root.Content, scalarNode("service"), service)
+	}
+	if len(plugins) > 0 {
+		root.Content = append(root.Content, scalarNode("plugins"), scalarSequenceNode(plugins))
+	}
+	for _, key := range []string{"parsers", "multiline_parsers"} {
+		if len(lists[key]) > 0 {
+			root.Content = append(root.Content, scalarNode(key), sequenceNode(lists[key]...))
+		}
+	}
+
+	p := mappingNode()
+	for _, key := range []string{"inputs", "filters", "outputs"} {
+		if len(pipeline[key]) > 0 {
+			p.Content = append(p.Content, scalarNode(key), sequenceNode(pipeline[key]...))
+		}
+	}
+	if len(p.Content) > 0 {
+		root.Content = append(root.Content, scalarNode("pipeline"), p)
+	}
+
+	if len(d.Includes) > 0 {
+		includes := make([]string, 0, len(d.Includes))
+		for _, include := range d.Includes {
+			includes = append(includes, strings.TrimSpace(string(include)))
+		}
+
+		root.Content = append(root.Content, scalarNode("includes"), scalarSequenceNode(includes))
+	}
+
+	if len(root.Content) == 0 {
+		return "", nil
+	}
+
+	var b bytes.Buffer
+
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(root); err != nil {
+		return "", fmt.Errorf("unable to render yaml: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("unable to render yaml: %w", err)
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+// ParseYAML parses a document in the yaml fluent-bit configuration format. Lists of values are parsed into entries
+// with the same key, multiline parser rules are parsed into rule entries of the classic format.
+func ParseYAML(content string) (Document, error) {
+	var (
+		d    Document
+		root yaml.Node
+	)
+
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		return d, fmt.Errorf("unable to parse yaml: %w", err)
+	}
+
+	if len(root.Content) == 0 {
+		return d, nil
+	}
+
+	top := root.Content[0]
+	if top.Kind != yaml.MappingNode {
+		return d, fmt.Errorf("line %d: configuration must be a mapping", top.Line)
+	}
+
+	for i := 0; i < len(top.Content); i += 2 {
+		key, value := top.Content[i], top.Content[i+1]
+
+		switch key.Value {
+		case "env":
+			if value.Kind != yaml.MappingNode {
+				return d, fmt.Errorf("line %d: env must be a mapping", value.Line)
+			}
+
+			for j := 0; j < len(value.Content); j += 2 {
+				if value.Content[j+1].Kind != yaml.ScalarNode {
+					return d, fmt.Errorf("line %d: value of variable %q must be a scalar", value.Content[j+1].Line, value.Content[j].Value)
+				}
+
+				d.Variables = d.Variables.Add(value.Content[j].Value, value.Content[j+1].Value)
+			}
+
+		case "service":
+			entries, err := parseYAMLEntries(value)
+			if err != nil {
+				return d, err
+			}
+
+			d.Sections = append(d.Sections, NamedSection{Name: "SERVICE", Entries: entries})
+
+		case "plugins":
+			paths, err := parseYAMLScalars(value)
+			if err != nil {
+				return d, err
+			}
+
+			plugins := Section{}
+			for _, path := range paths {
+				plugins = plugins.Add("path", path)
+			}
+
+			d.Sections = append(d.Sections, NamedSection{Name: "PLUGINS", Entries: plugins})
+
+		case "parsers", "multiline_parsers":
+			name := "PARSER"
+			if key.Value == "multiline_parsers" {
+				name = "MULTILINE_PARSER"
+			}
+
+			sections, err := parseYAMLSections(value, name)
+			if err != nil {
+				return d, err
+			}
+
+			d.Sections = append(d.Sections, sections...)
+
+		case "pipeline":
+			if value.Kind != yaml.MappingNode {
+				return d, fmt.Errorf("line %d: pipeline must be a mapping", value.Line)
+			}
+
+			for j := 0; j < len(value.Content); j += 2 {
+				var name string
+				for sectionName, pipelineKey := range yamlPipelineKeys {
+					if pipelineKey == value.Content[j].Value {
+						name = sectionName
+					}
+				}
+				if name == "" {
+					return d, fmt.Errorf("line %d: unsupported pipeline key %q", value.Content[j].Line, value.Content[j].Value)
+				}
+
+				sections, err := parseYAMLSections(value.Content[j+1], name)
+				if err != nil {
+					return d, err
+				}
+
+				d.Sections = append(d.Sections, sections...)
+			}
+
+		case "includes":
+			includes, err := parseYAMLScalars(value)
+			if err != nil {
+				return d, err
+			}
+
+			for _, include := range includes {
+				d.Includes = append(d.Includes, Include(include))
+			}
+
+		default:
+			return d, fmt.Errorf("line %d: unsupported key %q", key.Line, key.Value)
+		}
+	}
+
+	return d, nil
+}
+
+// parseYAMLSections parses a list of mappings into sections with the given name
+func parseYAMLSections(n *yaml.Node, name string) ([]NamedSection, error) {
+	if n.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: %s must be a list", n.Line, strings.ToLower(name))
+	}
+
+	var sections []NamedSection
+
+	for _, item := range n.Content {
+		if item.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: %s must be a mapping", item.Line, strings.ToLower(name))
+		}
+
+		var (
+			s      = NamedSection{Name: name}
+			values = mappingNode()
+		)
+
+		for i := 0; i < len(item.Content); i += 2 {
+			key, value := item.Content[i], item.Content[i+1]
+
+			switch {
+			case key.Value == "processors" && (name == "INPUT" || name == "OUTPUT"):
+				processors, err := parseYAMLProcessors(value)
+				if err != nil {
+					return nil, err
+				}
+
+				s.Processors = processors
+
+			case key.Value == "rules" && name == "MULTILINE_PARSER":
+				rules, err := parseYAMLRules(value)
+				if err != nil {
+					return nil, err
+				}
+
+				for _, rule := range rules {
+					values.Content = append(values.Content, scalarNode("rule"), scalarNode(rule.String()))
+				}
+
+			default:
+				values.Content = append(values.Content, key, value)
+			}
+		}
+
+		entries, err := parseYAMLEntries(values)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Entries = entries
+		sections = append(sections, s)
+	}
+
+	return sections, nil
+}
+
+// parseYAMLProcessors parses the processors of an input or output, only processors for logs are supported
+func parseYAMLProcessors(n *yaml.Node) ([]Section, error) {
+	if n.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: processors must be a mapping", n.Line)
+	}
+
+	var processors []Section
+
+	for i := 0; i < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if key.Value != "logs" {
+			return nil, fmt.Errorf("line %d: unsupported processors %q, only logs are supported", key.Line, key.Value)
+		}
+
+		sections, err := parseYAMLSections(value, "PROCESSOR")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range sections {
+			processors = append(processors, s.Entries)
+		}
+	}
+
+	return processors, nil
+}
+
+// parseYAMLRules parses the rules of a multiline parser
+func parseYAMLRules(n *yaml.Node) ([]MultilineRule, error) {
+	if n.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: rules must be a list", n.Line)
+	}
+
+	var rules []MultilineRule
+
+	for _, item := range n.Content {
+		var rule struct {
+			State     string `yaml:"state"`
+			Regex     string `yaml:"regex"`
+			NextState string `yaml:"next_state"`
+		}
+
+		if err := item.Decode(&rule); err != nil {
+			return nil, fmt.Errorf("line %d: invalid rule: %w", item.Line, err)
+		}
+
+		rules = append(rules, MultilineRule{State: rule.State, Regex: rule.Regex, NextState: rule.NextState})
+	}
+
+	return rules, nil
+}
+
+// parseYAMLEntries parses a mapping into entries, the values of a list are parsed into entries with the same key
+func parseYAMLEntries(n *yaml.Node) (Section, error) {
+	if n.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: section must be a mapping", n.Line)
+	}
+
+	entries := Section{}
+
+	for i := 0; i < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+
+		values, err := parseYAMLScalars(value)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key.Value, err)
+		}
+
+		for _, v := range values {
+			entries = entries.Add(key.Value, v)
+		}
+	}
+
+	return entries, nil
+}
+
+// parseYAMLScalars parses a scalar or a list of scalars
+func parseYAMLScalars(n *yaml.Node) ([]string, error) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return []string{n.Value}, nil
+	case yaml.SequenceNode:
+		var values []string
+		for _, item := range n.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: value must be a scalar", item.Line)
+			}
+
+			values = append(values, item.Value)
+		}
+
+		return values, nil
+	default:
+		return nil, fmt.Errorf("line %d: value must be a scalar or a list of scalars", n.Line)
+	}
+}
+
+// appendEntries appends the entries to the mapping, entries with the same key are grouped into a list
+func appendEntries(n *yaml.Node, s Section) {
+	index := map[string]int{}
+
+	for _, e := range s {
+		var (
+			key   = strings.TrimSpace(e.Key)
+			value = scalarNode(strings.TrimSpace(e.Value))
+		)
+
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(n.Content) + 1
+			n.Content = append(n.Content, scalarNode(key), value)
+			continue
+		}
+
+		if existing := n.Content[i]; existing.Kind != yaml.SequenceNode {
+			n.Content[i] = sequenceNode(existing)
+		}
+		n.Content[i].Content = append(n.Content[i].Content, value)
+	}
+}
+
+func mappingNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode}
+}
+
+func sequenceNode(items ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.SequenceNode, Content: items}
+}
+
+func scalarSequenceNode(values []string) *yaml.Node {
+	n := sequenceNode()
+	for _, v := range values {
+		n.Content = append(n.Content, scalarNode(v))
+	}
+
+	return n
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
@@ -0,0 +1,264 @@
+package fluentbitconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		document Document
+		want     string
+	}{
+		{
+			name: "config",
+			document: Config{
+				Service: Section{}.Add("flush", "1").Add("parsers_file", "/config/parsers.conf"),
+				Input:   Sections(HTTPInput{Port: 9880, StorageType: "filesystem"}),
+				Filter:  Sections(ModifyFilter{Match: "*"}.Add("a", "1").Rename("b", "c").Add("d", "on")),
+				Output: Sections(
+					StdoutOutput{OutputOptions: OutputOptions{Alias: "log", Match: "audit"}},
+					ForwardOutput{OutputOptions: OutputOptions{Alias: "forward", Match: "audit"}, Host: "localhost", Port: "24224"},
+				),
+				Includes: []Include{"a.backend.yaml", "b.backend.yaml"},
+				Processors: map[string][]Section{
+					"forward": Sections(ModifyFilter{}.Add("cluster", "shoot")),
+				},
+			}.Document(),
+			want: `service:
+  flush: "1"
+  parsers_file: /config/parsers.conf
+pipeline:
+  inputs:
+    - name: http
+      port: "9880"
+      storage.type: filesystem
+  filters:
+    - name: modify
+      match: '*'
+      add:
+        - a 1
+        - d on
+      rename: b c
+  outputs:
+    - name: stdout
+      match: audit
+      alias: log
+    - name: forward
+      match: audit
+      alias: forward
+      host: localhost
+      port: "24224"
+      processors:
+        logs:
+          - name: modify
+            add: cluster shoot
+includes:
+  - a.backend.yaml
+  - b.backend.yaml`,
+		},
+		{
+			name: "parsers",
+			document: ParsersFile{
+				Parser: Sections(Parser{Name: "json", Format: "json", TimeKey: "time", TimeKeep: true}),
+				MultilineParser: Sections(MultilineParser{
+					Name:         "stacktrace",
+					Type:         "regex",
+					FlushTimeout: 1000,
+					Rules: []MultilineRule{
+						{State: "start_state", Regex: `^\w+Exception "(.*)"`, NextState: "cont"},
+						{State: "cont", Regex: `^\s+at`, NextState: "cont"},
+					},
+				}),
+			}.Document(),
+			want: `parsers:
+  - name: json
+    format: json
+    time_key: time
+    time_keep: on
+multiline_parsers:
+  - name: stacktrace
+    type: regex
+    flush_timeout: "1000"
+    rules:
+      - state: start_state
+        regex: ^\w+Exception "(.*)"
+        next_state: cont
+      - state: cont
+        regex: ^\s+at
+        next_state: cont`,
+		},
+		{
+			name:     "plugins",
+			document: PluginsFile{Paths: []string{"/plugins/out_a.so", "/plugins/out_b.so"}}.Document(),
+			want: `plugins:
+  - /plugins/out_a.so
+  - /plugins/out_b.so`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.document.GenerateYAML()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			parsed, err := ParseYAML(got)
+			require.NoError(t, err)
+			assert.Equal(t, groupEntries(tt.document), groupEntries(parsed))
+		})
+	}
+}
+
+// TestRenderers_Equivalence ensures that both renderers produce semantically equivalent configurations. As the yaml
+// format groups entries with the same key into a list, the entries are compared grouped by their key.
+func TestRenderers_Equivalence(t *testing.T) {
+	documents := map[string]Document{
+		"config": Config{
+			Environment: []string{"SPLUNK_HEC_TOKEN"},
+			Service:     Section{}.Add("flush", "1").Add("log_level", "info").Add("http_server", "on"),
+			Input:       Sections(HTTPInput{Alias: "audit", Tag: "audit", Port: 9880, StorageType: "filesystem"}),
+			Filter: Sections(
+				ModifyFilter{Match: "*"}.Add("a", "1").Set("b", "2").Add("c", "3").Remove("d"),
+				GrepFilter{Match: "audit", Exclude: []GrepRule{{Key: "verb", Regex: "^(get|list|watch)$"}, {Key: "user", Regex: "^system:"}}},
+				RewriteTagFilter{Match: "audit", Rules: []RewriteTagRule{{Key: "$user['username']", Regex: "^system:", NewTag: "system", Keep: false}}},
+			),
+			Output: Sections(SplunkOutput{
+				OutputOptions: OutputOptions{Alias: "splunk", Match: "audit", RetryLimit: "no_limits"},
+				TLS:           TLS{Enabled: true, Verify: true, VHost: "splunk.example.com"},
+				Host:          "splunk.example.com",
+				Port:          "443",
+				Token:         "${SPLUNK_HEC_TOKEN}",
+			}),
+			Includes: []Include{"*.backend.conf"},
+		}.Document(),
+		"parsers": ParsersFile{
+			Parser: Sections(Parser{Name: "regex", Format: "regex", Regex: `^(?<user>[^ ]+) (?<verb>\w+)$`}),
+			MultilineParser: Sections(MultilineParser{
+				Name:  "stacktrace",
+				Type:  "regex",
+				Rules: []MultilineRule{{State: "start_state", Regex: `^\S`, NextState: "cont"}},
+			}),
+		}.Document(),
+		"plugins": PluginsFile{Paths: []string{"/plugins/out_a.so"}}.Document(),
+	}
+
+	for name, document := range documents {
+		t.Run(name, func(t *testing.T) {
+			classic, err := document.Render(FormatClassic)
+			require.NoError(t, err)
+			fromClassic, err := Parse(classic)
+			require.NoError(t, err)
+
+			yaml, err := document.Render(FormatYAML)
+			require.NoError(t, err)
+			fromYAML, err := ParseYAML(yaml)
+			require.NoError(t, err)
+
+			assert.Equal(t, groupEntries(fromClassic), groupEntries(fromYAML))
+		})
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	withProcessors := Config{
+		Output:     Sections(StdoutOutput{OutputOptions: OutputOptions{Alias: "log", Match: "audit"}}),
+		Processors: map[string][]Section{"log": Sections(ModifyFilter{}.Add("a", "b"))},
+	}
+
+	_, err := withProcessors.Render(FormatClassic)
+	require.EqualError(t, err, "processors of output are only supported by the yaml format")
+
+	_, err = withProcessors.Render("toml")
+	require.EqualError(t, err, `unsupported fluent-bit configuration format "toml"`)
+
+	_, err = Config{
+		Processors: map[string][]Section{"unknown": Sections(ModifyFilter{}.Add("a", "b"))},
+	}.Render(FormatYAML)
+	require.ErrorContains(t, err, `processors reference unknown input or output "unknown"`)
+
+	_, err = Document{Sections: []NamedSection{{Name: "FILTER", Entries: Section{}.Add("name", "modify"), Processors: []Section{{}}}}}.GenerateYAML()
+	require.ErrorContains(t, err, "filter 0: processors are only supported for inputs and outputs")
+
+	_, err = Document{Sections: []NamedSection{{Name: "CUSTOM", Entries: Section{}.Add("a", "b")}}}.GenerateYAML()
+	require.EqualError(t, err, `section "CUSTOM" is not supported by the yaml format`)
+}
+
+func TestParseYAML_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "no mapping",
+			content: "- a",
+			wantErr: "line 1: configuration must be a mapping",
+		},
+		{
+			name:    "unsupported key",
+			content: "customs:\n  a: b",
+			wantErr: `line 1: unsupported key "customs"`,
+		},
+		{
+			name:    "unsupported pipeline key",
+			content: "pipeline:\n  sinks: []",
+			wantErr: `line 2: unsupported pipeline key "sinks"`,
+		},
+		{
+			name:    "nested value",
+			content: "service:\n  flush:\n    a: b",
+			wantErr: `key "flush": line 3: value must be a scalar or a list of scalars`,
+		},
+		{
+			name:    "unsupported processors",
+			content: "pipeline:\n  outputs:\n    - name: stdout\n      processors:\n        metrics: []",
+			wantErr: `line 5: unsupported processors "metrics", only logs are supported`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseYAML(tt.content)
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+// groupEntries returns the document with the entries of every section grouped by their key in the order of their first
+// occurrence, like the yaml format renders them
+func groupEntries(d Document) Document {
+	group := func(s Section) Section {
+		var (
+			keys    []string
+			grouped = map[string]Section{}
+		)
+
+		for _, e := range s {
+			if _, ok := grouped[e.Key]; !ok {
+				keys = append(keys, e.Key)
+			}
+			grouped[e.Key] = append(grouped[e.Key], e)
+		}
+
+		result := Section{}
+		for _, key := range keys {
+			result = append(result, grouped[key]...)
+		}
+
+		return result
+	}
+
+	result := Document{Includes: d.Includes}
+	for _, s := range d.Sections {
+		grouped := NamedSection{Name: s.Name, Entries: group(s.Entries)}
+		for _, p := range s.Processors {
+			grouped.Processors = append(grouped.Processors, group(p))
+		}
+
+		result.Sections = append(result.Sections, grouped)
+	}
+
+	return result
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// Namespace is the prefix of all metrics exported by the audit extension
+	Namespace = "gardener_extension_audit"
+
+	LabelShootNamespace = "shoot_namespace"
+	LabelOutput         = "output"
+	LabelStatus         = "status"
+	LabelBackend        = "backend"
+	LabelResult         = "result"
+	LabelCertificate    = "certificate"
+	LabelWebhookMode    = "webhook_mode"
+
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+var (
+	// OutputHealth is 1 for the current health status of an output of a shoot's audit webhook backend and 0 for the other states
+	OutputHealth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "output_health_status",
+		Help:      "Health status of a fluent-bit output of the audit webhook backend as determined by the health check.",
+	}, []string{LabelShootNamespace, LabelOutput, LabelStatus})
+
+	// OutputRetries counts the retries of an output as observed by the health check
+	OutputRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "output_retries_total",
+		Help:      "Retries of a fluent-bit output of the audit webhook backend as observed by the health check.",
+	}, []string{LabelShootNamespace, LabelOutput})
+
+	// OutputDroppedRecords counts the dropped records of an output as observed by the health check
+	OutputDroppedRecords = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "output_dropped_records_total",
+		Help:      "Dropped records of a fluent-bit output of the audit webhook backend as observed by the health check.",
+	}, []string{LabelShootNamespace, LabelOutput})
+
+	// Reconciles counts the reconciliations of extensions per enabled backend and their result
+	Reconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "reconciles_total",
+		Help:      "Reconciliations of audit extensions per enabled backend and result.",
+	}, []string{LabelBackend, LabelResult})
+
+	// CertificateExpiry is the expiry of the certificates used for the communication between the cluster forwarding and the audittailer
+	CertificateExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "audittailer_certificate_expiry_timestamp_seconds",
+		Help:      "Expiry of the audittailer certificates as unix timestamp.",
+	}, []string{LabelShootNamespace, LabelCertificate})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		OutputHealth,
+		OutputRetries,
+		OutputDroppedRecords,
+		Reconciles,
+		CertificateExpiry,
+	)
+}
+
+// SetOutputHealth sets the health status of an output, the given status is set to 1 and all other states to 0
+func SetOutputHealth(shootNamespace, output string, status v1alpha1.OutputHealthStatus) {
+	for _, s := range []v1alpha1.OutputHealthStatus{
+		v1alpha1.OutputHealthStatusHealthy,
+		v1alpha1.OutputHealthStatusWarning,
+		v1alpha1.OutputHealthStatusFailed,
+	} {
+		value := 0.0
+		if s == status {
+			value = 1
+		}
+
+		OutputHealth.WithLabelValues(shootNamespace, output, string(s)).Set(value)
+	}
+}
+
+// DeleteShoot removes all metrics of a shoot namespace, which is required when the extension was deleted
+func DeleteShoot(shootNamespace string) {
+	labels := prometheus.Labels{LabelShootNamespace: shootNamespace}
+
+	OutputHealth.DeletePartialMatch(labels)
+	OutputRetries.DeletePartialMatch(labels)
+	OutputDroppedRecords.DeletePartialMatch(labels)
+	CertificateExpiry.DeletePartialMatch(labels)
+}
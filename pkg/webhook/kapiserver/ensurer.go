@@ -18,6 +18,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
@@ -39,6 +40,11 @@ type ensurer struct {
 	logger  logr.Logger
 }
 
+const (
+	auditWebhookConfigVolumeName    = "audit-webhook-config"
+	auditWebhookBackendNetworkLabel = "networking.resources.gardener.cloud/to-audit-webhook-backend-tcp-9880"
+)
+
 // EnsureKubeAPIServerDeployment ensures that the kube-apiserver deployment conforms to the provider requirements.
 func (e *ensurer) EnsureKubeAPIServerDeployment(ctx context.Context, gctx gcontext.GardenContext, new, _ *appsv1.Deployment) error {
 	cluster, err := gctx.GetCluster(ctx)
@@ -47,7 +53,8 @@ func (e *ensurer) EnsureKubeAPIServerDeployment(ctx context.Context, gctx gconte
 	}
 
 	if cluster.Shoot.DeletionTimestamp != nil && !cluster.Shoot.DeletionTimestamp.IsZero() {
-		e.logger.Info("skip mutating api server because shoot is in deletion")
+		e.logger.Info("removing audit webhook from api server because shoot is in deletion")
+		removeAuditWebhook(&new.Spec.Template)
 		return nil
 	}
 
@@ -61,7 +68,23 @@ func (e *ensurer) EnsureKubeAPIServerDeployment(ctx context.Context, gctx gconte
 	}
 	err = e.client.Get(ctx, client.ObjectKeyFromObject(ex), ex)
 	if err != nil {
-		return fmt.Errorf("unable to find extension resource. this extension needs to be configured with lifecycle policy BeforeKubeAPIServer")
+		if apierrors.IsNotFound(err) {
+			// the extension was removed from the shoot, the audit webhook backend does not exist anymore. this is only
+			// reached if the webhook is called before gardener removed the extension label from the shoot namespace,
+			// afterwards the webhook is not called anymore and the audit webhook is removed because gardenlet renders
+			// the kube-apiserver deployment again without the mutations of this webhook.
+			e.logger.Info("removing audit webhook from api server because extension does not exist")
+			removeAuditWebhook(&new.Spec.Template)
+			return nil
+		}
+
+		return fmt.Errorf("unable to get extension resource: %w", err)
+	}
+
+	if ex.DeletionTimestamp != nil && !ex.DeletionTimestamp.IsZero() {
+		e.logger.Info("removing audit webhook from api server because extension is in deletion")
+		removeAuditWebhook(&new.Spec.Template)
+		return nil
 	}
 
 	auditConfig := &v1alpha1.AuditConfig{}
@@ -71,13 +94,7 @@ func (e *ensurer) EnsureKubeAPIServerDeployment(ctx context.Context, gctx gconte
 		}
 	}
 
-	var webhookMode v1alpha1.AuditWebhookMode
-	switch mode := auditConfig.WebhookMode; mode {
-	case v1alpha1.AuditWebhookModeBatch, v1alpha1.AuditWebhookModeBlocking, v1alpha1.AuditWebhookModeBlockingStrict:
-		webhookMode = mode
-	default:
-		webhookMode = v1alpha1.AuditWebhookModeBlockingStrict
-	}
+	webhookMode := v1alpha1.EffectiveWebhookMode(auditConfig.WebhookMode)
 
 	template := &new.Spec.Template
 	ps := &template.Spec
@@ -88,14 +105,30 @@ func (e *ensurer) EnsureKubeAPIServerDeployment(ctx context.Context, gctx gconte
 		ensureVolumes(ps)
 	}
 
-	template.Labels["networking.resources.gardener.cloud/to-audit-webhook-backend-tcp-9880"] = "allowed"
+	if template.Labels == nil {
+		template.Labels = map[string]string{}
+	}
+	template.Labels[auditWebhookBackendNetworkLabel] = "allowed"
 
 	return nil
 }
 
+// removeAuditWebhook removes all mutations of the ensurer from the pod template of the kube-apiserver
+func removeAuditWebhook(template *corev1.PodTemplateSpec) {
+	ps := &template.Spec
+	if c := extensionswebhook.ContainerWithName(ps.Containers, "kube-apiserver"); c != nil {
+		c.Command = extensionswebhook.EnsureNoStringWithPrefix(c.Command, "--audit-webhook-config-file=")
+		c.Command = extensionswebhook.EnsureNoStringWithPrefix(c.Command, "--audit-webhook-mode=")
+		c.VolumeMounts = extensionswebhook.EnsureNoVolumeMountWithName(c.VolumeMounts, auditWebhookConfigVolumeName)
+	}
+	ps.Volumes = extensionswebhook.EnsureNoVolumeWithName(ps.Volumes, auditWebhookConfigVolumeName)
+
+	delete(template.Labels, auditWebhookBackendNetworkLabel)
+}
+
 func ensureVolumeMounts(c *corev1.Container) {
 	c.VolumeMounts = extensionswebhook.EnsureVolumeMountWithName(c.VolumeMounts, corev1.VolumeMount{
-		Name:      "audit-webhook-config",
+		Name:      auditWebhookConfigVolumeName,
 		ReadOnly:  true,
 		MountPath: "/etc/audit-webhook/config",
 	})
@@ -103,7 +136,7 @@ func ensureVolumeMounts(c *corev1.Container) {
 
 func ensureVolumes(ps *corev1.PodSpec) {
 	ps.Volumes = extensionswebhook.EnsureVolumeWithName(ps.Volumes, corev1.Volume{
-		Name: "audit-webhook-config",
+		Name: auditWebhookConfigVolumeName,
 		VolumeSource: corev1.VolumeSource{
 			Secret: &corev1.SecretVolumeSource{
 				SecretName: "audit-webhook-config",
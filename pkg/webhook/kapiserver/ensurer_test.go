@@ -0,0 +1,175 @@
+package kapiserver
+
+import (
+	"context"
+	"testing"
+
+	gcontext "github.com/gardener/gardener/extensions/pkg/webhook/context"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/extensions"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
+)
+
+func TestEnsureKubeAPIServerDeployment(t *testing.T) {
+	const namespace = "shoot--project--name"
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, extensionsv1alpha1.AddToScheme(scheme))
+	install.Install(scheme)
+
+	var (
+		now       = metav1.Now()
+		extension = func() *extensionsv1alpha1.Extension {
+			return &extensionsv1alpha1.Extension{
+				ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: namespace},
+				Spec: extensionsv1alpha1.ExtensionSpec{
+					DefaultSpec: extensionsv1alpha1.DefaultSpec{
+						Type:           "audit",
+						ProviderConfig: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","webhookMode":"blocking"}`)},
+					},
+				},
+			}
+		}
+		deletedExtension = func() *extensionsv1alpha1.Extension {
+			ex := extension()
+			ex.DeletionTimestamp = &now
+			ex.Finalizers = []string{"extensions.gardener.cloud/audit"}
+			return ex
+		}
+	)
+
+	tests := []struct {
+		name           string
+		objects        []client.Object
+		shootDeletion  *metav1.Time
+		deployment     *appsv1.Deployment
+		wantMutations  bool
+		wantWebhookArg string
+	}{
+		{
+			name:           "adds the audit webhook",
+			objects:        []client.Object{extension()},
+			deployment:     kubeAPIServerDeployment(),
+			wantMutations:  true,
+			wantWebhookArg: "--audit-webhook-mode=blocking",
+		},
+		{
+			name:           "is idempotent",
+			objects:        []client.Object{extension()},
+			deployment:     mutatedKubeAPIServerDeployment(),
+			wantMutations:  true,
+			wantWebhookArg: "--audit-webhook-mode=blocking",
+		},
+		{
+			name:       "removes the audit webhook if the extension does not exist",
+			deployment: mutatedKubeAPIServerDeployment(),
+		},
+		{
+			name:       "removes the audit webhook if the extension is in deletion",
+			objects:    []client.Object{deletedExtension()},
+			deployment: mutatedKubeAPIServerDeployment(),
+		},
+		{
+			name:          "removes the audit webhook if the shoot is in deletion",
+			objects:       []client.Object{extension()},
+			shootDeletion: &now,
+			deployment:    mutatedKubeAPIServerDeployment(),
+		},
+		{
+			name:       "nothing to remove",
+			deployment: kubeAPIServerDeployment(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &ensurer{
+				client:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.objects...).Build(),
+				decoder: serializer.NewCodecFactory(scheme, serializer.EnableStrict).UniversalDecoder(),
+				logger:  logr.Discard(),
+			}
+
+			gctx := gcontext.NewInternalGardenContext(&extensions.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: namespace},
+				Shoot: &gardencorev1beta1.Shoot{
+					ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: tt.shootDeletion},
+				},
+			})
+
+			err := e.EnsureKubeAPIServerDeployment(context.Background(), gctx, tt.deployment, nil)
+			require.NoError(t, err)
+
+			want := kubeAPIServerDeployment()
+			if tt.wantMutations {
+				want = mutatedKubeAPIServerDeployment()
+				want.Spec.Template.Spec.Containers[0].Command[2] = tt.wantWebhookArg
+			}
+
+			assert.Equal(t, want, tt.deployment)
+		})
+	}
+}
+
+// kubeAPIServerDeployment returns the kube-apiserver deployment as it is deployed by gardener
+func kubeAPIServerDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"role": "apiserver"},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:         "kube-apiserver",
+							Command:      []string{"/usr/local/bin/kube-apiserver"},
+							VolumeMounts: []corev1.VolumeMount{{Name: "kube-apiserver-tls", MountPath: "/srv/kubernetes/apiserver"}},
+						},
+					},
+					Volumes: []corev1.Volume{{Name: "kube-apiserver-tls"}},
+				},
+			},
+		},
+	}
+}
+
+// mutatedKubeAPIServerDeployment returns the kube-apiserver deployment with all mutations of the ensurer
+func mutatedKubeAPIServerDeployment() *appsv1.Deployment {
+	d := kubeAPIServerDeployment()
+
+	d.Spec.Template.Labels[auditWebhookBackendNetworkLabel] = "allowed"
+
+	c := &d.Spec.Template.Spec.Containers[0]
+	c.Command = append(c.Command,
+		"--audit-webhook-config-file=/etc/audit-webhook/config/audit-webhook-config.yaml",
+		"--audit-webhook-mode=batch",
+	)
+	c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+		Name:      auditWebhookConfigVolumeName,
+		ReadOnly:  true,
+		MountPath: "/etc/audit-webhook/config",
+	})
+
+	d.Spec.Template.Spec.Volumes = append(d.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: auditWebhookConfigVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: "audit-webhook-config",
+			},
+		},
+	})
+
+	return d
+}
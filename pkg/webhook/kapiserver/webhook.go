@@ -38,6 +38,8 @@ func New(mgr manager.Manager) (*extensionswebhook.Webhook, error) {
 		return nil, err
 	}
 
+	// gardener labels the shoot namespaces that have the extension enabled, removing the extension from a shoot therefore
+	// also stops the mutations of the kube-apiserver deployment
 	namespaceSelector := &metav1.LabelSelector{
 		MatchExpressions: []metav1.LabelSelectorRequirement{
 			{Key: v1beta1constants.LabelExtensionPrefix + "audit", Operator: metav1.LabelSelectorOpIn, Values: []string{"true"}},
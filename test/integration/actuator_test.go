@@ -0,0 +1,160 @@
+//go:build integration
+
+package integration
+
+import (
+	"testing"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	resourcesv1alpha1 "github.com/gardener/gardener/pkg/apis/resources/v1alpha1"
+	secretsmanager "github.com/gardener/gardener/pkg/utils/secrets/manager"
+	"github.com/go-logr/logr"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/v1alpha1"
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-audit/pkg/controller/audit"
+)
+
+func TestActuator(t *testing.T) {
+	const namespace = "shoot--project--actuator"
+
+	var (
+		log      = logr.Discard()
+		actuator = audit.NewActuator(mgr, config.ControllerConfiguration{})
+		shoot    = &gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: "actuator", Namespace: "garden-project"},
+		}
+	)
+
+	createShootNamespace(t, namespace, shoot)
+	ex := createExtension(t, namespace, `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","backends":{"log":{"enabled":true},"clusterForwarding":{"enabled":true}}}`)
+
+	t.Run("reconcile", func(t *testing.T) {
+		require.NoError(t, actuator.Reconcile(ctx, log, ex))
+
+		seed := managedResourceManifests(t, namespace, v1alpha1.SeedAuditResourceName)
+		assert.Contains(t, seed, "statefulset__"+namespace+"__audit-webhook-backend.yaml")
+		assert.Contains(t, seed, "configmap__"+namespace+"__audit-fluent-bit-config.yaml")
+		assert.Contains(t, seed, "deployment__"+namespace+"__audit-cluster-forwarding-vpn-gateway.yaml")
+
+		shoot := managedResourceManifests(t, namespace, v1alpha1.ShootAuditResourceName)
+		assert.Contains(t, shoot, "deployment__audit__audittailer.yaml")
+		assert.Contains(t, shoot, "secret__audit__audittailer-server.yaml")
+
+		assert.Equal(t, int32(2), webhookBackendReplicas(t, seed, namespace))
+
+		// the certificates of the cluster forwarding are generated by the secrets manager
+		certificates := &corev1.SecretList{}
+		require.NoError(t, testCl.List(ctx, certificates, client.InNamespace(namespace), client.MatchingLabels{
+			secretsmanager.LabelKeyManagedBy:       secretsmanager.LabelValueSecretsManager,
+			secretsmanager.LabelKeyManagerIdentity: "audit",
+		}))
+		var names []string
+		for _, s := range certificates.Items {
+			names = append(names, s.Labels[secretsmanager.LabelKeyName])
+		}
+		assert.Subset(t, names, []string{"ca-audittailer", "audittailer-server", "audittailer-client"})
+
+		accessSecret := &corev1.Secret{}
+		require.NoError(t, testCl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "shoot-access-audit-cluster-forwarding-vpn-gateway"}, accessSecret))
+
+		require.NoError(t, testCl.Get(ctx, client.ObjectKeyFromObject(ex), ex))
+		require.NotNil(t, ex.Status.ProviderStatus)
+		assert.Contains(t, string(ex.Status.ProviderStatus.Raw), `"log"`)
+	})
+
+	t.Run("reconcile is idempotent", func(t *testing.T) {
+		before := managedResourceSecretRefs(t, namespace)
+
+		require.NoError(t, actuator.Reconcile(ctx, log, ex))
+
+		assert.Equal(t, before, managedResourceSecretRefs(t, namespace))
+	})
+
+	t.Run("hibernation", func(t *testing.T) {
+		hibernated := shoot.DeepCopy()
+		hibernated.Spec.Hibernation = &gardencorev1beta1.Hibernation{Enabled: pointer.Pointer(true)}
+		hibernated.Status.IsHibernated = true
+		updateShoot(t, namespace, hibernated)
+
+		require.NoError(t, actuator.Reconcile(ctx, log, ex))
+
+		seed := managedResourceManifests(t, namespace, v1alpha1.SeedAuditResourceName)
+		assert.Equal(t, int32(0), webhookBackendReplicas(t, seed, namespace))
+
+		updateShoot(t, namespace, shoot)
+
+		require.NoError(t, actuator.Reconcile(ctx, log, ex))
+
+		seed = managedResourceManifests(t, namespace, v1alpha1.SeedAuditResourceName)
+		assert.Equal(t, int32(2), webhookBackendReplicas(t, seed, namespace))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, actuator.Delete(ctx, log, ex))
+
+		for _, name := range []string{v1alpha1.SeedAuditResourceName, v1alpha1.ShootAuditResourceName} {
+			err := testCl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &resourcesv1alpha1.ManagedResource{})
+			assert.True(t, apierrors.IsNotFound(err), "managed resource %s is deleted", name)
+		}
+	})
+}
+
+// managedResourceManifests returns the manifests of the given managed resource keyed by their file name
+func managedResourceManifests(t *testing.T, namespace, name string) map[string][]byte {
+	t.Helper()
+
+	mr := &resourcesv1alpha1.ManagedResource{}
+	require.NoError(t, testCl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, mr))
+
+	manifests := map[string][]byte{}
+	for _, ref := range mr.Spec.SecretRefs {
+		secret := &corev1.Secret{}
+		require.NoError(t, testCl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret))
+
+		for key, value := range secret.Data {
+			manifests[key] = value
+		}
+	}
+
+	return manifests
+}
+
+// managedResourceSecretRefs returns the secrets referenced by the managed resources of the audit extension, they are
+// immutable and named after their content, so they only change when the manifests change
+func managedResourceSecretRefs(t *testing.T, namespace string) map[string][]string {
+	t.Helper()
+
+	refs := map[string][]string{}
+	for _, name := range []string{v1alpha1.SeedAuditResourceName, v1alpha1.ShootAuditResourceName} {
+		mr := &resourcesv1alpha1.ManagedResource{}
+		require.NoError(t, testCl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, mr))
+
+		for _, ref := range mr.Spec.SecretRefs {
+			refs[name] = append(refs[name], ref.Name)
+		}
+	}
+
+	return refs
+}
+
+func webhookBackendReplicas(t *testing.T, manifests map[string][]byte, namespace string) int32 {
+	t.Helper()
+
+	manifest, ok := manifests["statefulset__"+namespace+"__audit-webhook-backend.yaml"]
+	require.True(t, ok, "statefulset of the audit webhook backend is part of the manifests")
+
+	sts := &appsv1.StatefulSet{}
+	require.NoError(t, yaml.Unmarshal(manifest, sts))
+
+	return pointer.SafeDeref(sts.Spec.Replicas)
+}
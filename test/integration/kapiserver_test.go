@@ -0,0 +1,240 @@
+//go:build integration
+
+package integration
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const networkLabel = "networking.resources.gardener.cloud/to-audit-webhook-backend-tcp-9880"
+
+func TestKubeAPIServerWebhook(t *testing.T) {
+	const namespace = "shoot--project--kapiserver"
+
+	createShootNamespace(t, namespace, &gardencorev1beta1.Shoot{
+		ObjectMeta: metav1.ObjectMeta{Name: "kapiserver", Namespace: "garden-project"},
+	})
+	ex := createExtension(t, namespace, `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","webhookMode":"batch"}`)
+
+	deployment := kubeAPIServerDeployment(namespace)
+
+	t.Run("mutates the kube-apiserver", func(t *testing.T) {
+		eventually(t, func() error {
+			return testCl.Create(ctx, deployment)
+		})
+
+		require.NoError(t, testCl.Get(ctx, client.ObjectKeyFromObject(deployment), deployment))
+		assertAuditMutations(t, deployment, "batch")
+
+		container := deployment.Spec.Template.Spec.Containers[0]
+		assert.Contains(t, container.Command, "--enable-admission-plugins=NodeRestriction", "unrelated flags are kept")
+		assert.Len(t, deployment.Spec.Template.Spec.Volumes, 2, "unrelated volumes are kept")
+	})
+
+	t.Run("mutations are idempotent", func(t *testing.T) {
+		deployment.Spec.Template.Annotations = map[string]string{"reconciled": "again"}
+		require.NoError(t, testCl.Update(ctx, deployment))
+
+		require.NoError(t, testCl.Get(ctx, client.ObjectKeyFromObject(deployment), deployment))
+		assertAuditMutations(t, deployment, "batch")
+	})
+
+	t.Run("follows the webhook mode of the extension", func(t *testing.T) {
+		require.NoError(t, testCl.Get(ctx, client.ObjectKeyFromObject(ex), ex))
+		ex.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(`{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig","webhookMode":"blocking"}`)}
+		require.NoError(t, testCl.Update(ctx, ex))
+
+		deployment.Spec.Template.Annotations = map[string]string{"reconciled": "with-blocking-mode"}
+		require.NoError(t, testCl.Update(ctx, deployment))
+
+		require.NoError(t, testCl.Get(ctx, client.ObjectKeyFromObject(deployment), deployment))
+		assertAuditMutations(t, deployment, "blocking")
+	})
+
+	t.Run("removes the audit webhook when the extension is deleted", func(t *testing.T) {
+		require.NoError(t, testCl.Get(ctx, client.ObjectKeyFromObject(ex), ex))
+		ex.Finalizers = []string{"extensions.gardener.cloud/audit"}
+		require.NoError(t, testCl.Update(ctx, ex))
+		require.NoError(t, testCl.Delete(ctx, ex))
+
+		deployment.Spec.Template.Annotations = map[string]string{"reconciled": "extension-in-deletion"}
+		require.NoError(t, testCl.Update(ctx, deployment))
+
+		require.NoError(t, testCl.Get(ctx, client.ObjectKeyFromObject(deployment), deployment))
+		assertNoAuditMutations(t, deployment)
+
+		require.NoError(t, testCl.Get(ctx, client.ObjectKeyFromObject(ex), ex))
+		ex.Finalizers = nil
+		require.NoError(t, testCl.Update(ctx, ex))
+
+		deployment.Spec.Template.Annotations = map[string]string{"reconciled": "extension-removed"}
+		require.NoError(t, testCl.Update(ctx, deployment))
+
+		require.NoError(t, testCl.Get(ctx, client.ObjectKeyFromObject(deployment), deployment))
+		assertNoAuditMutations(t, deployment)
+		assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].Command, "--enable-admission-plugins=NodeRestriction", "unrelated flags are kept")
+		assert.Len(t, deployment.Spec.Template.Spec.Volumes, 1, "unrelated volumes are kept")
+	})
+
+	t.Run("other deployments are not mutated", func(t *testing.T) {
+		other := kubeAPIServerDeployment(namespace)
+		other.Name = "kube-controller-manager"
+		other.Spec.Template.Spec.Containers[0].Name = "kube-controller-manager"
+		require.NoError(t, testCl.Create(ctx, other))
+
+		require.NoError(t, testCl.Get(ctx, client.ObjectKeyFromObject(other), other))
+		assert.NotContains(t, other.Spec.Template.Labels, networkLabel)
+		for _, flag := range other.Spec.Template.Spec.Containers[0].Command {
+			assert.False(t, strings.HasPrefix(flag, "--audit-webhook"), "flag %q is not added", flag)
+		}
+	})
+}
+
+func TestKubeAPIServerWebhook_ExtensionRemovedFromShoot(t *testing.T) {
+	const namespace = "shoot--project--kapiserver-removed"
+
+	createShootNamespace(t, namespace, &gardencorev1beta1.Shoot{
+		ObjectMeta: metav1.ObjectMeta{Name: "kapiserver-removed", Namespace: "garden-project"},
+	})
+	createExtension(t, namespace, `{"apiVersion":"audit.metal.extensions.gardener.cloud/v1alpha1","kind":"AuditConfig"}`)
+
+	deployment := kubeAPIServerDeployment(namespace)
+	eventually(t, func() error {
+		return testCl.Create(ctx, deployment)
+	})
+	require.NoError(t, testCl.Get(ctx, client.ObjectKeyFromObject(deployment), deployment))
+	assertAuditMutations(t, deployment, "blocking-strict")
+
+	// gardener removes the extension label from the shoot namespace when the extension is removed from the shoot, such
+	// that the namespace is not selected by the webhook anymore. the extension resource is left in place, so the
+	// mutations can only be gone because the webhook is not called anymore.
+	shootNamespace := &corev1.Namespace{}
+	require.NoError(t, testCl.Get(ctx, client.ObjectKey{Name: namespace}, shootNamespace))
+	delete(shootNamespace.Labels, v1beta1constants.LabelExtensionPrefix+"audit")
+	require.NoError(t, testCl.Update(ctx, shootNamespace))
+
+	// the webhook does not remove the mutations on its own, they are gone once gardenlet renders the kube-apiserver
+	// deployment again. the api server needs a moment to notice the changed namespace labels.
+	eventually(t, func() error {
+		if err := testCl.Get(ctx, client.ObjectKeyFromObject(deployment), deployment); err != nil {
+			return err
+		}
+		deployment.Spec.Template = kubeAPIServerDeployment(namespace).Spec.Template
+		if err := testCl.Update(ctx, deployment); err != nil {
+			return err
+		}
+		if _, ok := deployment.Spec.Template.Labels[networkLabel]; ok {
+			return fmt.Errorf("kube-apiserver deployment is still mutated")
+		}
+		return nil
+	})
+	assertNoAuditMutations(t, deployment)
+	assert.Equal(t, kubeAPIServerDeployment(namespace).Spec.Template.Spec.Containers[0].Command, deployment.Spec.Template.Spec.Containers[0].Command)
+}
+
+// assertAuditMutations checks that the audit webhook is configured exactly once in the kube-apiserver deployment
+func assertAuditMutations(t *testing.T, deployment *appsv1.Deployment, webhookMode string) {
+	t.Helper()
+
+	assert.Equal(t, "allowed", deployment.Spec.Template.Labels[networkLabel])
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+
+	var auditFlags []string
+	for _, flag := range container.Command {
+		if strings.HasPrefix(flag, "--audit-webhook") {
+			auditFlags = append(auditFlags, flag)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"--audit-webhook-config-file=/etc/audit-webhook/config/audit-webhook-config.yaml",
+		"--audit-webhook-mode=" + webhookMode,
+	}, auditFlags)
+
+	var mounts []corev1.VolumeMount
+	for _, m := range container.VolumeMounts {
+		if m.Name == "audit-webhook-config" {
+			mounts = append(mounts, m)
+		}
+	}
+	assert.Equal(t, []corev1.VolumeMount{{Name: "audit-webhook-config", ReadOnly: true, MountPath: "/etc/audit-webhook/config"}}, mounts)
+
+	var volumes []corev1.Volume
+	for _, v := range deployment.Spec.Template.Spec.Volumes {
+		if v.Name == "audit-webhook-config" {
+			volumes = append(volumes, v)
+		}
+	}
+	require.Len(t, volumes, 1)
+	require.NotNil(t, volumes[0].Secret)
+	assert.Equal(t, "audit-webhook-config", volumes[0].Secret.SecretName)
+}
+
+// assertNoAuditMutations checks that nothing of the audit webhook is left in the kube-apiserver deployment
+func assertNoAuditMutations(t *testing.T, deployment *appsv1.Deployment) {
+	t.Helper()
+
+	assert.NotContains(t, deployment.Spec.Template.Labels, networkLabel)
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	for _, flag := range container.Command {
+		assert.False(t, strings.HasPrefix(flag, "--audit-webhook"), "flag %q is removed", flag)
+	}
+	for _, m := range container.VolumeMounts {
+		assert.NotEqual(t, "audit-webhook-config", m.Name, "volume mount is removed")
+	}
+	for _, v := range deployment.Spec.Template.Spec.Volumes {
+		assert.NotEqual(t, "audit-webhook-config", v.Name, "volume is removed")
+	}
+}
+
+// kubeAPIServerDeployment returns a minimal kube-apiserver deployment as it is deployed by gardener before the
+// extension mutates it
+func kubeAPIServerDeployment(namespace string) *appsv1.Deployment {
+	labels := map[string]string{"app": "kubernetes", "role": "apiserver"}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver", Namespace: namespace},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "kube-apiserver",
+							Image: "registry.k8s.io/kube-apiserver:v1.29.5",
+							Command: []string{
+								"/usr/local/bin/kube-apiserver",
+								"--enable-admission-plugins=NodeRestriction",
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "kube-apiserver-tls", MountPath: "/srv/kubernetes/apiserver"},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "kube-apiserver-tls",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{SecretName: "kube-apiserver"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,238 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	extensionscontroller "github.com/gardener/gardener/extensions/pkg/controller"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"github.com/metal-stack/gardener-extension-audit/pkg/apis/audit/install"
+	"github.com/metal-stack/gardener-extension-audit/pkg/webhook/kapiserver"
+)
+
+// the suite runs the actuator and the kapiserver webhook against a local kube-apiserver and etcd started by envtest.
+// the binaries are looked up in KUBEBUILDER_ASSETS, see the test-integration target of the Makefile.
+var (
+	ctx    = context.Background()
+	mgr    manager.Manager
+	testCl client.Client
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	logf.SetLogger(zap.New(zap.UseDevMode(true), zap.WriteTo(os.Stderr)))
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("testdata", "crds")},
+		ErrorIfCRDPathMissing: true,
+		WebhookInstallOptions: envtest.WebhookInstallOptions{
+			MutatingWebhooks: []*admissionregistrationv1.MutatingWebhookConfiguration{kapiserverWebhookConfiguration()},
+		},
+	}
+
+	restConfig, err := testEnv.Start()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to start test environment: %s\n", err)
+		return 1
+	}
+	defer func() {
+		if err := testEnv.Stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to stop test environment: %s\n", err)
+		}
+	}()
+
+	scheme := runtime.NewScheme()
+	if err := extensionscontroller.AddToScheme(scheme); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := install.AddToScheme(scheme); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	mgr, err = manager.New(restConfig, manager.Options{
+		Scheme:  scheme,
+		Metrics: metricsserver.Options{BindAddress: "0"},
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Host:    testEnv.WebhookInstallOptions.LocalServingHost,
+			Port:    testEnv.WebhookInstallOptions.LocalServingPort,
+			CertDir: testEnv.WebhookInstallOptions.LocalServingCertDir,
+		}),
+		// the tests read what they have just written, so the client of the manager must not be served from a cache
+		NewClient: func(config *rest.Config, options client.Options) (client.Client, error) {
+			return client.New(config, options)
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create manager: %s\n", err)
+		return 1
+	}
+
+	wh, err := kapiserver.New(mgr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create kapiserver webhook: %s\n", err)
+		return 1
+	}
+	mgr.GetWebhookServer().Register("/"+wh.Path, wh.Webhook)
+
+	mgrCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		if err := mgr.Start(mgrCtx); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to start manager: %s\n", err)
+		}
+	}()
+
+	testCl = mgr.GetClient()
+
+	return m.Run()
+}
+
+// kapiserverWebhookConfiguration registers the kapiserver webhook like gardener does for the seed, envtest replaces
+// the service by the local webhook server
+func kapiserverWebhookConfiguration() *admissionregistrationv1.MutatingWebhookConfiguration {
+	return &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "gardener-extension-audit"},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{
+			{
+				Name: "kapiserver.audit.extensions.gardener.cloud",
+				Rules: []admissionregistrationv1.RuleWithOperations{
+					{
+						Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{"apps"},
+							APIVersions: []string{"v1"},
+							Resources:   []string{"deployments"},
+						},
+					},
+				},
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{v1beta1constants.LabelExtensionPrefix + "audit": "true"},
+				},
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service: &admissionregistrationv1.ServiceReference{
+						Name:      "gardener-extension-audit",
+						Namespace: "default",
+						Path:      pointer.Pointer("kapiserver"),
+					},
+				},
+				FailurePolicy:           pointer.Pointer(admissionregistrationv1.Fail),
+				SideEffects:             pointer.Pointer(admissionregistrationv1.SideEffectClassNone),
+				AdmissionReviewVersions: []string{"v1"},
+				TimeoutSeconds:          pointer.Pointer(int32(10)),
+			},
+		},
+	}
+}
+
+// createShootNamespace creates a shoot namespace of the seed with the cluster resource of the given shoot, the
+// namespace is deleted when the test finishes
+func createShootNamespace(t *testing.T, name string, shoot *gardencorev1beta1.Shoot) {
+	t.Helper()
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{v1beta1constants.LabelExtensionPrefix + "audit": "true"},
+		},
+	}
+	require.NoError(t, testCl.Create(ctx, namespace))
+	t.Cleanup(func() {
+		_ = testCl.Delete(ctx, namespace)
+	})
+
+	cluster := &extensionsv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+	setShoot(t, cluster, shoot)
+	require.NoError(t, testCl.Create(ctx, cluster))
+	t.Cleanup(func() {
+		_ = testCl.Delete(ctx, cluster)
+	})
+}
+
+// updateShoot replaces the shoot in the cluster resource of the given shoot namespace
+func updateShoot(t *testing.T, name string, shoot *gardencorev1beta1.Shoot) {
+	t.Helper()
+
+	cluster := &extensionsv1alpha1.Cluster{}
+	require.NoError(t, testCl.Get(ctx, client.ObjectKey{Name: name}, cluster))
+	setShoot(t, cluster, shoot)
+	require.NoError(t, testCl.Update(ctx, cluster))
+}
+
+func setShoot(t *testing.T, cluster *extensionsv1alpha1.Cluster, shoot *gardencorev1beta1.Shoot) {
+	t.Helper()
+
+	shoot = shoot.DeepCopy()
+	shoot.SetGroupVersionKind(gardencorev1beta1.SchemeGroupVersion.WithKind("Shoot"))
+
+	raw, err := json.Marshal(shoot)
+	require.NoError(t, err)
+
+	cluster.Spec.Shoot = runtime.RawExtension{Raw: raw}
+	cluster.Spec.Seed = runtime.RawExtension{Raw: []byte(`{"apiVersion":"core.gardener.cloud/v1beta1","kind":"Seed"}`)}
+	cluster.Spec.CloudProfile = runtime.RawExtension{Raw: []byte(`{"apiVersion":"core.gardener.cloud/v1beta1","kind":"CloudProfile"}`)}
+}
+
+// createExtension creates the audit extension with the given provider config in the shoot namespace
+func createExtension(t *testing.T, namespace, providerConfig string) *extensionsv1alpha1.Extension {
+	t.Helper()
+
+	ex := &extensionsv1alpha1.Extension{
+		ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: namespace},
+		Spec: extensionsv1alpha1.ExtensionSpec{
+			DefaultSpec: extensionsv1alpha1.DefaultSpec{
+				Type:           "audit",
+				ProviderConfig: &runtime.RawExtension{Raw: []byte(providerConfig)},
+			},
+		},
+	}
+	require.NoError(t, testCl.Create(ctx, ex))
+
+	return ex
+}
+
+// eventually retries the given function until it succeeds, the webhook server needs a moment to come up
+func eventually(t *testing.T, f func() error) {
+	t.Helper()
+
+	var err error
+	for i := 0; i < 50; i++ {
+		if err = f(); err == nil {
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	require.NoError(t, err)
+}